@@ -2,33 +2,65 @@
 package config
 
 import (
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/farmops/farmops/pkg/transport"
 )
 
 // Config holds all agent configuration.
 type Config struct {
 	// AgentID is the stable UUID identifying this agent instance.
-	// Generated on first boot and persisted; do not change after enrollment.
+	// Generated on first boot and persisted when Credentials is set; do not
+	// change after enrollment.
 	AgentID string `yaml:"agent_id"`
 
 	// ClusterAlias is a user-chosen label for this cluster (e.g. "prod-eu-1").
 	// This is the ONLY cluster identifier that appears in proofs — no real hostnames.
+	// A join token may preset it instead.
 	ClusterAlias string `yaml:"cluster_alias"`
 
 	// TrackerURL is the base URL of the Stats Tracker (e.g. "https://my-tracker.local:8443").
 	TrackerURL string `yaml:"tracker_url"`
 
-	// APIKey is the shared secret used to authenticate with the Stats Tracker.
+	// APIKey is the agent-scoped token used to authenticate with the Stats Tracker
+	// (minted with `farmctl token create -scope agent -agent-id <agent_id>`).
 	// Set via FARMOPS_API_KEY env var or directly in config (not recommended).
 	APIKey string `yaml:"api_key"`
 
+	// JoinToken is a single-use token from `farmctl join-token create`. An agent
+	// without an api_key uses it on first boot to enroll itself and obtain one.
+	// Set via FARMOPS_JOIN_TOKEN env var.
+	JoinToken string `yaml:"join_token"`
+
+	// ActorPepper is the tracker's hex-encoded actor hashing pepper, which keys
+	// the actor hashes in proofs. Usually left empty: a joining agent receives
+	// it from the tracker and keeps it with its credentials, and other agents
+	// fetch it from the tracker at startup.
+	ActorPepper string `yaml:"actor_pepper"`
+
+	// Credentials is where the agent persists its generated identity: agent_id,
+	// signing key, actor pepper and the token obtained by joining.
+	Credentials CredentialsConfig `yaml:"credentials"`
+
+	// TLS configures the connection to the tracker: the CA that signed its
+	// certificate and, for mutual TLS, this agent's client certificate.
+	TLS TLSConfig `yaml:"tls"`
+
 	// PrivateKeyHex is the hex-encoded Ed25519 private key used to sign proofs.
-	// Set via FARMOPS_PRIVATE_KEY env var or directly in config (not recommended).
+	// Private keys are never read from the environment; prefer Signer, which
+	// keeps the key out of the config too.
 	PrivateKeyHex string `yaml:"private_key"`
 
+	// Signer loads the signing key from an encrypted key file or a Kubernetes
+	// Secret, or signs with a key that never leaves Vault.
+	Signer SignerConfig `yaml:"signer"`
+
 	// Kubeconfig is the path to the kubeconfig file.
 	// Leave empty to use in-cluster config (default when running inside k8s).
 	Kubeconfig string `yaml:"kubeconfig"`
@@ -36,11 +68,100 @@ type Config struct {
 	// Plugins lists the plugin binaries or sidecar addresses to load.
 	Plugins []PluginConfig `yaml:"plugins"`
 
+	// Evidence keeps the raw evidence behind each proof in an encrypted local
+	// vault, so a single proof can be disclosed with `farmctl proof disclose`.
+	Evidence EvidenceConfig `yaml:"evidence"`
+
 	// ProofBufferPath is the path to the local BoltDB proof buffer.
 	// Proofs are buffered here if the tracker is temporarily unreachable.
 	ProofBufferPath string `yaml:"proof_buffer_path"`
 }
 
+// TLSConfig names the PEM files used to reach the tracker over TLS.
+type TLSConfig struct {
+	CAFile   string `yaml:"ca_file"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// Client builds the TLS config for reaching the tracker, or nil for defaults.
+func (c TLSConfig) Client() (*tls.Config, error) {
+	return transport.TLSFiles{CAFile: c.CAFile, CertFile: c.CertFile, KeyFile: c.KeyFile}.Config()
+}
+
+// CredentialsConfig selects where the agent identity is stored: a local file
+// or a Kubernetes Secret. At most one may be set.
+type CredentialsConfig struct {
+	// File is a JSON file, written with mode 0600.
+	File string `yaml:"file"`
+
+	// Secret is the name of a Kubernetes Secret.
+	Secret string `yaml:"secret"`
+
+	// Namespace holds Secret. Defaults to the agent pod's own namespace.
+	Namespace string `yaml:"namespace"`
+
+	// RotateAfter rotates the stored signing key once it is this old
+	// (e.g. "2160h"), by submitting a key rotation proof. Zero disables rotation.
+	RotateAfter time.Duration `yaml:"rotate_after"`
+}
+
+// Enabled reports whether a credentials store is configured.
+func (c CredentialsConfig) Enabled() bool {
+	return c.File != "" || c.Secret != ""
+}
+
+// SignerConfig selects where the proof signing key lives. At most one of
+// KeyFile, Secret and Vault may be set.
+type SignerConfig struct {
+	// KeyFile is a passphrase-encrypted PKCS#8 PEM key, as written by
+	// `farmctl agent keygen -out` or `openssl genpkey -algorithm ed25519 -aes256`.
+	KeyFile string `yaml:"key_file"`
+
+	// PassphraseFile holds the passphrase of KeyFile.
+	PassphraseFile string `yaml:"passphrase_file"`
+
+	// Secret is the name of a Kubernetes Secret holding the hex-encoded key
+	// under SecretKey (default "private-key").
+	Secret    string `yaml:"secret"`
+	SecretKey string `yaml:"secret_key"`
+
+	// Namespace holds Secret. Defaults to the agent pod's own namespace.
+	Namespace string `yaml:"namespace"`
+
+	// Vault signs with an ed25519 key in Vault's transit engine.
+	Vault VaultConfig `yaml:"vault"`
+}
+
+// VaultConfig locates a Vault transit key.
+type VaultConfig struct {
+	Address string `yaml:"address"`
+
+	// TokenFile holds the Vault token. Defaults to the VAULT_TOKEN env var.
+	TokenFile string `yaml:"token_file"`
+
+	Namespace  string `yaml:"namespace"`   // Vault Enterprise namespace
+	Mount      string `yaml:"mount"`       // transit mount path, default "transit"
+	Key        string `yaml:"key"`         // transit key name
+	KeyVersion int    `yaml:"key_version"` // 0 signs with the latest version
+}
+
+// Enabled reports whether a signer is configured.
+func (c SignerConfig) Enabled() bool {
+	return c.KeyFile != "" || c.Secret != "" || c.Vault.Address != ""
+}
+
+// EvidenceConfig locates the evidence vault.
+type EvidenceConfig struct {
+	// VaultDir holds one encrypted file per proof.
+	VaultDir string `yaml:"vault_dir"`
+
+	// KeyFile holds the hex-encoded AES-256 vault key. It is generated, with
+	// mode 0600, if it does not exist. Without it the evidence cannot be
+	// disclosed, so back it up or mount it from a Secret.
+	KeyFile string `yaml:"key_file"`
+}
+
 // PluginConfig describes a single plugin to load.
 type PluginConfig struct {
 	// ID is the plugin identifier, e.g. "farmops/k8s-pod-health".
@@ -67,12 +188,16 @@ func Load(path string) (*Config, error) {
 	if v := os.Getenv("FARMOPS_API_KEY"); v != "" {
 		cfg.APIKey = v
 	}
-	if v := os.Getenv("FARMOPS_PRIVATE_KEY"); v != "" {
-		cfg.PrivateKeyHex = v
+	if os.Getenv("FARMOPS_PRIVATE_KEY") != "" {
+		return nil, fmt.Errorf("config: FARMOPS_PRIVATE_KEY is no longer read, as private keys must not be kept in environment variables; " +
+			"unset it and configure signer, credentials or private_key instead")
 	}
 	if v := os.Getenv("FARMOPS_TRACKER_URL"); v != "" {
 		cfg.TrackerURL = v
 	}
+	if v := os.Getenv("FARMOPS_JOIN_TOKEN"); v != "" {
+		cfg.JoinToken = v
+	}
 
 	if err := cfg.validate(); err != nil {
 		return nil, err
@@ -81,24 +206,70 @@ func Load(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// validate checks the config as loaded. With a credentials store, agent_id,
+// private_key and api_key may be missing here; they are loaded, generated or
+// obtained by joining before the agent starts.
 func (c *Config) validate() error {
-	if c.AgentID == "" {
+	stored := c.Credentials.Enabled()
+	if c.Credentials.File != "" && c.Credentials.Secret != "" {
+		return fmt.Errorf("config: set only one of credentials.file and credentials.secret")
+	}
+	if c.JoinToken != "" && !stored {
+		return fmt.Errorf("config: join_token needs credentials.file or credentials.secret to keep the joined identity")
+	}
+	if c.AgentID == "" && !stored {
 		return fmt.Errorf("config: agent_id is required")
 	}
-	if c.ClusterAlias == "" {
+	if c.ClusterAlias == "" && !stored {
 		return fmt.Errorf("config: cluster_alias is required")
 	}
 	if c.TrackerURL == "" {
 		return fmt.Errorf("config: tracker_url is required (or set FARMOPS_TRACKER_URL)")
 	}
-	if c.APIKey == "" {
-		return fmt.Errorf("config: api_key is required (or set FARMOPS_API_KEY)")
+	if c.APIKey == "" && !stored {
+		return fmt.Errorf("config: api_key is required (or set FARMOPS_API_KEY, or use join_token)")
+	}
+	if _, err := hex.DecodeString(c.ActorPepper); err != nil {
+		return fmt.Errorf("config: actor_pepper must be hex-encoded")
+	}
+	if err := c.Signer.validate(); err != nil {
+		return err
 	}
-	if c.PrivateKeyHex == "" {
-		return fmt.Errorf("config: private_key is required (or set FARMOPS_PRIVATE_KEY)")
+	if c.Signer.Enabled() && c.PrivateKeyHex != "" {
+		return fmt.Errorf("config: set only one of private_key and signer")
+	}
+	if c.PrivateKeyHex == "" && !c.Signer.Enabled() && !stored {
+		return fmt.Errorf("config: private_key is required (or configure signer or credentials)")
+	}
+	if c.Credentials.RotateAfter != 0 && (!stored || c.PrivateKeyHex != "" || c.Signer.Enabled()) {
+		return fmt.Errorf("config: credentials.rotate_after needs the signing key in the credentials store, not in private_key or signer")
 	}
 	if c.ProofBufferPath == "" {
 		c.ProofBufferPath = "/var/lib/farmops-agent/proofs.db"
 	}
+	if c.Evidence.VaultDir == "" {
+		c.Evidence.VaultDir = "/var/lib/farmops-agent/evidence"
+	}
+	if c.Evidence.KeyFile == "" {
+		c.Evidence.KeyFile = "/var/lib/farmops-agent/evidence.key"
+	}
+	return nil
+}
+
+func (c SignerConfig) validate() error {
+	set := 0
+	for _, v := range []string{c.KeyFile, c.Secret, c.Vault.Address} {
+		if v != "" {
+			set++
+		}
+	}
+	switch {
+	case set > 1:
+		return fmt.Errorf("config: set only one of signer.key_file, signer.secret and signer.vault")
+	case c.KeyFile != "" && c.PassphraseFile == "":
+		return fmt.Errorf("config: signer.key_file needs signer.passphrase_file")
+	case c.Vault.Address != "" && c.Vault.Key == "":
+		return fmt.Errorf("config: signer.vault.key is required")
+	}
 	return nil
 }
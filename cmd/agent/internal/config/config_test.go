@@ -0,0 +1,52 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/farmops/farmops/cmd/agent/internal/config"
+)
+
+const minimal = `
+agent_id: a1
+cluster_alias: test
+tracker_url: http://localhost:8443
+api_key: fa_token
+private_key: "00"
+`
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_EnvironmentOverrides(t *testing.T) {
+	t.Setenv("FARMOPS_API_KEY", "fa_from_env")
+	t.Setenv("FARMOPS_TRACKER_URL", "https://tracker.example")
+
+	cfg, err := config.Load(writeConfig(t, minimal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIKey != "fa_from_env" || cfg.TrackerURL != "https://tracker.example" {
+		t.Errorf("api_key %q, tracker_url %q; want the environment's", cfg.APIKey, cfg.TrackerURL)
+	}
+	if cfg.PrivateKeyHex != "00" {
+		t.Errorf("private_key %q, want the config's", cfg.PrivateKeyHex)
+	}
+}
+
+func TestLoad_RefusesPrivateKeyInEnvironment(t *testing.T) {
+	t.Setenv("FARMOPS_PRIVATE_KEY", "00")
+
+	_, err := config.Load(writeConfig(t, minimal))
+	if err == nil || !strings.Contains(err.Error(), "FARMOPS_PRIVATE_KEY") {
+		t.Errorf("Load with FARMOPS_PRIVATE_KEY set: err = %v, want it refused", err)
+	}
+}
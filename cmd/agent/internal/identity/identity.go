@@ -0,0 +1,133 @@
+// Package identity keeps the agent's identity — its agent_id, signing key and
+// tracker token — and bootstraps it on first boot. A self-enrolling agent
+// generates its keypair, joins the tracker with a one-time join token, and
+// persists the result to a local file or Kubernetes Secret so that restarts
+// reuse it.
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/farmops/farmops/cmd/agent/internal/config"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+// ErrNotFound is returned by Store.Load when nothing has been saved yet.
+var ErrNotFound = errors.New("identity: no stored credentials")
+
+// Credentials is the persisted agent identity.
+type Credentials struct {
+	AgentID      string    `json:"agent_id"`
+	ClusterAlias string    `json:"cluster_alias,omitempty"`
+	PrivateKey   string    `json:"private_key"` // hex-encoded Ed25519 private key
+	KeyCreatedAt time.Time `json:"key_created_at"`
+	APIKey       string    `json:"api_key,omitempty"`
+	ActorPepper  string    `json:"actor_pepper,omitempty"` // hex-encoded, from the tracker
+
+	// PendingPrivateKey is the next signing key while its rotation proof,
+	// PendingProofID, is in flight. See Rotator.
+	PendingPrivateKey string `json:"pending_private_key,omitempty"`
+	PendingProofID    string `json:"pending_proof_id,omitempty"`
+}
+
+// Store loads and saves agent credentials.
+type Store interface {
+	Load(ctx context.Context) (*Credentials, error)
+	Save(ctx context.Context, c *Credentials) error
+}
+
+// Bootstrap completes cfg from store, generating an agent_id and signing key
+// if there are none and joining the tracker if there is no API key yet.
+// Values set in the config file or environment take precedence over stored
+// ones. Generated values are saved before the tracker is contacted, so a
+// failed join can be retried with the same identity. With a non-nil signer,
+// no key is generated and the agent joins with the signer's public key.
+func Bootstrap(ctx context.Context, cfg *config.Config, store Store, client *transport.TrackerClient, signer proof.Signer, log *slog.Logger) error {
+	creds, err := store.Load(ctx)
+	if errors.Is(err, ErrNotFound) {
+		creds = &Credentials{}
+	} else if err != nil {
+		return err
+	}
+
+	changed := false
+	if cfg.AgentID == "" {
+		if creds.AgentID == "" {
+			creds.AgentID = uuid.NewString()
+			changed = true
+			log.Info("identity: generated agent_id", "agent_id", creds.AgentID)
+		}
+		cfg.AgentID = creds.AgentID
+	}
+	if cfg.PrivateKeyHex == "" && signer == nil {
+		if creds.PrivateKey == "" {
+			_, priv, err := proof.GenerateKeyPair()
+			if err != nil {
+				return fmt.Errorf("identity: %w", err)
+			}
+			creds.PrivateKey, creds.KeyCreatedAt = proof.EncodePrivateKey(priv), time.Now().UTC()
+			changed = true
+			log.Info("identity: generated signing key")
+		}
+		cfg.PrivateKeyHex = creds.PrivateKey
+	}
+	if cfg.ClusterAlias == "" {
+		cfg.ClusterAlias = creds.ClusterAlias
+	}
+	if cfg.APIKey == "" {
+		cfg.APIKey = creds.APIKey
+	}
+	if cfg.ActorPepper == "" {
+		cfg.ActorPepper = creds.ActorPepper
+	}
+	if changed {
+		if err := store.Save(ctx, creds); err != nil {
+			return err
+		}
+	}
+	if cfg.APIKey != "" {
+		return nil
+	}
+
+	if cfg.JoinToken == "" {
+		return fmt.Errorf("identity: no api_key stored or configured, and no join_token to enroll with")
+	}
+	if signer == nil {
+		priv, err := proof.DecodePrivateKey(cfg.PrivateKeyHex)
+		if err != nil {
+			return fmt.Errorf("identity: decode private key: %w", err)
+		}
+		signer = proof.KeySigner(priv)
+	}
+	pub, err := signer.PublicKey(ctx)
+	if err != nil {
+		return fmt.Errorf("identity: %w", err)
+	}
+	res, err := client.Join(ctx, cfg.JoinToken, cfg.AgentID, cfg.ClusterAlias, proof.EncodePublicKey(pub))
+	if err != nil {
+		return fmt.Errorf("identity: join tracker: %w", err)
+	}
+
+	cfg.APIKey, cfg.ClusterAlias = res.Token, res.ClusterAlias
+	creds.APIKey, creds.ClusterAlias = res.Token, res.ClusterAlias
+	if cfg.ActorPepper == "" {
+		cfg.ActorPepper = res.ActorPepper
+	}
+	creds.ActorPepper = res.ActorPepper
+	if err := store.Save(ctx, creds); err != nil {
+		return err
+	}
+	log.Info("identity: joined tracker", "agent_id", res.AgentID, "cluster_alias", res.ClusterAlias, "status", res.Status)
+	if res.Status != "active" {
+		log.Warn("identity: agent awaits approval; proofs are rejected until then",
+			"approve_with", "farmctl agent approve "+res.AgentID)
+	}
+	return nil
+}
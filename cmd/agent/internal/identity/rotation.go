@@ -0,0 +1,102 @@
+package identity
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+// Rotator replaces the agent's signing key once it is older than After, by
+// submitting a key rotation proof signed with the current key.
+//
+// The next key is saved as pending before its rotation proof is submitted,
+// so a crash or network error never loses a key the tracker has accepted:
+// on the next attempt, a pending rotation found at the head of the chain is
+// completed, and one that never arrived is discarded.
+type Rotator struct {
+	Store  Store
+	Client *transport.TrackerClient
+	After  time.Duration
+	Log    *slog.Logger
+}
+
+// RotateIfDue rotates the key if it is due. pepper is the tracker's actor
+// hashing pepper and head the agent's latest proof on the tracker (nil before
+// the first). It returns the new private key, or nil if the key was not
+// rotated.
+func (r *Rotator) RotateIfDue(ctx context.Context, agent proof.AgentInfo, pepper []byte, head *proof.FarmProof) (ed25519.PrivateKey, error) {
+	creds, err := r.Store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+
+	if creds.PendingProofID != "" {
+		if head != nil && head.ProofID == creds.PendingProofID {
+			r.Log.Info("identity: completing interrupted key rotation", "proof_id", creds.PendingProofID)
+			return r.promote(ctx, creds, now)
+		}
+		creds.PendingPrivateKey, creds.PendingProofID = "", ""
+		if err := r.Store.Save(ctx, creds); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keys stored before rotation was enabled start their clock now.
+	if creds.KeyCreatedAt.IsZero() {
+		creds.KeyCreatedAt = now
+		return nil, r.Store.Save(ctx, creds)
+	}
+	if now.Sub(creds.KeyCreatedAt) < r.After {
+		return nil, nil
+	}
+
+	current, err := proof.DecodePrivateKey(creds.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("identity: decode private key: %w", err)
+	}
+	nextPub, next, err := proof.GenerateKeyPair()
+	if err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	p, err := proof.NewKeyRotation(agent, nextPub, pepper, head)
+	if err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	if err := proof.Sign(p, current); err != nil {
+		return nil, fmt.Errorf("identity: sign rotation proof: %w", err)
+	}
+
+	creds.PendingPrivateKey, creds.PendingProofID = proof.EncodePrivateKey(next), p.ProofID
+	if err := r.Store.Save(ctx, creds); err != nil {
+		return nil, err
+	}
+	resp, err := r.Client.SubmitProof(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("identity: submit rotation proof: %w", err)
+	}
+	if !resp.Accepted {
+		return nil, fmt.Errorf("identity: rotation proof rejected: %s", resp.RejectionReason)
+	}
+	r.Log.Info("identity: rotated signing key", "proof_id", p.ProofID, "previous_key_age", now.Sub(creds.KeyCreatedAt).Round(time.Hour).String())
+	return r.promote(ctx, creds, now)
+}
+
+// promote makes the pending key current.
+func (r *Rotator) promote(ctx context.Context, creds *Credentials, now time.Time) (ed25519.PrivateKey, error) {
+	next, err := proof.DecodePrivateKey(creds.PendingPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("identity: decode pending private key: %w", err)
+	}
+	creds.PrivateKey, creds.KeyCreatedAt = creds.PendingPrivateKey, now
+	creds.PendingPrivateKey, creds.PendingProofID = "", ""
+	if err := r.Store.Save(ctx, creds); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
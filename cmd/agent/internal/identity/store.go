@@ -0,0 +1,135 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// FileStore keeps credentials in a local JSON file readable only by its owner.
+type FileStore struct {
+	Path string
+}
+
+func (s FileStore) Load(_ context.Context) (*Credentials, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("identity: read %s: %w", s.Path, err)
+	}
+	var c Credentials
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("identity: parse %s: %w", s.Path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the file atomically, so a crash never leaves a half-written key.
+func (s FileStore) Save(_ context.Context, c *Credentials) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("identity: marshal credentials: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return fmt.Errorf("identity: %w", err)
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("identity: write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		return fmt.Errorf("identity: %w", err)
+	}
+	return nil
+}
+
+// Keys of the Kubernetes Secret data.
+const (
+	secretKeyAgentID      = "agent-id"
+	secretKeyClusterAlias = "cluster-alias"
+	secretKeyPrivateKey   = "private-key"
+	secretKeyKeyCreatedAt = "key-created-at"
+	secretKeyAPIKey       = "api-key"
+	secretKeyActorPepper  = "actor-pepper"
+	secretKeyPendingKey   = "pending-private-key"
+	secretKeyPendingProof = "pending-proof-id"
+)
+
+// SecretStore keeps credentials in a Kubernetes Secret. The agent's service
+// account needs get, create and update on it.
+type SecretStore struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+}
+
+func (s SecretStore) Load(ctx context.Context) (*Credentials, error) {
+	secret, err := s.Client.CoreV1().Secrets(s.Namespace).Get(ctx, s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("identity: get secret %s/%s: %w", s.Namespace, s.Name, err)
+	}
+	c := &Credentials{
+		AgentID:           string(secret.Data[secretKeyAgentID]),
+		ClusterAlias:      string(secret.Data[secretKeyClusterAlias]),
+		PrivateKey:        string(secret.Data[secretKeyPrivateKey]),
+		APIKey:            string(secret.Data[secretKeyAPIKey]),
+		ActorPepper:       string(secret.Data[secretKeyActorPepper]),
+		PendingPrivateKey: string(secret.Data[secretKeyPendingKey]),
+		PendingProofID:    string(secret.Data[secretKeyPendingProof]),
+	}
+	if v := secret.Data[secretKeyKeyCreatedAt]; len(v) > 0 {
+		if c.KeyCreatedAt, err = time.Parse(time.RFC3339, string(v)); err != nil {
+			return nil, fmt.Errorf("identity: secret %s/%s: %s: %w", s.Namespace, s.Name, secretKeyKeyCreatedAt, err)
+		}
+	}
+	return c, nil
+}
+
+func (s SecretStore) Save(ctx context.Context, c *Credentials) error {
+	data := map[string][]byte{
+		secretKeyAgentID:      []byte(c.AgentID),
+		secretKeyClusterAlias: []byte(c.ClusterAlias),
+		secretKeyPrivateKey:   []byte(c.PrivateKey),
+		secretKeyAPIKey:       []byte(c.APIKey),
+		secretKeyActorPepper:  []byte(c.ActorPepper),
+		secretKeyPendingKey:   []byte(c.PendingPrivateKey),
+		secretKeyPendingProof: []byte(c.PendingProofID),
+	}
+	if !c.KeyCreatedAt.IsZero() {
+		data[secretKeyKeyCreatedAt] = []byte(c.KeyCreatedAt.Format(time.RFC3339))
+	}
+	secrets := s.Client.CoreV1().Secrets(s.Namespace)
+	secret, err := secrets.Get(ctx, s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.Name,
+				Namespace: s.Namespace,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "farmops-agent"},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		}, metav1.CreateOptions{})
+	} else if err == nil {
+		secret.Data = data
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("identity: save secret %s/%s: %w", s.Namespace, s.Name, err)
+	}
+	return nil
+}
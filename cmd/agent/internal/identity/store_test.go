@@ -0,0 +1,56 @@
+package identity_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/farmops/farmops/cmd/agent/internal/identity"
+)
+
+type store interface {
+	Load(ctx context.Context) (*identity.Credentials, error)
+	Save(ctx context.Context, c *identity.Credentials) error
+}
+
+func TestStores_RoundTrip(t *testing.T) {
+	creds := &identity.Credentials{
+		AgentID:           "a1",
+		ClusterAlias:      "prod-eu-1",
+		PrivateKey:        "private key",
+		KeyCreatedAt:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		APIKey:            "fa_token",
+		ActorPepper:       "0123abcd",
+		PendingPrivateKey: "next key",
+		PendingProofID:    "rotation proof",
+	}
+	for name, s := range map[string]store{
+		"file":   identity.FileStore{Path: filepath.Join(t.TempDir(), "creds", "agent.json")},
+		"secret": identity.SecretStore{Client: fake.NewClientset(), Namespace: "farmops", Name: "agent-credentials"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := s.Load(ctx); !errors.Is(err, identity.ErrNotFound) {
+				t.Fatalf("Load before Save: err = %v, want ErrNotFound", err)
+			}
+			// Save twice, to create and then update.
+			for range 2 {
+				if err := s.Save(ctx, creds); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := s.Load(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, creds) {
+				t.Errorf("loaded %+v, saved %+v", got, creds)
+			}
+		})
+	}
+}
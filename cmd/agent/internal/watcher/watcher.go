@@ -5,6 +5,7 @@ package watcher
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
 	"time"
@@ -16,6 +17,8 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/farmops/farmops/cmd/agent/internal/config"
+	"github.com/farmops/farmops/cmd/agent/internal/identity"
+	"github.com/farmops/farmops/pkg/evidence"
 	"github.com/farmops/farmops/pkg/proof"
 	"github.com/farmops/farmops/pkg/transport"
 )
@@ -26,29 +29,39 @@ type Watcher struct {
 	log     *slog.Logger
 	k8s     kubernetes.Interface
 	client  *transport.TrackerClient
-	privKey []byte // raw Ed25519 private key bytes, decoded from cfg.PrivateKeyHex
+	signer  proof.Signer
+	rotator *identity.Rotator
+	vault   *evidence.Vault
+	pepper  []byte // actor hashing pepper; see actorPepper
 }
 
-// New creates a new Watcher, initialising the Kubernetes client and tracker transport.
-func New(cfg *config.Config, log *slog.Logger) (*Watcher, error) {
-	privKey, err := proof.DecodePrivateKey(cfg.PrivateKeyHex)
+// New creates a new Watcher observing k8sClient and submitting proofs signed
+// by signer to the tracker. If rotator is non-nil, the signing key is rotated
+// when it falls due.
+func New(cfg *config.Config, k8sClient kubernetes.Interface, signer proof.Signer, rotator *identity.Rotator, log *slog.Logger) (*Watcher, error) {
+	tlsConfig, err := cfg.TLS.Client()
 	if err != nil {
-		return nil, fmt.Errorf("watcher: decode private key: %w", err)
+		return nil, fmt.Errorf("watcher: tls config: %w", err)
 	}
+	trackerClient := transport.NewTrackerClientTLS(cfg.TrackerURL, cfg.APIKey, tlsConfig)
 
-	k8sClient, err := buildK8sClient(cfg.Kubeconfig)
+	key, err := evidence.LoadKey(cfg.Evidence.KeyFile, true)
 	if err != nil {
-		return nil, fmt.Errorf("watcher: build k8s client: %w", err)
+		return nil, fmt.Errorf("watcher: %w", err)
+	}
+	vault, err := evidence.Open(cfg.Evidence.VaultDir, key)
+	if err != nil {
+		return nil, fmt.Errorf("watcher: %w", err)
 	}
-
-	trackerClient := transport.NewTrackerClient(cfg.TrackerURL, cfg.APIKey)
 
 	return &Watcher{
 		cfg:     cfg,
 		log:     log,
 		k8s:     k8sClient,
 		client:  trackerClient,
-		privKey: privKey,
+		signer:  signer,
+		rotator: rotator,
+		vault:   vault,
 	}, nil
 }
 
@@ -60,19 +73,76 @@ func (w *Watcher) Run(ctx context.Context) error {
 	defer ticker.Stop()
 
 	// Run immediately on start, then on each tick.
-	if err := w.observePodHealth(ctx); err != nil {
-		w.log.Warn("watcher: pod health observation failed", "error", err)
-	}
+	w.tick(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			if err := w.observePodHealth(ctx); err != nil {
-				w.log.Warn("watcher: pod health observation failed", "error", err)
-			}
+			w.tick(ctx)
+		}
+	}
+}
+
+func (w *Watcher) tick(ctx context.Context) {
+	if err := w.actorPepper(ctx); err != nil {
+		w.log.Warn("watcher: no actor pepper, skipping proofs", "error", err)
+		return
+	}
+	if w.rotator != nil {
+		if err := w.rotateKey(ctx); err != nil {
+			w.log.Warn("watcher: key rotation failed", "error", err)
+		}
+	}
+	if err := w.observePodHealth(ctx); err != nil {
+		w.log.Warn("watcher: pod health observation failed", "error", err)
+	}
+}
+
+// actorPepper loads the tracker's actor hashing pepper, from the config or
+// else from the tracker, unless it is loaded already. Proofs cannot be built
+// without it.
+func (w *Watcher) actorPepper(ctx context.Context) error {
+	if w.pepper != nil {
+		return nil
+	}
+	if w.cfg.ActorPepper != "" {
+		pepper, err := hex.DecodeString(w.cfg.ActorPepper)
+		if err != nil || len(pepper) == 0 {
+			return fmt.Errorf("invalid actor_pepper in config")
 		}
+		w.pepper = pepper
+		return nil
+	}
+	pepper, err := w.client.ActorPepper(ctx)
+	if err != nil {
+		return fmt.Errorf("get actor pepper: %w", err)
+	}
+	w.pepper = pepper
+	return nil
+}
+
+// rotateKey switches to a new signing key if the current one is due for rotation.
+func (w *Watcher) rotateKey(ctx context.Context) error {
+	head, err := w.client.LatestProof(ctx, w.cfg.AgentID)
+	if err != nil {
+		return fmt.Errorf("get chain head: %w", err)
+	}
+	key, err := w.rotator.RotateIfDue(ctx, w.agentInfo(), w.pepper, head)
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		w.signer = proof.KeySigner(key)
+	}
+	return nil
+}
+
+func (w *Watcher) agentInfo() proof.AgentInfo {
+	return proof.AgentInfo{
+		AgentID:      w.cfg.AgentID,
+		ClusterAlias: w.cfg.ClusterAlias,
 	}
 }
 
@@ -109,15 +179,13 @@ func (w *Watcher) observePodHealth(ctx context.Context) error {
 		return nil
 	}
 
-	// Build evidence (kept locally, only its hash goes into the proof).
+	// Build evidence (kept in the vault; only a salted commitment to it goes
+	// into the proof).
 	evidenceJSON := fmt.Sprintf(`{"total":%d,"healthy":%d,"crash_looping":%d}`, total, healthy, crashLooping)
 
-	agent := proof.AgentInfo{
-		AgentID:      w.cfg.AgentID,
-		ClusterAlias: w.cfg.ClusterAlias,
-	}
+	agent := w.agentInfo()
 	actor := proof.ActorInfo{
-		ActorHash: proof.HashActor("agent:" + w.cfg.AgentID),
+		ActorHash: proof.HashActorKeyed(w.pepper, "agent:"+w.cfg.AgentID),
 		ActorType: proof.ActorSystem,
 	}
 	action := proof.ActionInfo{
@@ -127,9 +195,8 @@ func (w *Watcher) observePodHealth(ctx context.Context) error {
 		Description: fmt.Sprintf("Verified pod health: %d/%d pods healthy", healthy, total),
 	}
 	outcome := proof.OutcomeInfo{
-		Status:       proof.OutcomeSuccess,
-		Verified:     true,
-		EvidenceHash: proof.HashEvidence([]byte(evidenceJSON)),
+		Status:   proof.OutcomeSuccess,
+		Verified: true,
 	}
 
 	complexity := proof.ComplexityLow
@@ -142,12 +209,29 @@ func (w *Watcher) observePodHealth(ctx context.Context) error {
 		ArtifactsTouched: total,
 	}
 
-	p, err := proof.New(agent, actor, action, outcome, hints, nil)
+	// Link the proof to the head of the agent's chain on the tracker.
+	prev, err := w.client.LatestProof(ctx, w.cfg.AgentID)
+	if err != nil {
+		return fmt.Errorf("get chain head: %w", err)
+	}
+
+	p, err := proof.New(agent, actor, action, outcome, hints, prev)
 	if err != nil {
 		return fmt.Errorf("build proof: %w", err)
 	}
 
-	if err := proof.Sign(p, w.privKey); err != nil {
+	// Store the evidence before the proof can be accepted, so every proof on
+	// the tracker can be disclosed.
+	rec, err := evidence.New(p.ProofID, []byte(evidenceJSON))
+	if err != nil {
+		return fmt.Errorf("commit evidence: %w", err)
+	}
+	p.Outcome.EvidenceHash = rec.EvidenceHash
+	if err := w.vault.Put(rec); err != nil {
+		return fmt.Errorf("store evidence: %w", err)
+	}
+
+	if err := proof.SignWith(ctx, p, w.signer); err != nil {
 		return fmt.Errorf("sign proof: %w", err)
 	}
 
@@ -188,7 +272,9 @@ func clamp(v, min, max int) int {
 	return v
 }
 
-func buildK8sClient(kubeconfig string) (kubernetes.Interface, error) {
+// NewK8sClient builds a Kubernetes client from kubeconfig, or from the
+// in-cluster config if kubeconfig is empty.
+func NewK8sClient(kubeconfig string) (kubernetes.Interface, error) {
 	var restCfg *rest.Config
 	var err error
 
@@ -6,13 +6,21 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"k8s.io/client-go/kubernetes"
+
 	"github.com/farmops/farmops/cmd/agent/internal/config"
+	"github.com/farmops/farmops/cmd/agent/internal/identity"
 	"github.com/farmops/farmops/cmd/agent/internal/watcher"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/signer"
+	"github.com/farmops/farmops/pkg/transport"
 )
 
 func main() {
@@ -33,13 +41,59 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	k8sClient, err := watcher.NewK8sClient(cfg.Kubeconfig)
+	if err != nil {
+		slog.Error("failed to build kubernetes client", "error", err)
+		os.Exit(1)
+	}
+
+	var proofSigner proof.Signer
+	if cfg.Signer.Enabled() {
+		if proofSigner, err = configuredSigner(ctx, cfg.Signer, k8sClient); err != nil {
+			slog.Error("failed to load signer", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	var rotator *identity.Rotator
+	if cfg.Credentials.Enabled() {
+		tlsConfig, err := cfg.TLS.Client()
+		if err != nil {
+			slog.Error("failed to load tls config", "error", err)
+			os.Exit(1)
+		}
+		store := credentialsStore(cfg, k8sClient)
+		// Joining authenticates with the join token, not an API key.
+		if err := identity.Bootstrap(ctx, cfg, store, transport.NewTrackerClientTLS(cfg.TrackerURL, "", tlsConfig), proofSigner, logger); err != nil {
+			slog.Error("failed to bootstrap agent identity", "error", err)
+			os.Exit(1)
+		}
+		if cfg.Credentials.RotateAfter > 0 {
+			rotator = &identity.Rotator{
+				Store:  store,
+				Client: transport.NewTrackerClientTLS(cfg.TrackerURL, cfg.APIKey, tlsConfig),
+				After:  cfg.Credentials.RotateAfter,
+				Log:    logger,
+			}
+		}
+	}
+
+	if proofSigner == nil {
+		priv, err := proof.DecodePrivateKey(cfg.PrivateKeyHex)
+		if err != nil {
+			slog.Error("failed to decode private key", "error", err)
+			os.Exit(1)
+		}
+		proofSigner = proof.KeySigner(priv)
+	}
+
 	slog.Info("farmops-agent starting",
 		"agent_id", cfg.AgentID,
 		"cluster_alias", cfg.ClusterAlias,
 		"tracker_url", cfg.TrackerURL,
 	)
 
-	w, err := watcher.New(cfg, logger)
+	w, err := watcher.New(cfg, k8sClient, proofSigner, rotator, logger)
 	if err != nil {
 		slog.Error("failed to initialise watcher", "error", err)
 		os.Exit(1)
@@ -52,3 +106,66 @@ func main() {
 
 	slog.Info("farmops-agent stopped")
 }
+
+// credentialsStore returns the configured store for the agent identity.
+func credentialsStore(cfg *config.Config, k8sClient kubernetes.Interface) identity.Store {
+	if cfg.Credentials.Secret == "" {
+		return identity.FileStore{Path: cfg.Credentials.File}
+	}
+	namespace := cfg.Credentials.Namespace
+	if namespace == "" {
+		namespace = podNamespace()
+	}
+	return identity.SecretStore{Client: k8sClient, Namespace: namespace, Name: cfg.Credentials.Secret}
+}
+
+// configuredSigner loads the signer selected in the config.
+func configuredSigner(ctx context.Context, cfg config.SignerConfig, k8sClient kubernetes.Interface) (proof.Signer, error) {
+	switch {
+	case cfg.KeyFile != "":
+		passphrase, err := signer.ReadPassphrase(cfg.PassphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		return signer.LoadFile(cfg.KeyFile, passphrase)
+
+	case cfg.Secret != "":
+		namespace := cfg.Namespace
+		if namespace == "" {
+			namespace = podNamespace()
+		}
+		return signer.LoadSecret(ctx, k8sClient, namespace, cfg.Secret, cfg.SecretKey)
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if cfg.Vault.TokenFile != "" {
+		data, err := os.ReadFile(cfg.Vault.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("read vault token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	v := &signer.Vault{
+		Address:    cfg.Vault.Address,
+		Token:      token,
+		Namespace:  cfg.Vault.Namespace,
+		Mount:      cfg.Vault.Mount,
+		Key:        cfg.Vault.Key,
+		KeyVersion: cfg.Vault.KeyVersion,
+	}
+	// Fail at startup, not at the first proof, if Vault is unreachable.
+	if _, err := v.PublicKey(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// podNamespace returns the namespace the agent runs in, from its service
+// account mount, or "default" outside a cluster.
+func podNamespace() string {
+	data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if ns := strings.TrimSpace(string(data)); err == nil && ns != "" {
+		return ns
+	}
+	return "default"
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/farmops/farmops/pkg/bundle"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+// cmdExport downloads a signed bundle of the tracker's history and checks it
+// before reporting success.
+func cmdExport(ctx context.Context, client *transport.TrackerClient, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	outFile := fs.String("out", "farmops-"+time.Now().Format("20060102")+".tar.gz", "bundle file to write")
+	_ = fs.Parse(args)
+
+	var buf bytes.Buffer
+	if err := client.Export(ctx, &buf); err != nil {
+		fatalf("export: %v\n", err)
+	}
+	b := readBundle(buf.Bytes(), "")
+	if err := os.WriteFile(*outFile, buf.Bytes(), 0600); err != nil {
+		fatalf("write %s: %v\n", *outFile, err)
+	}
+	if out.structured() {
+		out.print(b.Manifest)
+		return
+	}
+	fmt.Printf("Exported %s to %s.\n", summarize(b), *outFile)
+	fmt.Printf("Signed by tracker key %s; pass it to farmctl import -signer-key.\n", b.Manifest.SignerKey)
+}
+
+// cmdImport verifies a bundle locally and uploads it to an empty tracker,
+// which verifies it again before storing anything. The exporting tracker's
+// key must be pinned with -signer-key, since anyone can sign a bundle.
+func cmdImport(ctx context.Context, client *transport.TrackerClient, args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	signerKey := fs.String("signer-key", "", "public key of the tracker that exported the bundle (required)")
+	dryRun := fs.Bool("dry-run", false, "verify the bundle without importing it")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fatalf("usage: farmctl import -signer-key key [-dry-run] <bundle>\n")
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	if *signerKey == "" {
+		if b, err := bundle.Read(bytes.NewReader(data)); err == nil {
+			fatalf("the bundle is signed by tracker key %s, which is not pinned; pass it with -signer-key if it is the exporting tracker's key\n",
+				b.Manifest.SignerKey)
+		}
+		fatalf("-signer-key is required\n")
+	}
+	b := readBundle(data, *signerKey)
+	if !out.structured() {
+		fmt.Printf("Bundle verified: %s.\n", summarize(b))
+	}
+	if *dryRun {
+		if out.structured() {
+			out.print(b.Manifest)
+		}
+		return
+	}
+
+	res, err := client.Import(ctx, bytes.NewReader(data), *signerKey)
+	if err != nil {
+		fatalf("import: %v\n", err)
+	}
+	if out.structured() {
+		out.print(res)
+		return
+	}
+	fmt.Printf("Imported %d agents, %d proofs and %d ledger events into farm %q.\n",
+		res.Agents, res.Proofs, res.LedgerEvents, res.Farm)
+	if !res.PepperImported {
+		fmt.Println("The bundle carries no actor pepper, so the tracker keeps its own: remove actor_pepper from each agent's config and credentials, and restart it to fetch the new one.")
+	}
+}
+
+// readBundle reads and verifies a bundle, printing each agent's chain unless
+// the output is structured, and exits if it does not verify or, with
+// signerKey set, was signed by another tracker.
+func readBundle(data []byte, signerKey string) *bundle.Bundle {
+	b, err := bundle.Read(bytes.NewReader(data))
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	if signerKey != "" {
+		want, err := proof.DecodePublicKey(signerKey)
+		if err != nil {
+			fatalf("invalid -signer-key: %v\n", err)
+		}
+		if err := b.CheckSigner(want); err != nil {
+			fatalf("%v\n", err)
+		}
+	}
+
+	reports, verr := b.Verify()
+	if out.structured() {
+		if verr != nil {
+			fatalf("%v\n", verr)
+		}
+		return b
+	}
+	rows := make([][]string, 0, len(b.Manifest.Agents))
+	for _, ac := range b.Manifest.Agents {
+		result := "ok"
+		if r := reports[ac.AgentID]; r == nil || !r.OK() {
+			result = "FAIL"
+		}
+		rows = append(rows, []string{ac.AgentID, fmt.Sprint(ac.Proofs), ac.Head, result})
+	}
+	printTable([]string{"AGENT", "PROOFS", "HEAD", "CHAIN"}, rows)
+	fmt.Println()
+	if verr != nil {
+		fatalf("%v\n", verr)
+	}
+	return b
+}
+
+func summarize(b *bundle.Bundle) string {
+	proofs := 0
+	for _, ac := range b.Manifest.Agents {
+		proofs += ac.Proofs
+	}
+	return fmt.Sprintf("farm %q: %d agents, %d proofs, %d ledger events",
+		b.Manifest.Farm, len(b.Agents), proofs, b.Manifest.LedgerEvents)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/farmops/farmops/cmd/farmctl/internal/config"
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+// applyContext fills the tracker URL, API token and TLS files not given by
+// flag or environment from the named context, or the current one if name is
+// empty, and returns the context used (nil if none). The context's token and
+// TLS files are only used with its own tracker, never sent to another URL.
+// A nil apiKey skips looking up the token.
+func applyContext(name string, trackerURL, apiKey *string, tlsFiles *transport.TLSFiles) *config.Context {
+	dir, err := config.Dir()
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	cfg, err := config.Load(dir)
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	c := cfg.Current()
+	if name != "" {
+		if c = cfg.Context(name); c == nil {
+			fatalf("no context named %q; see farmctl context list\n", name)
+		}
+	}
+	if c == nil {
+		return nil
+	}
+	if *trackerURL == "" {
+		*trackerURL = c.TrackerURL
+	}
+	if *trackerURL != c.TrackerURL {
+		return c
+	}
+	if apiKey != nil && *apiKey == "" {
+		if *apiKey, err = config.Token(dir, c); err != nil {
+			fatalf("context %s: %v\n", c.Name, err)
+		}
+	}
+	if tlsFiles.CAFile == "" {
+		tlsFiles.CAFile = c.TLS.CAFile
+	}
+	if tlsFiles.CertFile == "" && tlsFiles.KeyFile == "" {
+		tlsFiles.CertFile, tlsFiles.KeyFile = c.TLS.CertFile, c.TLS.KeyFile
+	}
+	return c
+}
+
+// cmdContext lists, selects, adds and removes named contexts.
+func cmdContext(args []string) {
+	if len(args) == 0 {
+		fatalf("usage: farmctl context list|current|use|add|remove\n")
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	cfg, err := config.Load(dir)
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+
+	switch args[0] {
+	case "list":
+		if out.structured() {
+			out.print(cfg)
+			return
+		}
+		rows := make([][]string, 0, len(cfg.Contexts))
+		for _, c := range cfg.Contexts {
+			current := ""
+			if c.Name == cfg.CurrentContext {
+				current = "*"
+			}
+			rows = append(rows, []string{current, c.Name, c.TrackerURL, orDash(c.Credential), orDash(c.Output)})
+		}
+		printTable([]string{"CURRENT", "NAME", "TRACKER", "CREDENTIAL", "OUTPUT"}, rows)
+
+	case "current":
+		if cfg.CurrentContext == "" {
+			fatalf("no current context; see farmctl context use\n")
+		}
+		report(Result{Kind: "context", ID: cfg.CurrentContext, Status: "current"}, "%s\n", cfg.CurrentContext)
+
+	case "use":
+		if len(args) < 2 {
+			fatalf("usage: farmctl context use <name>\n")
+		}
+		if cfg.Context(args[1]) == nil {
+			fatalf("no context named %q; see farmctl context list\n", args[1])
+		}
+		cfg.CurrentContext = args[1]
+		if err := cfg.Save(dir); err != nil {
+			fatalf("%v\n", err)
+		}
+		report(Result{Kind: "context", ID: args[1], Status: "current"}, "Switched to context %s.\n", args[1])
+
+	case "add":
+		cmdContextAdd(dir, cfg, args[1:])
+
+	case "remove":
+		if len(args) < 2 {
+			fatalf("usage: farmctl context remove <name>\n")
+		}
+		c := cfg.Context(args[1])
+		if c == nil {
+			fatalf("no context named %q\n", args[1])
+		}
+		if err := config.DeleteToken(dir, c); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		cfg.Remove(args[1])
+		if err := cfg.Save(dir); err != nil {
+			fatalf("%v\n", err)
+		}
+		report(Result{Kind: "context", ID: args[1], Status: "removed"}, "Context %s removed.\n", args[1])
+
+	default:
+		fatalf("unknown context command: %s\n", args[0])
+	}
+}
+
+// cmdContextAdd creates or replaces a context, storing its token in the OS
+// keyring unless told otherwise.
+func cmdContextAdd(dir string, cfg *config.File, args []string) {
+	fs := flag.NewFlagSet("context add", flag.ExitOnError)
+	tracker := fs.String("tracker", "", "Stats Tracker base URL")
+	token := fs.String("token", "", `API token for the context ("-" to read it from stdin)`)
+	tokenEnv := fs.String("token-env", "", "read the API token from this environment variable at run time instead of storing it")
+	store := fs.String("store", config.CredentialKeyring, "where to store the token: keyring (falling back to file) or file")
+	output := fs.String("output", "", "default output format for the context")
+	caFile := fs.String("tls-ca", "", "CA bundle for the tracker's certificate")
+	certFile := fs.String("tls-cert", "", "client certificate for mutual TLS")
+	keyFile := fs.String("tls-key", "", "client certificate key")
+	use := fs.Bool("use", false, "make this the current context")
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fatalf("usage: farmctl context add <name> -tracker <url> [-token t | -token-env VAR] [flags]\n")
+	}
+	name := args[0]
+	_ = fs.Parse(args[1:])
+
+	if *tracker == "" {
+		fatalf("context add: -tracker is required\n")
+	}
+	if *store != config.CredentialKeyring && *store != config.CredentialFile {
+		fatalf("context add: -store must be keyring or file\n")
+	}
+	if *token != "" && *tokenEnv != "" {
+		fatalf("context add: -token and -token-env are mutually exclusive\n")
+	}
+	if (*certFile == "") != (*keyFile == "") {
+		fatalf("context add: -tls-cert and -tls-key go together\n")
+	}
+	if *token == "-" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fatalf("context add: read token from stdin: %v\n", err)
+		}
+		*token = strings.TrimSpace(line)
+	}
+
+	c := config.Context{
+		Name:       name,
+		TrackerURL: *tracker,
+		Output:     *output,
+		TLS:        config.TLSConfig{CAFile: *caFile, CertFile: *certFile, KeyFile: *keyFile},
+	}
+	// Drop the token a replaced context had stored before keeping the new one.
+	if old := cfg.Context(name); old != nil {
+		_ = config.DeleteToken(dir, old)
+	}
+	switch {
+	case *tokenEnv != "":
+		c.Credential = "env:" + *tokenEnv
+	case *token != "":
+		if err := config.StoreToken(dir, &c, *token, *store == config.CredentialKeyring); err != nil {
+			fatalf("context add: %v\n", err)
+		}
+		if *store == config.CredentialKeyring && c.Credential == config.CredentialFile {
+			fmt.Fprintln(os.Stderr, "No OS keyring available; the token is in the credentials file (mode 0600).")
+		}
+	}
+
+	cfg.Set(c)
+	if *use || cfg.CurrentContext == "" {
+		cfg.CurrentContext = name
+	}
+	if err := cfg.Save(dir); err != nil {
+		fatalf("%v\n", err)
+	}
+	if cfg.CurrentContext == name {
+		report(Result{Kind: "context", ID: name, Status: "current"}, "Context %s saved and in use.\n", name)
+	} else {
+		report(Result{Kind: "context", ID: name, Status: "saved"}, "Context %s saved.\n", name)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
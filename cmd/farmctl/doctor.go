@@ -0,0 +1,573 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/signer"
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+// Results of a doctor check.
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// certRenewWindow is how close to expiry a certificate is reported.
+const certRenewWindow = 14 * 24 * time.Hour
+
+// agentConfig is the part of the agent config (cmd/agent/internal/config)
+// that doctor checks. With -agent-env, FARMOPS_* variables override it as
+// they do the agent's.
+type agentConfig struct {
+	AgentID      string `yaml:"agent_id"`
+	ClusterAlias string `yaml:"cluster_alias"`
+	TrackerURL   string `yaml:"tracker_url"`
+	APIKey       string `yaml:"api_key"`
+	JoinToken    string `yaml:"join_token"`
+	PrivateKey   string `yaml:"private_key"`
+	Credentials  struct {
+		File   string `yaml:"file"`
+		Secret string `yaml:"secret"`
+	} `yaml:"credentials"`
+	Signer struct {
+		KeyFile        string `yaml:"key_file"`
+		PassphraseFile string `yaml:"passphrase_file"`
+		Secret         string `yaml:"secret"`
+		Vault          struct {
+			Address string `yaml:"address"`
+		} `yaml:"vault"`
+	} `yaml:"signer"`
+	TLS struct {
+		CAFile   string `yaml:"ca_file"`
+		CertFile string `yaml:"cert_file"`
+		KeyFile  string `yaml:"key_file"`
+	} `yaml:"tls"`
+}
+
+// agentCredentials is the part of the agent's credentials file doctor reads.
+type agentCredentials struct {
+	AgentID           string `json:"agent_id"`
+	ClusterAlias      string `json:"cluster_alias"`
+	PrivateKey        string `json:"private_key"`
+	APIKey            string `json:"api_key"`
+	PendingPrivateKey string `json:"pending_private_key"`
+}
+
+// agentIdentity is who the agent is, as far as doctor can tell from its
+// config and credentials. Key is nil when the signing key is out of reach.
+type agentIdentity struct {
+	AgentID      string
+	ClusterAlias string
+	APIKey       string
+	Key          ed25519.PublicKey
+	PendingKey   ed25519.PublicKey // next key of a rotation in flight
+}
+
+// doctor collects the results of the checks.
+type doctor struct {
+	checks []DoctorCheck
+}
+
+func (d *doctor) add(check, status, detail, hint string) {
+	d.checks = append(d.checks, DoctorCheck{Check: check, Status: status, Detail: detail, Hint: hint})
+}
+
+func (d *doctor) failed() bool {
+	for _, c := range d.checks {
+		if c.Status == checkFail {
+			return true
+		}
+	}
+	return false
+}
+
+// cmdDoctor checks, end to end, why an agent's proofs might not be turning
+// into coins: its config and key, the tracker connection, its token and
+// trust status, and its latest proof. It exits non-zero if a check fails.
+// trackerURL is the tracker farmctl itself talks to, for the checks that
+// need farmctl's token.
+func cmdDoctor(ctx context.Context, client *transport.TrackerClient, trackerURL string, args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	cfgPath := fs.String("config", "agent.yaml", "the agent's config file")
+	stale := fs.Duration("stale", 24*time.Hour, "warn when the agent's latest proof is older than this")
+	agentEnv := fs.Bool("agent-env", false, "apply the agent's FARMOPS_* environment overrides; only for running in the agent's own environment, since farmctl reads FARMOPS_API_KEY as its own token")
+	_ = fs.Parse(args)
+
+	d := &doctor{}
+	d.run(ctx, client, trackerURL, *cfgPath, *agentEnv, *stale)
+	if out.structured() {
+		out.print(d.checks)
+	} else {
+		printDoctor(d.checks)
+	}
+	if d.failed() {
+		os.Exit(1)
+	}
+}
+
+func (d *doctor) run(ctx context.Context, client *transport.TrackerClient, trackerURL, cfgPath string, agentEnv bool, stale time.Duration) {
+	cfg, err := loadAgentConfig(cfgPath, agentEnv)
+	if err != nil {
+		d.add("agent config", checkFail, err.Error(), "point -config at the file the agent runs with")
+		return
+	}
+	d.add("agent config", checkPass, fmt.Sprintf("%s, tracker %s", cfgPath, cfg.TrackerURL), "")
+	if agentEnv && os.Getenv("FARMOPS_PRIVATE_KEY") != "" {
+		d.add("environment", checkFail, "FARMOPS_PRIVATE_KEY is set; the agent refuses to start with a private key in its environment",
+			"unset it and move the key to signer, credentials or private_key")
+	}
+
+	id := d.identity(cfg)
+	if id.AgentID == "" {
+		d.add("agent identity", checkFail, "no agent_id configured or stored",
+			"set agent_id, or start the agent once with a join_token so it generates and stores one")
+	}
+
+	tlsConfig, err := transport.TLSFiles{CAFile: cfg.TLS.CAFile, CertFile: cfg.TLS.CertFile, KeyFile: cfg.TLS.KeyFile}.Config()
+	if err != nil {
+		d.add("tls", checkFail, err.Error(), "fix tls.ca_file, tls.cert_file and tls.key_file in the agent config")
+		return
+	}
+	agentClient := transport.NewTrackerClientTLS(cfg.TrackerURL, id.APIKey, tlsConfig)
+	if err := agentClient.Ready(ctx); err != nil {
+		d.add("tracker", checkFail, err.Error(), connectHint(err))
+		return
+	}
+	d.add("tracker", checkPass, cfg.TrackerURL+" is up and ready", "")
+	d.checkTLS(cfg.TrackerURL, tlsConfig, id.AgentID)
+	if id.AgentID == "" {
+		return
+	}
+
+	latest, haveLatest := d.checkToken(ctx, agentClient, id)
+
+	if strings.TrimRight(trackerURL, "/") != strings.TrimRight(cfg.TrackerURL, "/") {
+		d.add("agent status", checkSkip, fmt.Sprintf("farmctl talks to %s, the agent to %s", trackerURL, cfg.TrackerURL),
+			fmt.Sprintf("run doctor with -tracker %s, or a context for it, to check the agent's trust status and proofs", cfg.TrackerURL))
+		return
+	}
+	agent := d.checkAgent(ctx, client, id)
+	if agent == nil {
+		return
+	}
+	if !haveLatest {
+		if latest, err = client.LatestProof(ctx, id.AgentID); err != nil {
+			d.add("latest proof", checkSkip, err.Error(), "give doctor the agent's token, or an admin token with -key, to fetch the chain head")
+			return
+		}
+	}
+	d.checkLatestProof(ctx, client, agent, latest, stale)
+}
+
+// identity resolves the agent's ID, token and signing key the way the agent
+// does: the config first, then its credentials store.
+func (d *doctor) identity(cfg *agentConfig) *agentIdentity {
+	id := &agentIdentity{AgentID: cfg.AgentID, ClusterAlias: cfg.ClusterAlias, APIKey: cfg.APIKey}
+	keyHex, keySource := cfg.PrivateKey, "private_key"
+	signerSet := cfg.Signer.KeyFile != "" || cfg.Signer.Secret != "" || cfg.Signer.Vault.Address != ""
+
+	switch {
+	case cfg.Credentials.File != "":
+		creds, err := readAgentCredentials(cfg.Credentials.File)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			d.add("credentials", checkWarn, cfg.Credentials.File+" does not exist yet",
+				"the agent creates it on first boot; start the agent, or run doctor as the agent's user where the file is")
+		case err != nil:
+			d.add("credentials", checkFail, err.Error(), "check credentials.file and that it is readable by you (it is mode 0600)")
+		default:
+			d.add("credentials", checkPass, cfg.Credentials.File, "")
+			id.AgentID = cmp.Or(id.AgentID, creds.AgentID)
+			id.ClusterAlias = cmp.Or(id.ClusterAlias, creds.ClusterAlias)
+			id.APIKey = cmp.Or(id.APIKey, creds.APIKey)
+			if keyHex == "" && !signerSet {
+				keyHex, keySource = creds.PrivateKey, cfg.Credentials.File
+			}
+			if creds.PendingPrivateKey != "" {
+				if priv, err := proof.DecodePrivateKey(creds.PendingPrivateKey); err == nil {
+					id.PendingKey = priv.Public().(ed25519.PublicKey)
+				}
+			}
+		}
+	case cfg.Credentials.Secret != "":
+		d.add("credentials", checkSkip, "kept in Kubernetes Secret "+cfg.Credentials.Secret,
+			"copy agent_id, api_key and private_key from the Secret into a config for doctor to check them")
+	}
+
+	var priv ed25519.PrivateKey
+	switch {
+	case cfg.Signer.KeyFile != "":
+		pass, err := signer.ReadPassphrase(cfg.Signer.PassphraseFile)
+		if err != nil {
+			d.add("signing key", checkFail, err.Error(), "check signer.passphrase_file")
+			return id
+		}
+		k, err := signer.LoadFile(cfg.Signer.KeyFile, pass)
+		if err != nil {
+			d.add("signing key", checkFail, err.Error(), "check signer.key_file and that signer.passphrase_file holds its passphrase")
+			return id
+		}
+		priv, keySource = ed25519.PrivateKey(k), cfg.Signer.KeyFile
+	case cfg.Signer.Secret != "" || cfg.Signer.Vault.Address != "":
+		d.add("signing key", checkSkip, "held in a Kubernetes Secret or Vault, out of doctor's reach",
+			"compare its public key with `farmctl -o wide agent list`")
+		return id
+	case keyHex == "":
+		if cfg.Credentials.File != "" || cfg.Credentials.Secret != "" {
+			return id // generated on first boot, reported with the credentials
+		}
+		d.add("signing key", checkFail, "no signing key configured", "set private_key, signer or credentials")
+		return id
+	default:
+		var err error
+		if priv, err = proof.DecodePrivateKey(keyHex); err != nil {
+			d.add("signing key", checkFail, fmt.Sprintf("%s: %v", keySource, err),
+				"the key must be an Ed25519 private key in hex, PKCS#8 PEM, OpenSSH or JWK; `farmctl agent keygen` makes one")
+			return id
+		}
+	}
+	id.Key = priv.Public().(ed25519.PublicKey)
+	d.add("signing key", checkPass, fmt.Sprintf("Ed25519 from %s, public key %s", keySource, proof.EncodePublicKey(id.Key)), "")
+	return id
+}
+
+// checkToken checks that the agent's token is an agent token for this agent
+// and that the tracker accepts it, by fetching the head of its chain the way
+// the agent does at startup. An admin token would pass the fetch, but the
+// agent must not run with one.
+func (d *doctor) checkToken(ctx context.Context, agentClient *transport.TrackerClient, id *agentIdentity) (*proof.FarmProof, bool) {
+	const mint = "mint one with `farmctl token create -scope agent -agent-id %s` and set api_key or FARMOPS_API_KEY"
+	if id.APIKey == "" {
+		d.add("agent token", checkFail, "no api_key configured or stored",
+			fmt.Sprintf(mint+", or give the agent a join_token", id.AgentID))
+		return nil, false
+	}
+	token, err := agentClient.CurrentToken(ctx)
+	switch {
+	case errors.Is(err, transport.ErrUnauthorized):
+		d.add("agent token", checkFail, "rejected by the tracker: unknown, expired or revoked", fmt.Sprintf(mint, id.AgentID))
+		return nil, false
+	case err != nil:
+		d.add("agent token", checkFail, err.Error(), "")
+		return nil, false
+	case token.Scope != "agent":
+		d.add("agent token", checkFail, fmt.Sprintf("token %s has scope %s, not agent", token.ID, token.Scope), fmt.Sprintf(mint, id.AgentID))
+		return nil, false
+	case token.AgentID != id.AgentID:
+		d.add("agent token", checkFail, fmt.Sprintf("token %s is bound to agent %s, not %s", token.ID, token.AgentID, id.AgentID),
+			fmt.Sprintf(mint, id.AgentID))
+		return nil, false
+	}
+	latest, err := agentClient.LatestProof(ctx, id.AgentID)
+	switch {
+	case errors.Is(err, transport.ErrUnauthorized):
+		d.add("agent token", checkFail, "rejected by the tracker: unknown, expired or revoked", fmt.Sprintf(mint, id.AgentID))
+	case errors.Is(err, transport.ErrForbidden):
+		d.add("agent token", checkFail, "not valid for agent "+id.AgentID+": it is bound to another agent or is not an agent token",
+			fmt.Sprintf(mint, id.AgentID))
+	case err != nil:
+		d.add("agent token", checkFail, err.Error(), "")
+	default:
+		d.add("agent token", checkPass, "accepted for agent "+id.AgentID, "")
+		return latest, true
+	}
+	return nil, false
+}
+
+// checkAgent checks that the tracker trusts the agent and holds its key, and
+// returns the tracker's record of it.
+func (d *doctor) checkAgent(ctx context.Context, client *transport.TrackerClient, id *agentIdentity) *transport.Agent {
+	agents, err := client.ListAgents(ctx)
+	if err != nil {
+		d.add("agent status", checkSkip, "farmctl cannot list agents: "+err.Error(), "give farmctl a read or admin token with -key or a context")
+		return nil
+	}
+	var agent *transport.Agent
+	for i := range agents {
+		if agents[i].AgentID == id.AgentID {
+			agent = &agents[i]
+		}
+	}
+	if agent == nil {
+		hint := "give the agent a join_token from `farmctl join-token create`"
+		if id.Key != nil {
+			hint = fmt.Sprintf("enroll it with `farmctl agent enroll %s %s %s`, or %s", id.AgentID, cmp.Or(id.ClusterAlias, "<cluster-alias>"), proof.EncodePublicKey(id.Key), hint)
+		}
+		d.add("agent status", checkFail, "agent "+id.AgentID+" is not enrolled with the tracker", hint)
+		return nil
+	}
+
+	switch agent.Status {
+	case "active":
+		d.add("agent status", checkPass, fmt.Sprintf("active, enrolled %s", agent.EnrolledAt.Local().Format(time.DateTime)), "")
+	case "pending":
+		d.add("agent status", checkFail, "pending approval: the tracker rejects its proofs until approved",
+			"farmctl agent approve "+agent.AgentID)
+	case "revoked":
+		d.add("agent status", checkFail, "revoked "+formatOptionalTime(agent.RevokedAt),
+			"if its key is safe, `farmctl agent approve "+agent.AgentID+"` trusts it again; otherwise enroll it anew with a fresh key")
+	default:
+		d.add("agent status", checkWarn, "unknown status "+agent.Status, "")
+	}
+
+	if id.Key == nil {
+		return agent
+	}
+	current, err := proof.DecodePublicKey(agent.PublicKey)
+	if err != nil {
+		d.add("key match", checkFail, "the tracker's key for the agent does not decode: "+err.Error(), "")
+		return agent
+	}
+	if id.Key.Equal(current) {
+		d.add("key match", checkPass, "the agent's key is the one the tracker trusts", "")
+		return agent
+	}
+	if id.PendingKey != nil && id.PendingKey.Equal(current) {
+		d.add("key match", checkWarn, "the tracker accepted the agent's key rotation, but the agent still signs with its old key",
+			"restart the agent so it finishes the rotation")
+		return agent
+	}
+	for _, k := range agent.KeyHistory {
+		if old, err := proof.DecodePublicKey(k.PublicKey); err == nil && id.Key.Equal(old) {
+			d.add("key match", checkFail, fmt.Sprintf("the agent signs with a key retired %s by rotation proof %s",
+				k.RetiredAt.Local().Format(time.DateTime), k.RotationProofID),
+				"the agent lost the key it rotated to; restore its credentials, or revoke it and enroll it again with a new key")
+			return agent
+		}
+	}
+	d.add("key match", checkFail, "the tracker holds a different public key for the agent: "+agent.PublicKey,
+		"restore the key the agent enrolled with, or revoke it and enroll it again with its current key")
+	return agent
+}
+
+// checkLatestProof checks the head of the agent's chain on the tracker: its
+// signature, its link to the proof before it, and its age.
+func (d *doctor) checkLatestProof(ctx context.Context, client *transport.TrackerClient, agent *transport.Agent, latest *proof.FarmProof, stale time.Duration) {
+	if latest == nil {
+		d.add("latest proof", checkWarn, "the agent has not submitted a proof yet",
+			"the agent submits a proof once one of its plugins verifies an action; check its logs and plugins")
+		return
+	}
+
+	var keys []ed25519.PublicKey
+	for _, k := range append([]string{agent.PublicKey}, retiredKeys(agent)...) {
+		if pub, err := proof.DecodePublicKey(k); err == nil {
+			keys = append(keys, pub)
+		}
+	}
+	signed := false
+	for _, k := range keys {
+		if proof.Verify(latest, k) == nil {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		d.add("latest proof", checkFail, fmt.Sprintf("proof %s: signature does not verify against the agent's keys", latest.ProofID),
+			fmt.Sprintf("run `farmctl proof verify -agent %s` on an export to find where the chain breaks", agent.AgentID))
+		return
+	}
+
+	link := "genesis"
+	if latest.PrevProofID != "" {
+		prev, err := client.GetProof(ctx, latest.PrevProofID)
+		switch {
+		case errors.Is(err, transport.ErrNotFound):
+			d.add("latest proof", checkFail, fmt.Sprintf("proof %s follows %s, which the tracker does not have", latest.ProofID, latest.PrevProofID), "")
+			return
+		case err != nil:
+			d.add("latest proof", checkSkip, "cannot fetch the previous proof: "+err.Error(), "give farmctl a read or admin token with -key or a context")
+			return
+		}
+		hash, err := prev.Hash()
+		if err != nil {
+			d.add("latest proof", checkFail, err.Error(), "")
+			return
+		}
+		if hash != latest.PrevProofHash {
+			d.add("latest proof", checkFail, fmt.Sprintf("proof %s: prev_proof_hash does not match proof %s", latest.ProofID, prev.ProofID),
+				fmt.Sprintf("run `farmctl proof verify -agent %s` on an export to find where the chain breaks", agent.AgentID))
+			return
+		}
+		link = "linked to " + prev.ProofID
+	}
+
+	age := time.Since(proofTime(latest.ProofID)).Round(time.Minute)
+	detail := fmt.Sprintf("%s, %s ago, signed and %s", latest.ProofID, formatAge(age), link)
+	if age > stale {
+		d.add("latest proof", checkWarn, detail,
+			"no proof for a while: check that the agent is running and its plugins see activity")
+		return
+	}
+	d.add("latest proof", checkPass, detail, "")
+}
+
+// checkTLS reports the TLS version and the certificates on both ends of the
+// agent's connection to the tracker.
+func (d *doctor) checkTLS(trackerURL string, config *tls.Config, agentID string) {
+	u, err := url.Parse(trackerURL)
+	if err != nil {
+		return
+	}
+	if u.Scheme != "https" {
+		d.add("tls", checkWarn, "plain HTTP: tokens and proofs cross the network unencrypted",
+			"serve the tracker over HTTPS; `farmctl pki init` and `farmctl pki server <host>` make the certificates")
+		return
+	}
+	if config == nil {
+		config = &tls.Config{}
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", host, config)
+	if err != nil {
+		d.add("tls", checkFail, err.Error(), connectHint(err))
+		return
+	}
+	state := conn.ConnectionState()
+	conn.Close()
+
+	server := state.PeerCertificates[0]
+	detail := fmt.Sprintf("%s, server certificate expires %s", tls.VersionName(state.Version), server.NotAfter.Local().Format(time.DateTime))
+	if time.Until(server.NotAfter) < certRenewWindow {
+		d.add("tls", checkWarn, detail, "renew it with `farmctl pki server <host>` and restart the tracker")
+	} else {
+		d.add("tls", checkPass, detail, "")
+	}
+
+	if len(config.Certificates) == 0 {
+		return
+	}
+	cert, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	if err != nil {
+		d.add("client certificate", checkFail, err.Error(), "")
+		return
+	}
+	reissue := fmt.Sprintf("issue a new one with `farmctl pki agent %s`", cmp.Or(agentID, "<agent-id>"))
+	switch {
+	case agentID != "" && cert.Subject.CommonName != agentID:
+		d.add("client certificate", checkWarn, fmt.Sprintf("issued to %s, not agent %s: a tracker with bind_agent_identity rejects its proofs", cert.Subject.CommonName, agentID), reissue)
+	case time.Until(cert.NotAfter) < certRenewWindow:
+		d.add("client certificate", checkWarn, "expires "+cert.NotAfter.Local().Format(time.DateTime), reissue)
+	default:
+		d.add("client certificate", checkPass, fmt.Sprintf("%s, expires %s", cert.Subject.CommonName, cert.NotAfter.Local().Format(time.DateTime)), "")
+	}
+}
+
+// connectHint suggests a fix for a failure to reach the tracker.
+func connectHint(err error) string {
+	var (
+		unknownCA x509.UnknownAuthorityError
+		hostname  x509.HostnameError
+		invalid   x509.CertificateInvalidError
+		dns       *net.DNSError
+	)
+	msg := err.Error()
+	switch {
+	case errors.As(err, &unknownCA):
+		return "the tracker's certificate is signed by an unknown CA: set tls.ca_file to its CA (ca.crt from `farmctl pki init`)"
+	case errors.As(err, &hostname):
+		return "the tracker's certificate does not name this host: use the name it was issued for in tracker_url, or reissue it with `farmctl pki server <host>`"
+	case errors.As(err, &invalid):
+		return "the tracker's certificate is invalid, e.g. expired: renew it with `farmctl pki server <host>`"
+	case strings.Contains(msg, "certificate required") || strings.Contains(msg, "bad certificate"):
+		return "the tracker requires a client certificate: set tls.cert_file and tls.key_file (`farmctl pki agent <agent-id>`)"
+	case strings.Contains(msg, "server gave HTTP response to HTTPS client"):
+		return "the tracker serves plain HTTP: use http:// in tracker_url, or enable TLS on the tracker"
+	case strings.Contains(msg, "malformed HTTP response"):
+		return "the tracker serves HTTPS: use https:// in tracker_url"
+	case errors.As(err, &dns):
+		return "the tracker's host name does not resolve: check tracker_url"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "nothing listens at tracker_url: check that the tracker is running and the port"
+	case errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, context.DeadlineExceeded):
+		return "the tracker did not answer in time: check the network path and firewalls"
+	case errors.Is(err, transport.ErrUnavailable):
+		return "the tracker is up but not ready; check its logs and storage"
+	}
+	return ""
+}
+
+// printDoctor prints the checks, with a hint under each that did not pass.
+func printDoctor(checks []DoctorCheck) {
+	width := 0
+	for _, c := range checks {
+		width = max(width, len(c.Check))
+	}
+	for _, c := range checks {
+		fmt.Printf("%-4s  %-*s  %s\n", strings.ToUpper(c.Status), width, c.Check, c.Detail)
+		if c.Hint != "" && c.Status != checkPass {
+			fmt.Printf("      %-*s  → %s\n", width, "", c.Hint)
+		}
+	}
+}
+
+// loadAgentConfig reads the agent config and, with agentEnv, applies the
+// environment overrides the agent applies. They are off by default:
+// FARMOPS_API_KEY in an operator's shell is farmctl's own token, not the
+// agent's.
+func loadAgentConfig(path string, agentEnv bool) (*agentConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg agentConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for v, env := range map[*string]string{
+		&cfg.APIKey:     "FARMOPS_API_KEY",
+		&cfg.TrackerURL: "FARMOPS_TRACKER_URL",
+		&cfg.JoinToken:  "FARMOPS_JOIN_TOKEN",
+	} {
+		if s := os.Getenv(env); s != "" && agentEnv {
+			*v = s
+		}
+	}
+	if cfg.TrackerURL == "" {
+		return nil, fmt.Errorf("%s: tracker_url is not set", path)
+	}
+	return &cfg, nil
+}
+
+func readAgentCredentials(path string) (*agentCredentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c agentCredentials
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+func retiredKeys(a *transport.Agent) []string {
+	keys := make([]string, len(a.KeyHistory))
+	for i, k := range a.KeyHistory {
+		keys[i] = k.PublicKey
+	}
+	return keys
+}
@@ -0,0 +1,256 @@
+// Package config loads farmctl's contexts: named trackers, each with the
+// credential, TLS files and output format to use with it, in the manner of
+// kubeconfig. API tokens are not kept in the config file itself but in the
+// OS keyring or, where there is none, a separate credentials file readable
+// only by its owner.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Where a context's API token is stored; see Context.Credential.
+const (
+	CredentialKeyring = "keyring"
+	CredentialFile    = "file"
+	credentialEnv     = "env:"
+)
+
+// File is farmctl's configuration file.
+type File struct {
+	CurrentContext string    `yaml:"current_context" json:"current_context"`
+	Contexts       []Context `yaml:"contexts" json:"contexts"`
+}
+
+// Context is a tracker and how to talk to it.
+type Context struct {
+	Name       string `yaml:"name" json:"name"`
+	TrackerURL string `yaml:"tracker_url" json:"tracker_url"`
+
+	// Credential says where the context's API token is kept: "keyring" for
+	// the OS keyring, "file" for farmctl's credentials file (both under the
+	// context's name), or "env:VAR" for an environment variable. Empty means
+	// the context has no token.
+	Credential string `yaml:"credential,omitempty" json:"credential,omitempty"`
+
+	// Output is the default output format for commands run in this context.
+	Output string `yaml:"output,omitempty" json:"output,omitempty"`
+
+	// TLS names the CA bundle for the tracker's certificate and, for mutual
+	// TLS, a client certificate.
+	TLS TLSConfig `yaml:"tls,omitempty" json:"tls"`
+}
+
+// TLSConfig names the PEM files used to reach the tracker over TLS.
+type TLSConfig struct {
+	CAFile   string `yaml:"ca_file,omitempty" json:"ca_file,omitempty"`
+	CertFile string `yaml:"cert_file,omitempty" json:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty" json:"key_file,omitempty"`
+}
+
+// Dir returns the directory of farmctl's configuration: $FARMOPS_CONFIG_DIR,
+// or farmops under $XDG_CONFIG_HOME, by default ~/.config/farmops.
+func Dir() (string, error) {
+	if dir := os.Getenv("FARMOPS_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "farmops"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("config: %w", err)
+	}
+	return filepath.Join(home, ".config", "farmops"), nil
+}
+
+// Load reads the config file in dir. A missing file is an empty config.
+func Load(dir string) (*File, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "config.yaml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("config: parse %s: %w", filepath.Join(dir, "config.yaml"), err)
+	}
+	return &f, nil
+}
+
+// Save writes the config file to dir.
+func (f *File) Save(dir string) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("config: marshal: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	return writeFile(filepath.Join(dir, "config.yaml"), data)
+}
+
+// Context returns the named context, or nil.
+func (f *File) Context(name string) *Context {
+	for i := range f.Contexts {
+		if f.Contexts[i].Name == name {
+			return &f.Contexts[i]
+		}
+	}
+	return nil
+}
+
+// Current returns the current context, or nil if none is set.
+func (f *File) Current() *Context {
+	return f.Context(f.CurrentContext)
+}
+
+// Set adds c, replacing any context with the same name.
+func (f *File) Set(c Context) {
+	if existing := f.Context(c.Name); existing != nil {
+		*existing = c
+		return
+	}
+	f.Contexts = append(f.Contexts, c)
+}
+
+// Remove deletes the named context and reports whether it existed.
+func (f *File) Remove(name string) bool {
+	for i, c := range f.Contexts {
+		if c.Name == name {
+			f.Contexts = append(f.Contexts[:i], f.Contexts[i+1:]...)
+			if f.CurrentContext == name {
+				f.CurrentContext = ""
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Token returns the API token of context c, kept in dir's credentials file
+// if it is not in the keyring.
+func Token(dir string, c *Context) (string, error) {
+	switch {
+	case c.Credential == "":
+		return "", nil
+	case c.Credential == CredentialKeyring:
+		return keyringGet(c.Name)
+	case c.Credential == CredentialFile:
+		creds, err := loadCredentials(dir)
+		if err != nil {
+			return "", err
+		}
+		token, ok := creds.Tokens[c.Name]
+		if !ok {
+			return "", fmt.Errorf("config: no token for context %s in the credentials file", c.Name)
+		}
+		return token, nil
+	case strings.HasPrefix(c.Credential, credentialEnv):
+		return os.Getenv(strings.TrimPrefix(c.Credential, credentialEnv)), nil
+	}
+	return "", fmt.Errorf("config: context %s: unknown credential %q", c.Name, c.Credential)
+}
+
+// StoreToken keeps token for context c in the OS keyring, or in dir's
+// credentials file if there is no usable keyring or useKeyring is false, and
+// points c.Credential at it.
+func StoreToken(dir string, c *Context, token string, useKeyring bool) error {
+	if useKeyring {
+		if err := keyringSet(c.Name, token); err == nil {
+			c.Credential = CredentialKeyring
+			return nil
+		}
+	}
+	creds, err := loadCredentials(dir)
+	if err != nil {
+		return err
+	}
+	creds.Tokens[c.Name] = token
+	if err := creds.save(dir); err != nil {
+		return err
+	}
+	c.Credential = CredentialFile
+	return nil
+}
+
+// DeleteToken removes the stored token of context c, if farmctl stored it.
+func DeleteToken(dir string, c *Context) error {
+	switch c.Credential {
+	case CredentialKeyring:
+		return keyringDelete(c.Name)
+	case CredentialFile:
+		creds, err := loadCredentials(dir)
+		if err != nil {
+			return err
+		}
+		delete(creds.Tokens, c.Name)
+		return creds.save(dir)
+	}
+	return nil
+}
+
+// credentials is the fallback token store, credentials.yaml in the config
+// directory, mode 0600.
+type credentials struct {
+	Tokens map[string]string `yaml:"tokens"`
+}
+
+func loadCredentials(dir string) (*credentials, error) {
+	path := filepath.Join(dir, "credentials.yaml")
+	creds := &credentials{}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		creds.Tokens = map[string]string{}
+		return creds, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	// Like ssh with private keys, refuse tokens others could have read.
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("config: %s is accessible by other users; run chmod 600 on it", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, creds); err != nil {
+		return nil, fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	if creds.Tokens == nil {
+		creds.Tokens = map[string]string{}
+	}
+	return creds, nil
+}
+
+func (c *credentials) save(dir string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("config: marshal credentials: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	return writeFile(filepath.Join(dir, "credentials.yaml"), data)
+}
+
+// writeFile replaces path with data, mode 0600.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/farmops/farmops/cmd/farmctl/internal/config"
+)
+
+func TestDir(t *testing.T) {
+	t.Setenv("FARMOPS_CONFIG_DIR", "")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if dir, _ := config.Dir(); dir != filepath.Join("/xdg", "farmops") {
+		t.Errorf("Dir with XDG_CONFIG_HOME = %s", dir)
+	}
+	t.Setenv("FARMOPS_CONFIG_DIR", "/farmops")
+	if dir, _ := config.Dir(); dir != "/farmops" {
+		t.Errorf("Dir with FARMOPS_CONFIG_DIR = %s", dir)
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "farmops")
+	f, err := config.Load(dir)
+	if err != nil || len(f.Contexts) != 0 {
+		t.Fatalf("Load without a config file: %+v, %v; want an empty config", f, err)
+	}
+
+	f.Set(config.Context{Name: "prod", TrackerURL: "https://prod:8443", Output: "json",
+		TLS: config.TLSConfig{CAFile: "ca.crt"}})
+	f.Set(config.Context{Name: "dev", TrackerURL: "http://localhost:8443"})
+	f.CurrentContext = "prod"
+	if err := f.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("config.yaml has mode %o, want 600", perm)
+	}
+
+	loaded, err := config.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := loaded.Current()
+	if c == nil || c.TrackerURL != "https://prod:8443" || c.Output != "json" || c.TLS.CAFile != "ca.crt" {
+		t.Errorf("current context after reload = %+v", c)
+	}
+	if len(loaded.Contexts) != 2 {
+		t.Errorf("reloaded %d contexts, want 2", len(loaded.Contexts))
+	}
+}
+
+func TestFile_SetRemove(t *testing.T) {
+	f := &config.File{}
+	f.Set(config.Context{Name: "prod", TrackerURL: "https://old"})
+	f.Set(config.Context{Name: "prod", TrackerURL: "https://new"})
+	if len(f.Contexts) != 1 || f.Context("prod").TrackerURL != "https://new" {
+		t.Errorf("Set did not replace the context: %+v", f.Contexts)
+	}
+
+	f.CurrentContext = "prod"
+	if !f.Remove("prod") {
+		t.Error("Remove of an existing context returned false")
+	}
+	if f.Remove("prod") {
+		t.Error("Remove of a missing context returned true")
+	}
+	if f.CurrentContext != "" || f.Current() != nil {
+		t.Errorf("current context %q after removing it", f.CurrentContext)
+	}
+}
+
+func TestStoreToken_FallsBackToFile(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("the Keychain is always available on macOS")
+	}
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "") // no Secret Service
+	dir := t.TempDir()
+	c := &config.Context{Name: "prod"}
+
+	if err := config.StoreToken(dir, c, "fa_secret", true); err != nil {
+		t.Fatal(err)
+	}
+	if c.Credential != config.CredentialFile {
+		t.Errorf("credential %q, want %q without a keyring", c.Credential, config.CredentialFile)
+	}
+	if token, err := config.Token(dir, c); err != nil || token != "fa_secret" {
+		t.Errorf("Token = %q, %v; want the stored token", token, err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "credentials.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("credentials.yaml has mode %o, want 600", perm)
+	}
+
+	if err := config.DeleteToken(dir, c); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Token(dir, c); err == nil {
+		t.Error("Token after DeleteToken: no error")
+	}
+}
+
+func TestToken_RefusesReadableCredentials(t *testing.T) {
+	dir := t.TempDir()
+	c := &config.Context{Name: "prod"}
+	if err := config.StoreToken(dir, c, "fa_secret", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(dir, "credentials.yaml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Token(dir, c); err == nil || !strings.Contains(err.Error(), "chmod 600") {
+		t.Errorf("Token from a world-readable credentials file: err = %v, want it refused", err)
+	}
+}
+
+func TestToken_Credentials(t *testing.T) {
+	t.Setenv("FARMOPS_TEST_TOKEN", "fa_from_env")
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		credential string
+		want       string
+		wantErr    bool
+	}{
+		{"", "", false},
+		{"env:FARMOPS_TEST_TOKEN", "fa_from_env", false},
+		{"file", "", true}, // no credentials file yet
+		{"vault", "", true},
+	} {
+		token, err := config.Token(dir, &config.Context{Name: "prod", Credential: tc.credential})
+		if token != tc.want || (err != nil) != tc.wantErr {
+			t.Errorf("Token with credential %q = %q, %v; want %q, error %v", tc.credential, token, err, tc.want, tc.wantErr)
+		}
+	}
+}
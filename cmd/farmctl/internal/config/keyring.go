@@ -0,0 +1,108 @@
+package config
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringService is the service name tokens are filed under in the keyring.
+const keyringService = "farmops"
+
+// errNoKeyring is returned where farmctl cannot reach an OS keyring.
+var errNoKeyring = errors.New("config: no OS keyring available")
+
+// The OS keyring is reached through its command-line tool, which keeps
+// farmctl free of cgo: security(1) for the macOS Keychain and secret-tool(1)
+// for the Secret Service (GNOME Keyring, KWallet) on Linux. Secrets are
+// passed on stdin, never as arguments, which other users can see in ps.
+
+func keyringSet(account, secret string) error {
+	switch {
+	case runtime.GOOS == "darwin":
+		// security -i reads commands from stdin; -X takes the secret in hex,
+		// so it needs no quoting.
+		if strings.ContainsAny(account, "\"\\\n") {
+			return fmt.Errorf("config: context name %q cannot be used as a Keychain account", account)
+		}
+		cmd := fmt.Sprintf("add-generic-password -U -s %s -a \"%s\" -X %s\n", keyringService, account, hex.EncodeToString([]byte(secret)))
+		if err := runKeyring(strings.NewReader(cmd), "security", "-i"); err != nil {
+			return err
+		}
+		// security -i exits zero even if a command fails.
+		if stored, err := keyringGet(account); err != nil || stored != secret {
+			return fmt.Errorf("config: the Keychain did not store the token for context %s", account)
+		}
+		return nil
+	case secretService():
+		return runKeyring(strings.NewReader(secret), "secret-tool", "store",
+			"--label", "FarmOps token ("+account+")", "service", keyringService, "account", account)
+	}
+	return errNoKeyring
+}
+
+func keyringGet(account string) (string, error) {
+	var out bytes.Buffer
+	var err error
+	switch {
+	case runtime.GOOS == "darwin":
+		err = runKeyringOut(&out, "security", "find-generic-password", "-s", keyringService, "-a", account, "-w")
+	case secretService():
+		err = runKeyringOut(&out, "secret-tool", "lookup", "service", keyringService, "account", account)
+	default:
+		return "", errNoKeyring
+	}
+	if err != nil {
+		return "", fmt.Errorf("config: no token for context %s in the OS keyring: %w", account, err)
+	}
+	return strings.TrimRight(out.String(), "\n"), nil
+}
+
+func keyringDelete(account string) error {
+	switch {
+	case runtime.GOOS == "darwin":
+		return runKeyring(nil, "security", "delete-generic-password", "-s", keyringService, "-a", account)
+	case secretService():
+		return runKeyring(nil, "secret-tool", "clear", "service", keyringService, "account", account)
+	}
+	return errNoKeyring
+}
+
+// secretService reports whether secret-tool and a session bus to reach the
+// Secret Service are available.
+func secretService() bool {
+	if runtime.GOOS != "linux" || os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return false
+	}
+	_, err := exec.LookPath("secret-tool")
+	return err == nil
+}
+
+func runKeyring(stdin *strings.Reader, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func runKeyringOut(out *bytes.Buffer, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = out
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -5,14 +5,20 @@ package main
 import (
 	"context"
 	"crypto/ed25519"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
 	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/render"
+	"github.com/farmops/farmops/pkg/signer"
 	"github.com/farmops/farmops/pkg/transport"
 )
 
@@ -22,25 +28,121 @@ Usage:
   farmctl <command> [flags]
 
 Commands:
-  agent keygen              Generate a new Ed25519 keypair for an agent
-  agent enroll              Enroll an agent with the Stats Tracker
+  agent keygen [-format hex|pem|openssh|jwk] [-out key.pem -passphrase-file f]
+                            Generate a new Ed25519 keypair for an agent; with
+                            -out, write the private key passphrase-encrypted
+  agent enroll <agent-id> <cluster-alias> <public-key | key-file>
+                            Enroll an agent with the Stats Tracker; the key may
+                            be hex, PEM, OpenSSH or JWK
   agent approve <agent-id>  Approve a pending agent
-  agent revoke  <agent-id>  Revoke an agent's trust
+  agent revoke  <agent-id> [-from-proof id | -from-time t] [-reason r]
+                            Revoke an agent's trust; from a point in its chain,
+                            also invalidate later proofs and reverse their coins
   agent list                List all registered agents
+  agent rotate-key <agent-id> <cluster-alias> -current-key f [-passphrase-file p] [-out new.pem]
+                            Rotate an agent's signing key with a rotation proof
+                            signed by its current key, read from a file
 
-  farm status               Show current farm state
+  farm status [-watch]      Show current farm state; with -watch, again on
+                            every change until interrupted
+  farm show                 Draw the farm in the terminal
   farm profile              Show public farm profile
+  prompt [-format tmpl] [-stale 10m] [-refresh 1m]
+                            Print a compact status such as 🌾 1,250c 🔥14d for
+                            shell prompts and status bars, from a local cache
+  prompt refresh [-every d] Update the prompt cache (run in the background by
+                            prompt when the cache is old)
+  top [-interval 30s] [-stale 2h]
+                            Live dashboard: balance, streak countdown, coins
+                            per category today vs yesterday, agent health and
+                            incoming proofs (q to quit)
+
+  token create -scope agent|admin|read [-agent-id id] [-name n] [-expires 2160h]
+                            Mint a scoped API token (printed once)
+  token list                List API tokens
+  token rotate <token-id>   Replace a token's secret (printed once)
+  token revoke <token-id>   Permanently disable a token
+
+  join-token create [-alias a] [-auto-approve] [-expires 1h] [-name n]
+                            Mint a single-use token agents enroll themselves with
+  join-token list           List join tokens
+  join-token revoke <id>    Disable an unused join token
+
+  webhook add <url> [event...]  Subscribe a URL to tracker events (all if none given)
+        -format json|slack|discord, -secret <key> (generated if omitted)
+  webhook list              List webhook subscriptions
+  webhook remove <id>       Delete a webhook subscription
+  webhook deliveries <id>   Show recent delivery attempts
+  webhook dead-letters      List deliveries that exhausted their retries
+  webhook redeliver <dead-letter-id>  Retry a dead-lettered delivery
+
+  context list              List the contexts in the config file
+  context use <name>        Make a context the current one
+  context add <name> -tracker <url> [-token t|- | -token-env VAR] [-store keyring|file]
+        [-tls-ca f] [-tls-cert f -tls-key f] [-output fmt] [-use]
+                            Add or replace a context; the token goes to the OS
+                            keyring, or a 0600 credentials file without one
+  context remove <name>     Delete a context and its stored token
+  context current           Print the current context's name
+
+  scoring simulate [-category c] [-complexity low|medium|high] [-impact n] [-streak n]
+        [-upgrade x | -buildings slug:level,...] [-proof file.json] [-farm]
+        [-config default|live|file]... [-scenarios file.csv]
+                            Preview the coins a proof would earn and why;
+                            repeat -config to compare scoring configs
+
+  doctor [-config agent.yaml] [-stale 24h] [-agent-env]
+                            Diagnose an agent end to end: its config and key,
+                            the tracker connection and TLS, its token, trust
+                            status and latest proof, with hints to fix failures;
+                            -agent-env applies the agent's FARMOPS_* overrides
+
+  audit [-limit n]          Show recent administrative actions
+
+  export [-out file.tar.gz] Download a signed bundle of all agents, proof
+                            chains and the coin ledger
+  import -signer-key key [-dry-run] <bundle>
+                            Verify a bundle and load it into an empty tracker
+
+  pki init                  Create a local CA for mutual TLS (in -dir, default ./pki)
+  pki server <host>...      Issue the tracker's server certificate
+  pki agent <agent-id>      Issue an agent client certificate bound to its agent_id
+
+  plugin new [-categories c,...] [-source type] [-resources r,...] [-module m] <owner/name>
+                            Generate a Go plugin project: FarmPlugin server,
+                            tests, Dockerfile, manifest entry and agent config
 
   proof inspect <proof-id>  Inspect a stored proof (not yet implemented)
+  proof verify [-file proofs.jsonl | bundle] [-agent id] -key <public-key | key-file> | -trust agents.json | -signer-key key
+                            Verify proof chains offline: signatures, links,
+                            gaps and forks; exits non-zero on any issue
+  proof disclose <proof-id> [-vault-dir d] [-key-file f] [-out file] [-check]
+                            Export a proof's evidence and salt from the agent's
+                            evidence vault, for an auditor
 
 Flags:
-  -tracker  Stats Tracker base URL (default: http://localhost:8443)
-  -key      API key for authenticated operations (or FARMOPS_API_KEY env var)
+  -context  Named context from ~/.config/farmops/config.yaml (or FARMOPS_CONTEXT);
+            defaults to the current context
+  -tracker  Stats Tracker base URL (default: the context's, else http://localhost:8443)
+  -key      API token for authenticated operations (or FARMOPS_API_KEY env var)
+
+Flags and FARMOPS_* variables override the selected context.
+  -tls-ca   CA bundle for the tracker's certificate (or FARMOPS_TLS_CA)
+  -tls-cert Client certificate for mutual TLS (or FARMOPS_TLS_CERT)
+  -tls-key  Client certificate key (or FARMOPS_TLS_KEY)
+  -o        Output format: table, wide, json, yaml or template=<Go template>
+            (or FARMOPS_OUTPUT; default: the context's, else table)
 `
 
 func main() {
-	trackerURL := flag.String("tracker", envOr("FARMOPS_TRACKER_URL", "http://localhost:8443"), "Stats Tracker base URL")
+	contextName := flag.String("context", os.Getenv("FARMOPS_CONTEXT"), "named context from the config file")
+	output := flag.String("o", os.Getenv("FARMOPS_OUTPUT"), "output format: table, wide, json, yaml or template=<text/template>")
+	trackerURL := flag.String("tracker", os.Getenv("FARMOPS_TRACKER_URL"), "Stats Tracker base URL")
 	apiKey := flag.String("key", os.Getenv("FARMOPS_API_KEY"), "API key")
+	tlsFiles := transport.TLSFiles{}
+	flag.StringVar(&tlsFiles.CAFile, "tls-ca", os.Getenv("FARMOPS_TLS_CA"), "CA bundle for the tracker's certificate")
+	flag.StringVar(&tlsFiles.CertFile, "tls-cert", os.Getenv("FARMOPS_TLS_CERT"), "client certificate for mutual TLS")
+	flag.StringVar(&tlsFiles.KeyFile, "tls-key", os.Getenv("FARMOPS_TLS_KEY"), "client certificate key")
 	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
 	flag.Parse()
 
@@ -50,35 +152,174 @@ func main() {
 		os.Exit(1)
 	}
 
+	if args[0] == "context" {
+		setOutput(*output)
+		cmdContext(args[1:])
+		return
+	}
+
+	// The prompt is printed from a cache on every shell prompt; it needs no
+	// credentials and must not wait on a keyring.
+	prompting := args[0] == "prompt" && (len(args) < 2 || args[1] != "refresh")
+	key := apiKey
+	if prompting {
+		key = nil
+	}
+
+	// Flags and environment variables take precedence over the context.
+	if c := applyContext(*contextName, trackerURL, key, &tlsFiles); c != nil && *output == "" {
+		*output = c.Output
+	}
+	setOutput(*output)
+	if *trackerURL == "" {
+		*trackerURL = "http://localhost:8443"
+	}
+	if prompting {
+		cmdPrompt(*trackerURL, os.Args[1:len(os.Args)-len(args)], args[1:])
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	client := transport.NewTrackerClient(*trackerURL, *apiKey)
+	tlsConfig, err := tlsFiles.Config()
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	client := transport.NewTrackerClientTLS(*trackerURL, *apiKey, tlsConfig)
 
 	switch {
 	case len(args) >= 2 && args[0] == "agent" && args[1] == "keygen":
-		cmdAgentKeygen()
+		cmdAgentKeygen(args[2:])
 
 	case len(args) >= 2 && args[0] == "agent" && args[1] == "enroll":
 		if len(args) < 5 {
-			fatalf("usage: farmctl agent enroll <agent-id> <cluster-alias> <public-key-hex>\n")
+			fatalf("usage: farmctl agent enroll <agent-id> <cluster-alias> <public-key | key-file>\n")
 		}
 		cmdAgentEnroll(ctx, client, args[2], args[3], args[4])
 
 	case len(args) >= 3 && args[0] == "agent" && args[1] == "approve":
-		cmdAgentSetStatus(ctx, *trackerURL, *apiKey, args[2], "approve")
+		if err := client.ApproveAgent(ctx, args[2]); err != nil {
+			fatalf("approve agent: %v\n", err)
+		}
+		report(Result{Kind: "agent", ID: args[2], Status: "active"}, "Agent %s approved; its proofs are now accepted.\n", args[2])
 
 	case len(args) >= 3 && args[0] == "agent" && args[1] == "revoke":
-		cmdAgentSetStatus(ctx, *trackerURL, *apiKey, args[2], "revoke")
+		cmdAgentRevoke(ctx, client, args[2], args[3:])
+
+	case len(args) >= 2 && args[0] == "agent" && args[1] == "rotate-key":
+		if len(args) < 4 {
+			fatalf("usage: farmctl agent rotate-key <agent-id> <cluster-alias> -current-key <file>\n")
+		}
+		cmdAgentRotateKey(ctx, client, args[2], args[3], args[4:])
 
 	case len(args) >= 2 && args[0] == "agent" && args[1] == "list":
-		cmdAgentList(ctx, *trackerURL, *apiKey)
+		cmdAgentList(ctx, client)
 
 	case len(args) >= 2 && args[0] == "farm" && args[1] == "status":
-		cmdFarmStatus(ctx, *trackerURL)
+		cmdFarmStatus(ctx, client, args[2:])
+
+	case len(args) >= 2 && args[0] == "farm" && args[1] == "show":
+		cmdFarmShow(ctx, client)
 
 	case len(args) >= 2 && args[0] == "farm" && args[1] == "profile":
-		cmdFarmProfile(ctx, *trackerURL)
+		cmdFarmProfile(ctx, client)
+
+	case len(args) >= 2 && args[0] == "prompt" && args[1] == "refresh":
+		cmdPromptRefresh(ctx, client, *trackerURL, args[2:])
+
+	case args[0] == "top":
+		if out.structured() {
+			fatalf("top is interactive; for structured output use farm status -watch\n")
+		}
+		cmdTop(ctx, client, args[1:])
+
+	case len(args) >= 2 && args[0] == "scoring" && args[1] == "simulate":
+		cmdScoringSimulate(ctx, client, args[2:])
+
+	case args[0] == "export":
+		cmdExport(ctx, client, args[1:])
+
+	case args[0] == "import":
+		cmdImport(ctx, client, args[1:])
+
+	case args[0] == "doctor":
+		cmdDoctor(ctx, client, *trackerURL, args[1:])
+
+	case args[0] == "audit":
+		cmdAudit(ctx, client, args[1:])
+
+	case args[0] == "pki":
+		cmdPKI(args[1:])
+
+	case args[0] == "plugin":
+		cmdPlugin(args[1:])
+
+	case len(args) >= 2 && args[0] == "proof" && args[1] == "verify":
+		cmdProofVerify(ctx, client, args[2:])
+
+	case len(args) >= 2 && args[0] == "proof" && args[1] == "disclose":
+		cmdProofDisclose(ctx, client, args[2:])
+
+	case len(args) >= 2 && args[0] == "token" && args[1] == "create":
+		cmdTokenCreate(ctx, client, args[2:])
+
+	case len(args) >= 2 && args[0] == "token" && args[1] == "list":
+		cmdTokenList(ctx, client)
+
+	case len(args) >= 3 && args[0] == "token" && args[1] == "rotate":
+		t, err := client.RotateToken(ctx, args[2])
+		if err != nil {
+			fatalf("rotate token: %v\n", err)
+		}
+		if out.structured() {
+			out.print(t)
+			break
+		}
+		fmt.Printf("Token %s rotated; the old secret no longer works.\n", t.ID)
+		fmt.Printf("New token (shown once):\n  %s\n", t.Token)
+
+	case len(args) >= 3 && args[0] == "token" && args[1] == "revoke":
+		if err := client.RevokeToken(ctx, args[2]); err != nil {
+			fatalf("revoke token: %v\n", err)
+		}
+		report(Result{Kind: "token", ID: args[2], Status: "revoked"}, "Token %s revoked.\n", args[2])
+
+	case len(args) >= 2 && args[0] == "join-token" && args[1] == "create":
+		cmdJoinTokenCreate(ctx, client, args[2:])
+
+	case len(args) >= 2 && args[0] == "join-token" && args[1] == "list":
+		cmdJoinTokenList(ctx, client)
+
+	case len(args) >= 3 && args[0] == "join-token" && args[1] == "revoke":
+		if err := client.RevokeJoinToken(ctx, args[2]); err != nil {
+			fatalf("revoke join token: %v\n", err)
+		}
+		report(Result{Kind: "join-token", ID: args[2], Status: "revoked"}, "Join token %s revoked.\n", args[2])
+
+	case len(args) >= 2 && args[0] == "webhook" && args[1] == "add":
+		cmdWebhookAdd(ctx, client, args[2:])
+
+	case len(args) >= 2 && args[0] == "webhook" && args[1] == "list":
+		cmdWebhookList(ctx, client)
+
+	case len(args) >= 3 && args[0] == "webhook" && args[1] == "remove":
+		if err := client.DeleteWebhook(ctx, args[2]); err != nil {
+			fatalf("remove webhook: %v\n", err)
+		}
+		report(Result{Kind: "webhook", ID: args[2], Status: "removed"}, "Webhook %s removed.\n", args[2])
+
+	case len(args) >= 3 && args[0] == "webhook" && args[1] == "deliveries":
+		cmdWebhookDeliveries(ctx, client, args[2])
+
+	case len(args) >= 2 && args[0] == "webhook" && args[1] == "dead-letters":
+		cmdWebhookDeadLetters(ctx, client)
+
+	case len(args) >= 3 && args[0] == "webhook" && args[1] == "redeliver":
+		if err := client.RedeliverDeadLetter(ctx, args[2]); err != nil {
+			fatalf("redeliver: %v\n", err)
+		}
+		report(Result{Kind: "dead-letter", ID: args[2], Status: "redelivering"}, "Dead letter %s queued for redelivery.\n", args[2])
 
 	default:
 		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n%s", args[0], usage)
@@ -86,68 +327,606 @@ func main() {
 	}
 }
 
-// cmdAgentKeygen generates a new Ed25519 keypair and prints both keys.
+// cmdAgentKeygen generates a new Ed25519 keypair and prints both keys, or
+// writes the private key to an encrypted key file for the agent's signer.
 // The private key must be stored securely (Kubernetes Secret, Vault, etc.).
-func cmdAgentKeygen() {
+func cmdAgentKeygen(args []string) {
+	fs := flag.NewFlagSet("agent keygen", flag.ExitOnError)
+	format := fs.String("format", proof.FormatHex, "encoding of the printed keys: "+strings.Join(proof.KeyFormats, ", "))
+	keyFile := fs.String("out", "", "write the private key to this passphrase-encrypted PEM file")
+	passphraseFile := fs.String("passphrase-file", "", "file holding the passphrase for -out")
+	_ = fs.Parse(args)
+	if *keyFile != "" && *passphraseFile == "" {
+		fatalf("-out needs -passphrase-file\n")
+	}
+
 	pub, priv, err := proof.GenerateKeyPair()
 	if err != nil {
 		fatalf("keygen failed: %v\n", err)
 	}
-	fmt.Printf("Public key (share with tracker during enrollment):\n  %s\n\n", proof.EncodePublicKey(pub))
-	fmt.Printf("Private key (store securely — never share):\n  %s\n\n", proof.EncodePrivateKey(priv))
-	fmt.Println("Store the private key as FARMOPS_PRIVATE_KEY in your agent config or Kubernetes Secret.")
+	kp := KeyPair{Format: *format, KeyFile: *keyFile}
+	if kp.PublicKey, err = proof.FormatPublicKey(pub, *format); err != nil {
+		fatalf("%v\n", err)
+	}
+	if *keyFile != "" {
+		passphrase, err := signer.ReadPassphrase(*passphraseFile)
+		if err != nil {
+			fatalf("%v\n", err)
+		}
+		if err := signer.WriteFile(*keyFile, priv, passphrase); err != nil {
+			fatalf("%v\n", err)
+		}
+	} else if kp.PrivateKey, err = proof.FormatPrivateKey(priv, *format); err != nil {
+		fatalf("%v\n", err)
+	}
+	if out.structured() {
+		out.print(kp)
+		return
+	}
+
+	fmt.Printf("Public key (share with tracker during enrollment):\n%s\n\n", indent(kp.PublicKey))
+	if *keyFile != "" {
+		fmt.Printf("Encrypted private key written to %s; point the agent's signer.key_file at it.\n", *keyFile)
+		return
+	}
+	fmt.Printf("Private key (store securely — never share):\n%s\n\n", indent(kp.PrivateKey))
+	fmt.Println("Store the private key in your agent's private_key or a Kubernetes Secret for signer.secret; never in an environment variable.")
 }
 
-// cmdAgentEnroll sends an enrollment request to the Stats Tracker.
-func cmdAgentEnroll(ctx context.Context, _ *transport.TrackerClient, agentID, clusterAlias, pubKeyHex string) {
-	// Validate the public key before sending.
-	if _, err := proof.DecodePublicKey(pubKeyHex); err != nil {
+// indent indents a one-line key by two spaces. PEM blocks are left as they
+// are so they can be copied verbatim.
+func indent(s string) string {
+	s = strings.TrimRight(s, "\n")
+	if strings.Contains(s, "\n") {
+		return s
+	}
+	return "  " + s
+}
+
+// cmdAgentEnroll sends an enrollment request to the Stats Tracker. key is the
+// public key in any supported encoding, or a file holding it.
+func cmdAgentEnroll(ctx context.Context, client *transport.TrackerClient, agentID, clusterAlias, key string) {
+	if data, err := os.ReadFile(key); err == nil {
+		key = string(data)
+	}
+	// Validate the public key before sending, and send it in the canonical
+	// encoding.
+	pub, err := proof.DecodePublicKey(key)
+	if err != nil {
 		fatalf("invalid public key: %v\n", err)
 	}
+	if err := client.EnrollAgent(ctx, agentID, clusterAlias, proof.EncodePublicKey(pub)); errors.Is(err, transport.ErrConflict) {
+		fatalf("agent %s is already enrolled; use farmctl agent rotate-key to change its key\n", agentID)
+	} else if err != nil {
+		fatalf("enroll agent: %v\n", err)
+	}
+	if out.structured() {
+		out.print(Result{Kind: "agent", ID: agentID, Status: "pending"})
+		return
+	}
+	fmt.Printf("Agent %s (alias: %s) enrolled, pending approval.\n", agentID, clusterAlias)
+	fmt.Printf("Approve it with:\n  farmctl agent approve %s\n", agentID)
+}
+
+// cmdAgentRotateKey rotates the signing key of an agent whose private key is
+// managed outside a credentials store. -key must be the agent's token or an
+// admin token. The current key is read from a file, never the environment:
+// a plain key in any supported encoding, or an encrypted key file with
+// -passphrase-file. The agent must be restarted with the new key, since
+// proofs signed with the old key are rejected from now on.
+func cmdAgentRotateKey(ctx context.Context, client *transport.TrackerClient, agentID, clusterAlias string, args []string) {
+	fs := flag.NewFlagSet("agent rotate-key", flag.ExitOnError)
+	currentFile := fs.String("current-key", "", "file holding the agent's current private key")
+	passphraseFile := fs.String("passphrase-file", "", "file holding the passphrase for an encrypted -current-key and for -out")
+	keyFile := fs.String("out", "", "write the new private key to this passphrase-encrypted PEM file")
+	_ = fs.Parse(args)
+	if *currentFile == "" {
+		fatalf("-current-key is required\n")
+	}
+	if *keyFile != "" && *passphraseFile == "" {
+		fatalf("-out needs -passphrase-file\n")
+	}
+	if _, err := os.Stat(*keyFile); *keyFile != "" && err == nil {
+		fatalf("%s already exists; the new key is written to a new file\n", *keyFile)
+	}
+	current, passphrase := readCurrentKey(*currentFile, *passphraseFile)
+
+	head, err := client.LatestProof(ctx, agentID)
+	if err != nil {
+		fatalf("get chain head: %v\n", err)
+	}
+	pepper, err := client.ActorPepper(ctx)
+	if err != nil {
+		fatalf("get actor pepper: %v\n", err)
+	}
+	pub, priv, err := proof.GenerateKeyPair()
+	if err != nil {
+		fatalf("keygen failed: %v\n", err)
+	}
+	p, err := proof.NewKeyRotation(proof.AgentInfo{AgentID: agentID, ClusterAlias: clusterAlias}, pub, pepper, head)
+	if err != nil {
+		fatalf("build rotation proof: %v\n", err)
+	}
+	if err := proof.Sign(p, current); err != nil {
+		fatalf("sign rotation proof: %v\n", err)
+	}
+	resp, err := client.SubmitProof(ctx, p)
+	if err != nil {
+		fatalf("submit rotation proof: %v\n", err)
+	}
+	if !resp.Accepted {
+		fatalf("rotation proof rejected: %s\n", resp.RejectionReason)
+	}
+	rotation := KeyRotation{AgentID: agentID, RotationProofID: p.ProofID, PublicKey: proof.EncodePublicKey(pub), KeyFile: *keyFile}
+	if *keyFile != "" {
+		if err := signer.WriteFile(*keyFile, priv, passphrase); err != nil {
+			fatalf("key rotated, but writing the new key failed; the agent cannot sign until it has it: %v\n", err)
+		}
+	} else {
+		rotation.PrivateKey = proof.EncodePrivateKey(priv)
+	}
+	if out.structured() {
+		out.print(rotation)
+		return
+	}
+	fmt.Printf("Key rotated for agent %s (rotation proof %s).\n\n", agentID, p.ProofID)
+	if *keyFile != "" {
+		fmt.Printf("Encrypted new private key written to %s.\n", *keyFile)
+	} else {
+		fmt.Printf("New private key (store securely — never share):\n  %s\n\n", rotation.PrivateKey)
+	}
+	fmt.Println("Replace the agent's private_key or signer key with it and restart the agent; the old key no longer verifies.")
+}
+
+// readCurrentKey reads an agent's private key from path, in any encoding
+// proof.DecodePrivateKey accepts or as a key file encrypted with the
+// passphrase in passphraseFile. It returns the passphrase for -out.
+func readCurrentKey(path, passphraseFile string) (ed25519.PrivateKey, []byte) {
+	var passphrase []byte
+	if passphraseFile != "" {
+		var err error
+		if passphrase, err = signer.ReadPassphrase(passphraseFile); err != nil {
+			fatalf("%v\n", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fatalf("read current key: %v\n", err)
+	}
+	priv, err := proof.DecodePrivateKey(string(data))
+	if err != nil && passphrase != nil {
+		priv, err = signer.DecryptKey(data, passphrase)
+	}
+	if err != nil {
+		fatalf("%s must hold the agent's current private key: %v\n", path, err)
+	}
+	return priv, passphrase
+}
+
+// cmdAgentRevoke revokes an agent, optionally from a proof or a point in time.
+func cmdAgentRevoke(ctx context.Context, client *transport.TrackerClient, agentID string, args []string) {
+	fs := flag.NewFlagSet("agent revoke", flag.ExitOnError)
+	fromProof := fs.String("from-proof", "", "invalidate this proof and every one chained after it")
+	fromTime := fs.String("from-time", "", "invalidate proofs received at or after this RFC 3339 time")
+	reason := fs.String("reason", "", "why the agent is revoked, recorded in the audit log")
+	_ = fs.Parse(args)
+
+	opts := transport.RevokeOptions{FromProofID: *fromProof, Reason: *reason}
+	if *fromTime != "" {
+		t, err := time.Parse(time.RFC3339, *fromTime)
+		if err != nil {
+			fatalf("invalid -from-time: %v\n", err)
+		}
+		opts.FromTime = t
+	}
+	res, err := client.RevokeAgent(ctx, agentID, opts)
+	if err != nil {
+		fatalf("revoke agent: %v\n", err)
+	}
+	if out.structured() {
+		out.print(res)
+		return
+	}
+	fmt.Printf("Agent %s revoked.\n", agentID)
+	if len(res.InvalidatedProofs) > 0 {
+		fmt.Printf("Invalidated %d proofs and reversed %d coins.\n", len(res.InvalidatedProofs), res.CoinsReversed)
+	}
+}
+
+func cmdAgentList(ctx context.Context, client *transport.TrackerClient) {
+	agents, err := client.ListAgents(ctx)
+	if err != nil {
+		fatalf("list agents: %v\n", err)
+	}
+	if out.structured() {
+		out.print(agents)
+		return
+	}
+	rows := make([][]string, 0, len(agents))
+	for _, a := range agents {
+		key := a.PublicKey
+		if len(key) > 16 && !out.wide() {
+			key = key[:16] + "…"
+		}
+		row := []string{a.AgentID, a.ClusterAlias, a.Status, key, a.EnrolledAt.Local().Format(time.DateTime)}
+		if out.wide() {
+			revoked := "-"
+			if a.RevokedAt != nil {
+				revoked = a.RevokedAt.Local().Format(time.DateTime)
+			}
+			row = append(row, fmt.Sprint(len(a.KeyHistory)), revoked)
+		}
+		rows = append(rows, row)
+	}
+	headers := []string{"AGENT", "ALIAS", "STATUS", "KEY", "ENROLLED"}
+	if out.wide() {
+		headers = append(headers, "RETIRED KEYS", "REVOKED")
+	}
+	printTable(headers, rows)
+}
+
+// cmdFarmStatus prints the farm's balance and streak, then its proofs and
+// coins per category. With -watch it prints the status again whenever the
+// tracker's event stream reports a change, until interrupted.
+func cmdFarmStatus(ctx context.Context, client *transport.TrackerClient, args []string) {
+	fs := flag.NewFlagSet("farm status", flag.ExitOnError)
+	watch := fs.Bool("watch", false, "print the status again on every change")
+	_ = fs.Parse(args)
+
+	st, err := client.Status(ctx)
+	if err != nil {
+		fatalf("get farm status: %v\n", err)
+	}
+	printFarmStatus(st, *watch)
+	if !*watch {
+		return
+	}
+
+	// The stream runs until interrupted, not for the usual request timeout.
+	ctx, stop := signal.NotifyContext(context.WithoutCancel(ctx), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var lastID uint64
+	for {
+		err := client.StreamEvents(ctx, lastID, statusEvents, func(ev transport.Event) error {
+			lastID = ev.ID
+			reqCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			st, err := client.Status(reqCtx)
+			if err != nil {
+				return err
+			}
+			printFarmStatus(st, true)
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "watch: %v; reconnecting\n", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+// statusEvents are the tracker events that change what farm status shows.
+var statusEvents = []string{
+	transport.EventCoinsAwarded, transport.EventRewardReversed,
+	transport.EventUpgradePurchased, transport.EventStreakBroken,
+}
+
+func printFarmStatus(st *transport.FarmStatus, watching bool) {
+	if out.structured() {
+		out.print(st)
+		return
+	}
+	if watching {
+		if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fmt.Print("\x1b[H\x1b[2J")
+		} else if out.printed > 0 {
+			fmt.Println()
+		}
+		out.printed++
+	}
+	fmt.Printf("Farm:     %s\n", st.Name)
+	fmt.Printf("Balance:  %d coins (%d earned)\n", st.CurrentCoins, st.TotalCoins)
+	fmt.Printf("Streak:   %d days\n", st.StreakDays)
+	if st.LastActiveAt != nil {
+		fmt.Printf("Active:   %s\n", st.LastActiveAt.Local().Format(time.DateTime))
+	}
+	if len(st.Categories) == 0 {
+		return
+	}
+
+	categories := make([]string, 0, len(st.Categories))
+	for c := range st.Categories {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+	rows := make([][]string, 0, len(categories))
+	for _, c := range categories {
+		last := "-"
+		if t := st.Categories[c].LastProofAt; t != nil {
+			last = t.Local().Format(time.DateTime)
+		}
+		rows = append(rows, []string{c, fmt.Sprint(st.Categories[c].Proofs), fmt.Sprint(st.Categories[c].Coins), last})
+	}
+	fmt.Println()
+	printTable([]string{"CATEGORY", "PROOFS", "COINS", "LAST PROOF"}, rows)
+}
+
+// cmdFarmShow draws the farm world as ANSI truecolor art.
+func cmdFarmShow(ctx context.Context, client *transport.TrackerClient) {
+	world, err := client.GetWorld(ctx)
+	if err != nil {
+		fatalf("get farm: %v\n", err)
+	}
+	if out.structured() {
+		out.print(world)
+		return
+	}
+	if err := render.ANSI(os.Stdout, world, render.Options{}); err != nil {
+		fatalf("render farm: %v\n", err)
+	}
+}
 
-	body, _ := json.Marshal(map[string]string{
-		"agent_id":      agentID,
-		"cluster_alias": clusterAlias,
-		"public_key":    pubKeyHex,
+func cmdFarmProfile(ctx context.Context, client *transport.TrackerClient) {
+	p, err := client.PublicProfile(ctx)
+	if err != nil {
+		fatalf("get profile: %v\n", err)
+	}
+	if out.structured() {
+		out.print(p)
+		return
+	}
+	printTable([]string{"FARM", "TOTAL COINS", "BALANCE", "STREAK"}, [][]string{
+		{p.FarmName, fmt.Sprint(p.TotalCoins), fmt.Sprint(p.CurrentCoins), fmt.Sprintf("%d days", p.StreakDays)},
 	})
+}
 
-	// Use a raw HTTP call since TrackerClient.SubmitProof is proof-specific.
-	// A general-purpose API client will be added in Phase 1.
-	fmt.Printf("Enrolling agent %s (alias: %s)...\n", agentID, clusterAlias)
-	fmt.Printf("POST /api/v1/agents/enroll\nBody: %s\n", body)
-	fmt.Println("\nNote: use curl or the tracker API directly for now.")
-	fmt.Printf("  curl -X POST %s/api/v1/agents/enroll \\\n", "http://localhost:8443")
-	fmt.Printf("    -H 'Authorization: Bearer $FARMOPS_API_KEY' \\\n")
-	fmt.Printf("    -H 'Content-Type: application/json' \\\n")
-	fmt.Printf("    -d '%s'\n", body)
-	fmt.Printf("\nThen approve with:\n  farmctl agent approve %s\n", agentID)
+// cmdTokenCreate mints a scoped API token.
+func cmdTokenCreate(ctx context.Context, client *transport.TrackerClient, args []string) {
+	fs := flag.NewFlagSet("token create", flag.ExitOnError)
+	scope := fs.String("scope", "", "token scope: agent, admin or read")
+	agentID := fs.String("agent-id", "", "agent the token may submit proofs for (agent scope)")
+	name := fs.String("name", "", "human-readable label")
+	expires := fs.Duration("expires", 0, "lifetime, e.g. 2160h (default: never expires)")
+	_ = fs.Parse(args)
+	if *scope == "" {
+		fatalf("usage: farmctl token create -scope agent|admin|read [-agent-id id] [-name n] [-expires 2160h]\n")
+	}
+
+	t, err := client.CreateToken(ctx, *name, *scope, *agentID, *expires)
+	if err != nil {
+		fatalf("create token: %v\n", err)
+	}
+	if out.structured() {
+		out.print(t)
+		return
+	}
+	fmt.Printf("Token %s created (scope: %s).\n", t.ID, t.Scope)
+	fmt.Printf("Token (shown once — store it securely):\n  %s\n", t.Token)
 }
 
-func cmdAgentSetStatus(ctx context.Context, trackerURL, apiKey, agentID, action string) {
-	url := fmt.Sprintf("%s/api/v1/agents/%s/%s", trackerURL, agentID, action)
-	fmt.Printf("POST %s\n", url)
-	fmt.Println("Note: direct HTTP client for agent management will be added in Phase 1.")
-	fmt.Printf("  curl -X POST '%s' -H 'Authorization: Bearer %s'\n", url, apiKey)
+func cmdTokenList(ctx context.Context, client *transport.TrackerClient) {
+	tokens, err := client.ListTokens(ctx)
+	if err != nil {
+		fatalf("list tokens: %v\n", err)
+	}
+	if out.structured() {
+		out.print(tokens)
+		return
+	}
+	rows := make([][]string, 0, len(tokens))
+	for _, t := range tokens {
+		state := "active"
+		switch {
+		case t.RevokedAt != nil:
+			state = "revoked"
+		case t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt):
+			state = "expired"
+		}
+		row := []string{t.ID, t.Name, t.Scope, t.AgentID, state, t.CreatedAt.Local().Format(time.DateTime)}
+		if out.wide() {
+			row = append(row, formatOptionalTime(t.RotatedAt), formatOptionalTime(t.ExpiresAt))
+		}
+		rows = append(rows, row)
+	}
+	headers := []string{"ID", "NAME", "SCOPE", "AGENT", "STATE", "CREATED"}
+	if out.wide() {
+		headers = append(headers, "ROTATED", "EXPIRES")
+	}
+	printTable(headers, rows)
 }
 
-func cmdAgentList(ctx context.Context, trackerURL, apiKey string) {
-	url := fmt.Sprintf("%s/api/v1/agents", trackerURL)
-	fmt.Printf("GET %s\n", url)
-	fmt.Println("Note: direct HTTP client will be added in Phase 1.")
-	fmt.Printf("  curl '%s' -H 'Authorization: Bearer %s'\n", url, apiKey)
+func cmdJoinTokenCreate(ctx context.Context, client *transport.TrackerClient, args []string) {
+	fs := flag.NewFlagSet("join-token create", flag.ExitOnError)
+	alias := fs.String("alias", "", "cluster alias assigned to the joining agent (default: the agent's own)")
+	autoApprove := fs.Bool("auto-approve", false, "enroll the agent as active without manual approval")
+	expires := fs.Duration("expires", time.Hour, "lifetime of the token")
+	name := fs.String("name", "", "human-readable label")
+	_ = fs.Parse(args)
+
+	t, err := client.CreateJoinToken(ctx, *name, *alias, *autoApprove, *expires)
+	if err != nil {
+		fatalf("create join token: %v\n", err)
+	}
+	if out.structured() {
+		out.print(t)
+		return
+	}
+	fmt.Printf("Join token %s created; valid once until %s.\n", t.ID, t.ExpiresAt.Local().Format(time.DateTime))
+	fmt.Printf("Token (shown once) — set it as the agent's join_token or FARMOPS_JOIN_TOKEN:\n  %s\n", t.Token)
 }
 
-func cmdFarmStatus(ctx context.Context, trackerURL string) {
-	url := fmt.Sprintf("%s/api/v1/farm", trackerURL)
-	fmt.Printf("GET %s\n", url)
-	fmt.Println("Note: direct HTTP client will be added in Phase 1.")
-	fmt.Printf("  curl '%s'\n", url)
+func cmdJoinTokenList(ctx context.Context, client *transport.TrackerClient) {
+	tokens, err := client.ListJoinTokens(ctx)
+	if err != nil {
+		fatalf("list join tokens: %v\n", err)
+	}
+	if out.structured() {
+		out.print(tokens)
+		return
+	}
+	rows := make([][]string, 0, len(tokens))
+	for _, t := range tokens {
+		state := "unused"
+		switch {
+		case t.UsedAt != nil:
+			state = "used by " + t.UsedBy
+		case t.RevokedAt != nil:
+			state = "revoked"
+		case time.Now().After(t.ExpiresAt):
+			state = "expired"
+		}
+		approve := "manual"
+		if t.AutoApprove {
+			approve = "auto"
+		}
+		rows = append(rows, []string{t.ID, t.Name, t.ClusterAlias, approve, state, t.ExpiresAt.Local().Format(time.DateTime)})
+	}
+	printTable([]string{"ID", "NAME", "ALIAS", "APPROVAL", "STATE", "EXPIRES"}, rows)
 }
 
-func cmdFarmProfile(ctx context.Context, trackerURL string) {
-	url := fmt.Sprintf("%s/api/v1/public/profile", trackerURL)
-	fmt.Printf("GET %s\n", url)
-	fmt.Printf("  curl '%s'\n", url)
+// cmdWebhookAdd subscribes a URL to tracker events.
+func cmdWebhookAdd(ctx context.Context, client *transport.TrackerClient, args []string) {
+	fs := flag.NewFlagSet("webhook add", flag.ExitOnError)
+	format := fs.String("format", "json", "payload format: json, slack or discord")
+	secret := fs.String("secret", "", "HMAC signing secret (generated if empty)")
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 {
+		fatalf("usage: farmctl webhook add [-format json|slack|discord] [-secret key] <url> [event...]\n")
+	}
+
+	hook, err := client.CreateWebhook(ctx, fs.Arg(0), fs.Args()[1:], *format, *secret)
+	if err != nil {
+		fatalf("add webhook: %v\n", err)
+	}
+	if out.structured() {
+		out.print(hook)
+		return
+	}
+	fmt.Printf("Webhook %s created.\n", hook.ID)
+	fmt.Printf("Signing secret (shown once — store it with the receiver):\n  %s\n", hook.Secret)
+}
+
+func cmdWebhookList(ctx context.Context, client *transport.TrackerClient) {
+	hooks, err := client.ListWebhooks(ctx)
+	if err != nil {
+		fatalf("list webhooks: %v\n", err)
+	}
+	if out.structured() {
+		out.print(hooks)
+		return
+	}
+	rows := make([][]string, 0, len(hooks))
+	for _, h := range hooks {
+		events := "all"
+		if len(h.Events) > 0 {
+			events = strings.Join(h.Events, ",")
+		}
+		rows = append(rows, []string{h.ID, h.Format, events, h.URL})
+	}
+	printTable([]string{"ID", "FORMAT", "EVENTS", "URL"}, rows)
+}
+
+func cmdWebhookDeliveries(ctx context.Context, client *transport.TrackerClient, id string) {
+	deliveries, err := client.ListWebhookDeliveries(ctx, id, 25)
+	if err != nil {
+		fatalf("list deliveries: %v\n", err)
+	}
+	if out.structured() {
+		out.print(deliveries)
+		return
+	}
+	rows := make([][]string, 0, len(deliveries))
+	for _, d := range deliveries {
+		result := "ok"
+		if d.Error != "" {
+			result = d.Error
+		}
+		rows = append(rows, []string{
+			d.At.Local().Format(time.DateTime), d.EventType, fmt.Sprint(d.Attempt),
+			fmt.Sprint(d.StatusCode), d.Duration.Round(time.Millisecond).String(), result,
+		})
+	}
+	printTable([]string{"TIME", "EVENT", "ATTEMPT", "STATUS", "DURATION", "RESULT"}, rows)
+}
+
+func cmdWebhookDeadLetters(ctx context.Context, client *transport.TrackerClient) {
+	letters, err := client.ListDeadLetters(ctx)
+	if err != nil {
+		fatalf("list dead letters: %v\n", err)
+	}
+	if out.structured() {
+		out.print(letters)
+		return
+	}
+	rows := make([][]string, 0, len(letters))
+	for _, dl := range letters {
+		rows = append(rows, []string{
+			dl.ID, dl.WebhookID, dl.EventType, fmt.Sprint(dl.Attempts),
+			dl.FailedAt.Local().Format(time.DateTime), dl.LastError,
+		})
+	}
+	printTable([]string{"ID", "WEBHOOK", "EVENT", "ATTEMPTS", "FAILED", "LAST ERROR"}, rows)
+}
+
+// cmdAudit shows the most recent entries of the tracker's audit log.
+func cmdAudit(ctx context.Context, client *transport.TrackerClient, args []string) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	limit := fs.Int("limit", 50, "number of entries to show")
+	_ = fs.Parse(args)
+
+	entries, err := client.ListAudit(ctx, *limit)
+	if err != nil {
+		fatalf("list audit log: %v\n", err)
+	}
+	if out.structured() {
+		out.print(entries)
+		return
+	}
+	rows := make([][]string, 0, len(entries))
+	for _, e := range entries {
+		keys := make([]string, 0, len(e.Detail))
+		for k := range e.Detail {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		detail := make([]string, 0, len(keys))
+		for _, k := range keys {
+			detail = append(detail, k+"="+e.Detail[k])
+		}
+		rows = append(rows, []string{e.At.Local().Format(time.DateTime), e.Actor, e.Action, e.Target, strings.Join(detail, " ")})
+	}
+	printTable([]string{"TIME", "ACTOR", "ACTION", "TARGET", "DETAIL"}, rows)
+}
+
+// report prints the outcome of a command on one object: the message for
+// people, or r with a structured -o format.
+func report(r Result, format string, args ...any) {
+	if out.structured() {
+		out.print(r)
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// setOutput selects the -o format.
+func setOutput(format string) {
+	p, err := newPrinter(format)
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	out = p
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
 }
 
 // printTable prints a simple tabular output.
@@ -176,12 +955,5 @@ func fatalf(format string, args ...any) {
 	os.Exit(1)
 }
 
-func envOr(key, fallback string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
-	}
-	return fallback
-}
-
 // Ensure ed25519 is imported (used indirectly via proof package; kept for future direct use).
 var _ ed25519.PublicKey
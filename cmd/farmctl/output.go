@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+// Output formats selected with -o. The structured formats print the types of
+// the tracker API client (package transport), or the result types below for
+// commands the API has no type for. YAML and templates see the same field
+// names as JSON, so a template reads {{.CurrentCoins}} for the "CurrentCoins"
+// field of -o json.
+const (
+	outputTable    = "table"
+	outputWide     = "wide"
+	outputJSON     = "json"
+	outputYAML     = "yaml"
+	outputTemplate = "template="
+)
+
+// printer writes command results in the format selected with -o.
+type printer struct {
+	format  string
+	tmpl    *template.Template
+	printed int
+}
+
+// out is the printer for the -o flag, set up in main.
+var out = &printer{format: outputTable}
+
+// newPrinter parses an -o value.
+func newPrinter(format string) (*printer, error) {
+	switch {
+	case format == "" || format == outputTable:
+		return &printer{format: outputTable}, nil
+	case format == outputWide || format == outputJSON || format == outputYAML:
+		return &printer{format: format}, nil
+	case strings.HasPrefix(format, outputTemplate):
+		tmpl, err := template.New("output").Funcs(template.FuncMap{
+			"json": func(v any) (string, error) {
+				data, err := json.Marshal(v)
+				return string(data), err
+			},
+		}).Parse(strings.TrimPrefix(format, outputTemplate))
+		if err != nil {
+			return nil, fmt.Errorf("invalid -o template: %w", err)
+		}
+		return &printer{format: outputTemplate, tmpl: tmpl}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q (want table, wide, json, yaml or template=...)", format)
+}
+
+// structured reports whether commands print a result object rather than
+// tables and messages.
+func (p *printer) structured() bool {
+	return p.format != outputTable && p.format != outputWide
+}
+
+// wide reports whether tables should include their optional columns.
+func (p *printer) wide() bool {
+	return p.format == outputWide
+}
+
+// print writes v in the selected structured format. Successive results are
+// separate JSON values, YAML documents or template executions.
+func (p *printer) print(v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fatalf("encode output: %v\n", err)
+	}
+	p.printed++
+
+	switch p.format {
+	case outputJSON:
+		os.Stdout.Write(append(data, '\n'))
+
+	case outputYAML:
+		// Going through JSON keeps the field names and order of -o json.
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			fatalf("encode output: %v\n", err)
+		}
+		plainStyle(&node)
+		if p.printed > 1 {
+			fmt.Println("---")
+		}
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			fatalf("encode output: %v\n", err)
+		}
+		enc.Close()
+
+	case outputTemplate:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var generic any
+		if err := dec.Decode(&generic); err != nil {
+			fatalf("encode output: %v\n", err)
+		}
+		var buf bytes.Buffer
+		if err := p.tmpl.Execute(&buf, generic); err != nil {
+			fatalf("-o template: %v\n", err)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		os.Stdout.Write(buf.Bytes())
+	}
+}
+
+// plainStyle drops the JSON quoting and flow style from a parsed node tree
+// so that it encodes as block YAML.
+func plainStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		plainStyle(c)
+	}
+}
+
+// Result is what a command that acts on one object prints with a structured
+// -o format when the tracker answers with no object of its own.
+type Result struct {
+	Kind   string // agent, token, join-token, webhook, dead-letter or context
+	ID     string
+	Status string // state of the object after the command, e.g. "approved"
+}
+
+// KeyPair is the output of agent keygen. PrivateKey is empty when the key was
+// written to KeyFile instead.
+type KeyPair struct {
+	Format     string
+	PublicKey  string
+	PrivateKey string `json:",omitempty"`
+	KeyFile    string `json:",omitempty"`
+}
+
+// KeyRotation is the output of agent rotate-key. PrivateKey is empty when
+// the new key was written to KeyFile instead.
+type KeyRotation struct {
+	AgentID         string
+	RotationProofID string
+	PublicKey       string
+	PrivateKey      string `json:",omitempty"`
+	KeyFile         string `json:",omitempty"`
+}
+
+// ChainResult is one agent's entry in the output of proof verify. Report is
+// nil if the agent is not in the trust store.
+type ChainResult struct {
+	AgentID string
+	OK      bool
+	Report  *proof.ChainReport
+}
+
+// IssuedCert is the output of the pki commands.
+type IssuedCert struct {
+	Subject  string
+	CertFile string
+	KeyFile  string
+	NotAfter time.Time
+}
+
+// Scaffold is the output of plugin new.
+type Scaffold struct {
+	Plugin string
+	Dir    string
+	Files  []string
+}
+
+// DoctorCheck is one line of the output of doctor. Hint says how to fix a
+// check that did not pass.
+type DoctorCheck struct {
+	Check  string
+	Status string // pass, warn, fail or skip
+	Detail string
+	Hint   string `json:",omitempty"`
+}
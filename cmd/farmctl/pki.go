@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/farmops/farmops/pkg/pki"
+)
+
+const (
+	caValidity     = 10 * 365 * 24 * time.Hour
+	serverValidity = 825 * 24 * time.Hour
+	agentValidity  = 365 * 24 * time.Hour
+)
+
+// cmdPKI mints a local CA and the certificates signed by it.
+func cmdPKI(args []string) {
+	if len(args) == 0 {
+		fatalf("usage: farmctl pki init|server|agent [flags]\n")
+	}
+	fs := flag.NewFlagSet("pki "+args[0], flag.ExitOnError)
+	dir := fs.String("dir", "pki", "directory holding the CA and issued certificates")
+	validFor := fs.Duration("valid-for", 0, "certificate lifetime (default: 10y CA, 825d server, 365d agent)")
+	_ = fs.Parse(args[1:])
+
+	caCert, caKey := filepath.Join(*dir, "ca.crt"), filepath.Join(*dir, "ca.key")
+	lifetime := func(def time.Duration) time.Duration {
+		if *validFor > 0 {
+			return *validFor
+		}
+		return def
+	}
+
+	switch args[0] {
+	case "init":
+		if _, err := os.Stat(caKey); err == nil {
+			fatalf("%s already exists; refusing to overwrite the CA\n", caKey)
+		}
+		if err := os.MkdirAll(*dir, 0700); err != nil {
+			fatalf("create %s: %v\n", *dir, err)
+		}
+		ca, err := pki.NewCA("FarmOps local CA", lifetime(caValidity))
+		if err != nil {
+			fatalf("%v\n", err)
+		}
+		writePair(ca, caCert, caKey)
+		if !out.structured() {
+			fmt.Println("Give ca.crt to agents (tls.ca_file) and to the tracker (tls.client_ca_file).")
+			fmt.Println("Keep ca.key offline; it can mint certificates for any agent.")
+		}
+
+	case "server":
+		if fs.NArg() == 0 {
+			fatalf("usage: farmctl pki server [-dir pki] <host-or-ip>...\n")
+		}
+		ca := loadCA(caCert, caKey)
+		cert, err := ca.IssueServer(fs.Args(), lifetime(serverValidity))
+		if err != nil {
+			fatalf("%v\n", err)
+		}
+		writePair(cert, filepath.Join(*dir, "tracker.crt"), filepath.Join(*dir, "tracker.key"))
+		if !out.structured() {
+			fmt.Println("Set tls.cert_file and tls.key_file in tracker.yaml to these files.")
+		}
+
+	case "agent":
+		if fs.NArg() != 1 {
+			fatalf("usage: farmctl pki agent [-dir pki] <agent-id>\n")
+		}
+		agentID := fs.Arg(0)
+		ca := loadCA(caCert, caKey)
+		cert, err := ca.IssueAgent(agentID, lifetime(agentValidity))
+		if err != nil {
+			fatalf("%v\n", err)
+		}
+		writePair(cert, filepath.Join(*dir, "agent-"+agentID+".crt"), filepath.Join(*dir, "agent-"+agentID+".key"))
+		if !out.structured() {
+			fmt.Println("Set tls.cert_file and tls.key_file in the agent config to these files.")
+		}
+
+	default:
+		fatalf("unknown pki command: %s (want init, server or agent)\n", args[0])
+	}
+}
+
+func loadCA(certFile, keyFile string) *pki.Certificate {
+	ca, err := pki.Load(certFile, keyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		fatalf("no CA in %s; run farmctl pki init first\n", filepath.Dir(certFile))
+	}
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	return ca
+}
+
+func writePair(cert *pki.Certificate, certFile, keyFile string) {
+	if err := cert.WriteFiles(certFile, keyFile); err != nil {
+		fatalf("%v\n", err)
+	}
+	if out.structured() {
+		out.print(IssuedCert{
+			Subject: cert.Cert.Subject.CommonName, CertFile: certFile, KeyFile: keyFile, NotAfter: cert.Cert.NotAfter,
+		})
+		return
+	}
+	fmt.Printf("Wrote %s (valid until %s)\n      %s\n", certFile, cert.Cert.NotAfter.Format(time.DateOnly), keyFile)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/farmops/farmops/pkg/proof"
+	pluginv1 "github.com/farmops/farmops/proto/plugin/v1"
+)
+
+// pluginTemplates are the files of a new plugin project. Each is named after
+// the file it generates plus .tmpl, so that go.mod does not cut plugintmpl out
+// of this module.
+//
+//go:embed plugintmpl
+var pluginTemplates embed.FS
+
+// pluginIDPattern matches plugin IDs such as farmops/k8s-pod-health.
+var pluginIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*/[a-z0-9][a-z0-9-]*$`)
+
+var (
+	pluginCategories = []string{
+		proof.CategoryMaintenance, proof.CategoryToil, proof.CategoryReliability,
+		proof.CategorySecurity, proof.CategoryIncident, proof.CategoryUpgrade,
+	}
+	pluginSourceTypes = []string{"kubernetes", "terraform", "prometheus", "git", "ci", "custom"}
+)
+
+// pluginScaffold is what the plugin templates are executed with.
+type pluginScaffold struct {
+	ID           string
+	Name         string // last element of ID: binary, image and socket name
+	Module       string
+	Version      string
+	Description  string
+	Categories   []string
+	SourceType   string
+	Resources    []string
+	PollInterval int // seconds; 0 watches Kubernetes resources instead of polling
+	Image        string
+	Address      string
+}
+
+// cmdPlugin dispatches the plugin subcommands.
+func cmdPlugin(args []string) {
+	if len(args) == 0 || args[0] != "new" {
+		fatalf("usage: farmctl plugin new [flags] <owner/name>\n")
+	}
+	cmdPluginNew(args[1:])
+}
+
+// cmdPluginNew generates a Go plugin project implementing plugin.v1.
+func cmdPluginNew(args []string) {
+	fs := flag.NewFlagSet("plugin new", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory to create (default: the plugin's name)")
+	module := fs.String("module", "", "Go module path (default: example.com/<owner/name>)")
+	version := fs.String("version", "0.1.0", "plugin version")
+	description := fs.String("description", "", "what the plugin verifies")
+	categories := fs.String("categories", proof.CategoryMaintenance, "comma-separated action categories the plugin handles")
+	source := fs.String("source", "custom", "source type: "+strings.Join(pluginSourceTypes, ", "))
+	resources := fs.String("resources", "", "comma-separated resources to watch, e.g. pods,deployments")
+	image := fs.String("image", "", "container image (default: ghcr.io/<owner/name>:<version>)")
+	address := fs.String("address", "", "address the plugin serves on (default: unix:///tmp/farmops-<name>.sock)")
+	force := fs.Bool("force", false, "write into an existing directory, overwriting files")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fatalf("usage: farmctl plugin new [-dir d] [-module m] [-version v] [-description d] [-categories c,...]\n" +
+			"        [-source type] [-resources r,...] [-image i] [-address a] [-force] <owner/name>\n")
+	}
+
+	id := fs.Arg(0)
+	if !pluginIDPattern.MatchString(id) {
+		fatalf("invalid plugin ID %q: want owner/name in lowercase letters, digits and dashes\n", id)
+	}
+	_, name, _ := strings.Cut(id, "/")
+	s := pluginScaffold{
+		ID:          id,
+		Name:        name,
+		Module:      *module,
+		Version:     *version,
+		Description: *description,
+		Categories:  splitList(*categories),
+		SourceType:  *source,
+		Resources:   splitList(*resources),
+		Image:       *image,
+		Address:     *address,
+	}
+	if s.Module == "" {
+		s.Module = "example.com/" + id
+	}
+	if s.Description == "" {
+		s.Description = "Verifies " + strings.Join(s.Categories, " and ") + " work."
+	}
+	if len(s.Categories) == 0 {
+		fatalf("-categories: at least one category is required\n")
+	}
+	for _, c := range s.Categories {
+		if !slices.Contains(pluginCategories, c) {
+			fatalf("-categories: unknown category %q (want %s)\n", c, strings.Join(pluginCategories, ", "))
+		}
+	}
+	if !slices.Contains(pluginSourceTypes, s.SourceType) {
+		fatalf("-source: unknown source type %q (want %s)\n", s.SourceType, strings.Join(pluginSourceTypes, ", "))
+	}
+	if s.SourceType != "kubernetes" {
+		s.PollInterval = 300
+	}
+	if s.Image == "" {
+		s.Image = "ghcr.io/" + id + ":" + s.Version
+	}
+	if s.Address == "" {
+		s.Address = "unix:///tmp/farmops-" + name + ".sock"
+	}
+	if *dir == "" {
+		*dir = name
+	}
+
+	if entries, err := os.ReadDir(*dir); err == nil && len(entries) > 0 && !*force {
+		fatalf("%s is not empty; use -force to write into it\n", *dir)
+	}
+	files, err := writeScaffold(*dir, s)
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+
+	if out.structured() {
+		out.print(Scaffold{Plugin: id, Dir: *dir, Files: files})
+		return
+	}
+	fmt.Printf("Created plugin %s in %s:\n", id, *dir)
+	for _, f := range files {
+		fmt.Printf("  %s\n", f)
+	}
+	fmt.Printf("\nNext: cd %s && make proto test build\n", *dir)
+	fmt.Println("Load it in the agent with agent-config.yaml:")
+	snippet, _ := os.ReadFile(filepath.Join(*dir, "agent-config.yaml"))
+	fmt.Printf("\n%s", snippet)
+}
+
+// writeScaffold executes the plugin templates into dir and returns the files
+// written, relative to dir. Go files are gofmt'ed.
+func writeScaffold(dir string, s pluginScaffold) ([]string, error) {
+	funcs := template.FuncMap{
+		"join":      strings.Join,
+		"goStrings": goStrings,
+	}
+	tmpls, err := template.New("").Funcs(funcs).ParseFS(pluginTemplates, "plugintmpl/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	write := func(name string, data []byte) error {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			return err
+		}
+		files = append(files, name)
+		return nil
+	}
+
+	entries, err := pluginTemplates.ReadDir("plugintmpl")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		tmpl := e.Name()
+		var b bytes.Buffer
+		if err := tmpls.ExecuteTemplate(&b, tmpl, s); err != nil {
+			return nil, fmt.Errorf("plugin template %s: %w", tmpl, err)
+		}
+		name := strings.TrimSuffix(tmpl, ".tmpl")
+		if name == "dockerignore" {
+			name = ".dockerignore"
+		}
+		data := b.Bytes()
+		if strings.HasSuffix(name, ".go") {
+			if data, err = format.Source(data); err != nil {
+				return nil, fmt.Errorf("plugin template %s: %w", tmpl, err)
+			}
+		}
+		if err := write(name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	// The project generates its bindings from its own copy of the contract,
+	// so it builds without farmops' generated code.
+	if err := write("proto/plugin/v1/plugin.proto", pluginv1.Proto); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// goStrings renders a []string literal for the Go templates.
+func goStrings(ss []string) string {
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWriteScaffold(t *testing.T) {
+	wantFiles := []string{
+		".dockerignore", "Dockerfile", "Makefile", "README.md", "agent-config.yaml",
+		"farmops-plugins.yaml", "go.mod", "main.go", "plugin.go", "plugin_test.go",
+		"proto/plugin/v1/plugin.proto",
+	}
+
+	// Every non-empty set of categories, for every source type.
+	for _, source := range pluginSourceTypes {
+		for mask := 1; mask < 1<<len(pluginCategories); mask++ {
+			var categories []string
+			for i, c := range pluginCategories {
+				if mask&(1<<i) != 0 {
+					categories = append(categories, c)
+				}
+			}
+			name := source + "/" + strings.Join(categories, ",")
+			t.Run(name, func(t *testing.T) {
+				s := pluginScaffold{
+					ID:          "farmops/test-plugin",
+					Name:        "test-plugin",
+					Module:      "example.com/farmops/test-plugin",
+					Version:     "0.1.0",
+					Description: "Verifies " + strings.Join(categories, " and ") + " work.",
+					Categories:  categories,
+					SourceType:  source,
+					Image:       "ghcr.io/farmops/test-plugin:0.1.0",
+					Address:     "unix:///tmp/farmops-test-plugin.sock",
+				}
+				if source == "kubernetes" {
+					s.Resources = []string{"pods", "deployments"}
+				} else {
+					s.PollInterval = 300
+				}
+
+				dir := t.TempDir()
+				files, err := writeScaffold(dir, s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				slices.Sort(files)
+				if !slices.Equal(files, wantFiles) {
+					t.Fatalf("wrote %v, want %v", files, wantFiles)
+				}
+				for _, f := range files {
+					data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f)))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(data) == 0 {
+						t.Errorf("%s is empty", f)
+					}
+					if !strings.HasSuffix(f, ".go") {
+						continue
+					}
+					formatted, err := format.Source(data)
+					if err != nil {
+						t.Errorf("%s does not parse: %v", f, err)
+					} else if !bytes.Equal(formatted, data) {
+						t.Errorf("%s is not gofmt'ed", f)
+					}
+				}
+
+				plugin, _ := os.ReadFile(filepath.Join(dir, "plugin.go"))
+				for _, want := range []string{
+					"Categories:  " + goStrings(categories),
+					fmt.Sprintf("SourceType: %q", source),
+					fmt.Sprintf("Category:    %q", categories[0]),
+				} {
+					if !bytes.Contains(plugin, []byte(want)) {
+						t.Errorf("plugin.go does not contain %s", want)
+					}
+				}
+			})
+		}
+	}
+}
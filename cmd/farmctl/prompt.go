@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+// defaultPromptFormat renders as 🌾 1,250c 🔥14d, with ⌛ once the cached
+// snapshot is stale.
+const defaultPromptFormat = `🌾 {{coins .Balance}}c 🔥{{.Streak}}d{{if .Stale}} ⌛{{end}}`
+
+// refreshLockAge is how long a refresh lock holds off further refreshes, in
+// case the refresher died without removing it.
+const refreshLockAge = time.Minute
+
+// PromptSnapshot is the farm status farmctl prompt prints from, as cached by
+// farmctl prompt refresh. A failed refresh keeps the last status and records
+// the error, leaving FetchedAt to age.
+type PromptSnapshot struct {
+	Tracker   string
+	FetchedAt time.Time
+	Status    *transport.FarmStatus
+	Error     string `json:",omitempty"`
+}
+
+// promptData is what -format templates see.
+type promptData struct {
+	Farm    string
+	Balance int
+	Earned  int
+	Streak  int
+	Kept    bool // a proof was rewarded today (UTC), so the streak is safe
+	Age     time.Duration
+	Stale   bool
+	Error   string
+}
+
+// cmdPrompt prints a compact farm status for shell prompts and status bars
+// from the cached snapshot, without touching the network. When the snapshot
+// is older than -refresh it starts farmctl prompt refresh in the background
+// for the next prompt. globalArgs are farmctl's flags before the command, so
+// the refresher reaches the same tracker.
+func cmdPrompt(trackerURL string, globalArgs, args []string) {
+	fs := flag.NewFlagSet("prompt", flag.ExitOnError)
+	format := fs.String("format", defaultPromptFormat, "Go template over Farm, Balance, Earned, Streak, Kept, Age, Stale and Error")
+	stale := fs.Duration("stale", 10*time.Minute, "snapshot age from which it is shown as stale")
+	refresh := fs.Duration("refresh", time.Minute, "snapshot age from which a background refresh starts (0 to never start one)")
+	_ = fs.Parse(args)
+
+	tmpl, err := template.New("prompt").Funcs(template.FuncMap{"coins": thousands}).Parse(*format)
+	if err != nil {
+		fatalf("invalid -format: %v\n", err)
+	}
+	path, err := promptCachePath(trackerURL)
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+
+	snap, err := readPromptSnapshot(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fatalf("%v\n", err)
+	}
+	now := time.Now()
+	if *refresh > 0 && (snap == nil || now.Sub(snap.FetchedAt) >= *refresh) {
+		startPromptRefresh(path, globalArgs, now)
+	}
+	if snap == nil || snap.Status == nil {
+		// Nothing to show until the first refresh lands.
+		return
+	}
+	if out.structured() {
+		out.print(snap)
+		return
+	}
+	line, err := renderPrompt(tmpl, snap, now, *stale)
+	if err != nil {
+		fatalf("-format: %v\n", err)
+	}
+	fmt.Println(line)
+}
+
+// renderPrompt executes a -format template over snap as it stands at now,
+// showing it as stale from the given age. snap must have a status.
+func renderPrompt(tmpl *template.Template, snap *PromptSnapshot, now time.Time, stale time.Duration) (string, error) {
+	st := snap.Status
+	age := now.Sub(snap.FetchedAt)
+	data := promptData{
+		Farm:    st.Name,
+		Balance: st.CurrentCoins,
+		Earned:  st.TotalCoins,
+		Streak:  st.StreakDays,
+		Kept:    st.LastActiveAt != nil && utcDay(*st.LastActiveAt) == utcDay(now),
+		Age:     age.Round(time.Second),
+		Stale:   age >= stale,
+		Error:   snap.Error,
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// cmdPromptRefresh fetches the farm status into the prompt cache, once or,
+// with -every, until interrupted.
+func cmdPromptRefresh(ctx context.Context, client *transport.TrackerClient, trackerURL string, args []string) {
+	fs := flag.NewFlagSet("prompt refresh", flag.ExitOnError)
+	every := fs.Duration("every", 0, "keep refreshing at this interval, e.g. from a tmux or systemd unit")
+	_ = fs.Parse(args)
+
+	path, err := promptCachePath(trackerURL)
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	if *every == 0 {
+		if err := refreshPromptSnapshot(ctx, client, trackerURL, path, time.Now()); err != nil {
+			// The lock stays until it ages out, so that prompts back off
+			// from a tracker that is down.
+			fatalf("prompt refresh: %v\n", err)
+		}
+		os.Remove(path + ".lock")
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	for {
+		if err := refreshPromptSnapshot(ctx, client, trackerURL, path, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "prompt refresh: %v\n", err)
+		}
+		time.Sleep(*every)
+	}
+}
+
+// refreshPromptSnapshot fetches the farm status and writes it to the cache as
+// fetched at now. On failure the previous status is kept with the error noted.
+func refreshPromptSnapshot(ctx context.Context, client *transport.TrackerClient, trackerURL, path string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	st, fetchErr := client.Status(ctx)
+	snap := &PromptSnapshot{Tracker: trackerURL, FetchedAt: now.UTC(), Status: st}
+	if fetchErr != nil {
+		old, err := readPromptSnapshot(path)
+		if err != nil {
+			old = &PromptSnapshot{Tracker: trackerURL}
+		}
+		snap = old
+		snap.Error = fetchErr.Error()
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return fetchErr
+}
+
+func readPromptSnapshot(path string) (*PromptSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snap PromptSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &snap, nil
+}
+
+// startPromptRefresh runs farmctl prompt refresh in the background unless a
+// refresh is already under way, and does not wait for it.
+func startPromptRefresh(path string, globalArgs []string, now time.Time) {
+	lock := path + ".lock"
+	if !claimRefreshLock(lock, now) {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		os.Remove(lock)
+		return
+	}
+	cmd := exec.Command(exe, append(append([]string{}, globalArgs...), "prompt", "refresh")...)
+	if err := cmd.Start(); err != nil {
+		os.Remove(lock)
+		return
+	}
+	_ = cmd.Process.Release()
+}
+
+// claimRefreshLock creates the refresh lock, replacing one older than
+// refreshLockAge at now. It reports false while another refresh holds it.
+func claimRefreshLock(lock string, now time.Time) bool {
+	if info, err := os.Stat(lock); err == nil && now.Sub(info.ModTime()) < refreshLockAge {
+		return false
+	}
+	_ = os.Remove(lock)
+	if err := os.MkdirAll(filepath.Dir(lock), 0700); err != nil {
+		return false
+	}
+	f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return false // another prompt got there first
+	}
+	f.Close()
+	return true
+}
+
+// promptCachePath returns the prompt cache file for a tracker: one file per
+// tracker URL under the user's cache directory, $FARMOPS_CACHE_DIR if set.
+func promptCachePath(trackerURL string) (string, error) {
+	dir := os.Getenv("FARMOPS_CACHE_DIR")
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(cache, "farmops")
+	}
+	sum := sha256.Sum256([]byte(trackerURL))
+	return filepath.Join(dir, "prompt-"+hex.EncodeToString(sum[:6])+".json"), nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+func TestPromptCachePath(t *testing.T) {
+	t.Setenv("FARMOPS_CACHE_DIR", "")
+	t.Setenv("XDG_CACHE_HOME", "/xdg")
+	path, err := promptCachePath("https://tracker:8443")
+	if err != nil || filepath.Dir(path) != filepath.Join("/xdg", "farmops") {
+		t.Errorf("path with XDG_CACHE_HOME = %s, %v", path, err)
+	}
+
+	t.Setenv("FARMOPS_CACHE_DIR", "/cache")
+	prod, _ := promptCachePath("https://tracker:8443")
+	again, _ := promptCachePath("https://tracker:8443")
+	dev, _ := promptCachePath("http://localhost:8443")
+	if filepath.Dir(prod) != "/cache" {
+		t.Errorf("path with FARMOPS_CACHE_DIR = %s", prod)
+	}
+	if prod != again || prod == dev {
+		t.Errorf("paths %s, %s and %s: want one per tracker URL", prod, again, dev)
+	}
+}
+
+func TestRenderPrompt(t *testing.T) {
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	today := now.Add(-3 * time.Hour)
+	yesterday := now.Add(-24 * time.Hour)
+	status := func(lastActive *time.Time) *transport.FarmStatus {
+		return &transport.FarmStatus{Farm: transport.Farm{
+			Name: "ops", TotalCoins: 4200, CurrentCoins: 1250, StreakDays: 14, LastActiveAt: lastActive,
+		}}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		format string
+		snap   PromptSnapshot
+		want   string
+	}{
+		{
+			name:   "fresh",
+			format: defaultPromptFormat,
+			snap:   PromptSnapshot{FetchedAt: now.Add(-time.Minute), Status: status(&today)},
+			want:   "🌾 1,250c 🔥14d",
+		},
+		{
+			name:   "stale",
+			format: defaultPromptFormat,
+			snap:   PromptSnapshot{FetchedAt: now.Add(-10 * time.Minute), Status: status(&today)},
+			want:   "🌾 1,250c 🔥14d ⌛",
+		},
+		{
+			name:   "kept today",
+			format: `{{.Farm}} {{.Earned}} {{.Kept}}`,
+			snap:   PromptSnapshot{FetchedAt: now, Status: status(&today)},
+			want:   "ops 4200 true",
+		},
+		{
+			name:   "not kept today",
+			format: `{{.Kept}}`,
+			snap:   PromptSnapshot{FetchedAt: now, Status: status(&yesterday)},
+			want:   "false",
+		},
+		{
+			name:   "never active",
+			format: `{{.Kept}}`,
+			snap:   PromptSnapshot{FetchedAt: now, Status: status(nil)},
+			want:   "false",
+		},
+		{
+			name:   "age and error",
+			format: `{{.Age}} {{.Stale}} {{.Error}}`,
+			snap:   PromptSnapshot{FetchedAt: now.Add(-90*time.Second - 400*time.Millisecond), Status: status(nil), Error: "tracker down"},
+			want:   "1m30s false tracker down",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl := template.Must(template.New("prompt").Funcs(template.FuncMap{"coins": thousands}).Parse(tc.format))
+			got, err := renderPrompt(tmpl, &tc.snap, now, 10*time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRefreshPromptSnapshot(t *testing.T) {
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/farm":
+			json.NewEncoder(w).Encode(transport.Farm{Name: "ops", CurrentCoins: 1250, StreakDays: 14})
+		case "/api/v1/farm/stats":
+			json.NewEncoder(w).Encode(map[string]any{"categories": map[string]any{}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	client := transport.NewTrackerClient(srv.URL, "")
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "farmops", "prompt.json")
+	fetched := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	// A tracker that is down before the first refresh leaves nothing to show.
+	down.Store(true)
+	if err := refreshPromptSnapshot(ctx, client, srv.URL, path, fetched); err == nil {
+		t.Fatal("refresh from a tracker that is down: no error")
+	}
+	snap, err := readPromptSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Status != nil || snap.Error == "" || !snap.FetchedAt.IsZero() {
+		t.Errorf("snapshot after a failed first refresh = %+v", snap)
+	}
+
+	down.Store(false)
+	if err := refreshPromptSnapshot(ctx, client, srv.URL, path, fetched); err != nil {
+		t.Fatal(err)
+	}
+	snap, err = readPromptSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Status == nil || snap.Status.CurrentCoins != 1250 || !snap.FetchedAt.Equal(fetched) || snap.Error != "" {
+		t.Errorf("snapshot after a refresh = %+v", snap)
+	}
+
+	// A failed refresh keeps the last status, and its age, with the error.
+	down.Store(true)
+	if err := refreshPromptSnapshot(ctx, client, srv.URL, path, fetched.Add(time.Hour)); err == nil {
+		t.Fatal("refresh from a tracker that is down: no error")
+	}
+	snap, err = readPromptSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Status == nil || snap.Status.CurrentCoins != 1250 || !snap.FetchedAt.Equal(fetched) {
+		t.Errorf("snapshot after a failed refresh = %+v, want the last status", snap)
+	}
+	if !strings.Contains(snap.Error, "503") {
+		t.Errorf("snapshot error %q, want the tracker's", snap.Error)
+	}
+
+	tmpl := template.Must(template.New("prompt").Funcs(template.FuncMap{"coins": thousands}).Parse(defaultPromptFormat))
+	if line, _ := renderPrompt(tmpl, snap, fetched.Add(time.Hour), 10*time.Minute); line != "🌾 1,250c 🔥14d ⌛" {
+		t.Errorf("prompt from the kept status = %q, want it shown as stale", line)
+	}
+}
+
+func TestClaimRefreshLock(t *testing.T) {
+	lock := filepath.Join(t.TempDir(), "farmops", "prompt.json.lock")
+	now := time.Now()
+	if !claimRefreshLock(lock, now) {
+		t.Fatal("first claim failed")
+	}
+	if claimRefreshLock(lock, now) {
+		t.Error("second claim succeeded while the lock is held")
+	}
+	if claimRefreshLock(lock, now.Add(refreshLockAge-time.Second)) {
+		t.Error("claim succeeded before the lock aged out")
+	}
+	if !claimRefreshLock(lock, now.Add(refreshLockAge+time.Second)) {
+		t.Error("claim failed after the lock aged out")
+	}
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/farmops/farmops/pkg/bundle"
+	"github.com/farmops/farmops/pkg/evidence"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+// cmdProofDisclose exports the evidence and salt behind one proof from an
+// agent's evidence vault, for an auditor to check against the proof's
+// evidence_hash. With -check, the proof is fetched from the tracker and the
+// disclosure is checked against it first.
+func cmdProofDisclose(ctx context.Context, client *transport.TrackerClient, args []string) {
+	fs := flag.NewFlagSet("proof disclose", flag.ExitOnError)
+	vaultDir := fs.String("vault-dir", "/var/lib/farmops-agent/evidence", "the agent's evidence vault (evidence.vault_dir)")
+	keyFile := fs.String("key-file", "/var/lib/farmops-agent/evidence.key", "the vault key (evidence.key_file)")
+	outFile := fs.String("out", "", "write the disclosure to this file instead of stdout")
+	check := fs.Bool("check", false, "check the disclosure against the proof held by the tracker")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fatalf("usage: farmctl proof disclose [-vault-dir d] [-key-file f] [-out file] [-check] <proof-id>\n")
+	}
+	proofID := fs.Arg(0)
+
+	key, err := evidence.LoadKey(*keyFile, false)
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	vault, err := evidence.Open(*vaultDir, key)
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	rec, err := vault.Get(proofID)
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	if err := rec.Verify(rec.EvidenceHash); err != nil {
+		fatalf("vault record is corrupt: %v\n", err)
+	}
+	if *check {
+		p, err := client.GetProof(ctx, proofID)
+		if err != nil {
+			fatalf("get proof: %v\n", err)
+		}
+		if err := rec.Verify(p.Outcome.EvidenceHash); err != nil {
+			fatalf("%v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Evidence matches the evidence_hash of proof %s on the tracker.\n", proofID)
+	}
+
+	if *outFile == "" && out.structured() {
+		out.print(rec)
+		return
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		fatalf("marshal disclosure: %v\n", err)
+	}
+	data = append(data, '\n')
+	if *outFile == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*outFile, data, 0600); err != nil {
+		fatalf("write %s: %v\n", *outFile, err)
+	}
+	fmt.Fprintf(os.Stderr, "Disclosure of proof %s written to %s.\n", proofID, *outFile)
+}
+
+// cmdProofVerify checks the signatures and linkage of agents' proof chains
+// offline, against keys the user supplies rather than the tracker's word.
+// Proofs come from a file or, with -agent alone, are pulled from the tracker.
+// It exits non-zero if any chain has an issue.
+func cmdProofVerify(ctx context.Context, client *transport.TrackerClient, args []string) {
+	fs := flag.NewFlagSet("proof verify", flag.ExitOnError)
+	file := fs.String("file", "", "JSONL or JSON array of proofs, or a bundle, to verify (- for stdin)")
+	agentID := fs.String("agent", "", "verify only this agent; without -file, fetch its proofs from the tracker")
+	key := fs.String("key", "", "the agent's enrollment public key, or a file holding it")
+	trust := fs.String("trust", "", "trust store export: agents as returned by GET /api/v1/agents")
+	signerKey := fs.String("signer-key", "", "for a bundle: public key of the tracker that exported it, to check against the bundle's own trust store")
+	_ = fs.Parse(args)
+	if *file == "" && *agentID == "" {
+		fatalf("usage: farmctl proof verify [-file proofs.jsonl | bundle] [-agent id] -key <public-key | key-file> | -trust agents.json | -signer-key key\n")
+	}
+
+	var proofs []*proof.FarmProof
+	var b *bundle.Bundle
+	var err error
+	if *file != "" {
+		proofs, b, err = readProofsFile(*file)
+	} else {
+		proofs, err = fetchChain(ctx, client, *agentID)
+	}
+	if err != nil {
+		fatalf("%v\n", err)
+	}
+	if *signerKey != "" && b == nil {
+		fatalf("-signer-key only applies to bundles\n")
+	}
+	if *key == "" && *trust == "" {
+		// A bundle's trust store is only as good as its signer, and anyone
+		// can sign a bundle.
+		switch {
+		case b == nil:
+			fatalf("pass the agent's key with -key or a trust store with -trust\n")
+		case *signerKey == "":
+			fatalf("the bundle is signed by tracker key %s, which is not pinned; pass it with -signer-key if it is the exporting tracker's key, or check the chains against -key or -trust\n",
+				b.Manifest.SignerKey)
+		}
+	}
+
+	byAgent := make(map[string][]*proof.FarmProof)
+	for _, p := range proofs {
+		if *agentID == "" || p.Agent.AgentID == *agentID {
+			byAgent[p.Agent.AgentID] = append(byAgent[p.Agent.AgentID], p)
+		}
+	}
+	if len(byAgent) == 0 {
+		fatalf("no proofs to verify\n")
+	}
+	if *key != "" && *trust == "" && len(byAgent) > 1 {
+		fatalf("proofs from %d agents: pick one with -agent, or pass -trust\n", len(byAgent))
+	}
+
+	keys := make(map[string][]ed25519.PublicKey)
+	switch {
+	case *key == "" && *trust == "":
+		pub, err := proof.DecodePublicKey(*signerKey)
+		if err != nil {
+			fatalf("invalid -signer-key: %v\n", err)
+		}
+		if err := b.CheckSigner(pub); err != nil {
+			fatalf("%v\n", err)
+		}
+		if keys, err = bundleKeys(b); err != nil {
+			fatalf("%v\n", err)
+		}
+	case *trust != "":
+		if keys, err = readTrustStore(*trust); err != nil {
+			fatalf("%v\n", err)
+		}
+	default:
+		if data, err := os.ReadFile(*key); err == nil {
+			*key = string(data)
+		}
+		pub, err := proof.DecodePublicKey(*key)
+		if err != nil {
+			fatalf("invalid public key: %v\n", err)
+		}
+		for id := range byAgent {
+			keys[id] = []ed25519.PublicKey{pub}
+		}
+	}
+
+	agents := make([]string, 0, len(byAgent))
+	for id := range byAgent {
+		agents = append(agents, id)
+	}
+	sort.Strings(agents)
+	results := make([]ChainResult, 0, len(agents))
+	failed := false
+	for _, id := range agents {
+		res := ChainResult{AgentID: id}
+		if agentKeys := keys[id]; len(agentKeys) > 0 {
+			res.Report = proof.CheckChain(byAgent[id], agentKeys[0], agentKeys[1:]...)
+			res.OK = res.Report.OK()
+		}
+		failed = failed || !res.OK
+		results = append(results, res)
+	}
+	if out.structured() {
+		out.print(results)
+	} else {
+		printChainResults(results)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func printChainResults(results []ChainResult) {
+	for i, res := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Agent:    %s\n", res.AgentID)
+		r := res.Report
+		if r == nil {
+			fmt.Println("Result:   FAIL (agent is not in the trust store)")
+			continue
+		}
+		fmt.Printf("Proofs:   %d (%d verified)\n", r.Proofs, r.Verified)
+		if r.StartsAfter != "" {
+			fmt.Printf("Start:    %s (partial chain, after %s)\n", r.Start, r.StartsAfter)
+		} else {
+			fmt.Printf("Start:    %s (genesis)\n", r.Start)
+		}
+		fmt.Printf("Head:     %s\n", r.Head)
+		if r.OK() {
+			fmt.Println("Result:   OK")
+			continue
+		}
+		fmt.Printf("Result:   FAIL, first broken link: %s\n\n", r.Issues[0])
+		rows := make([][]string, 0, len(r.Issues))
+		for _, issue := range r.Issues {
+			rows = append(rows, []string{issue.Kind, issue.ProofID, issue.Detail})
+		}
+		printTable([]string{"ISSUE", "PROOF", "DETAIL"}, rows)
+	}
+}
+
+// readProofsFile reads proofs from a JSONL file, a JSON array or a bundle.
+// Stored proofs as returned by the tracker's API are accepted as well. For a
+// bundle, it also returns the bundle, whose signer is not yet pinned.
+func readProofsFile(path string) ([]*proof.FarmProof, *bundle.Bundle, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && bundle.IsBundle(magic) {
+		return readBundleProofs(br)
+	}
+	if first, err := peekNonSpace(br); err == nil && first == '[' {
+		var proofs []*proof.FarmProof
+		if err := json.NewDecoder(br).Decode(&proofs); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return proofs, nil, nil
+	}
+
+	var proofs []*proof.FarmProof
+	dec := json.NewDecoder(br)
+	for {
+		var p proof.FarmProof
+		err := dec.Decode(&p)
+		if errors.Is(err, io.EOF) {
+			return proofs, nil, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: proof %d: %w", path, len(proofs)+1, err)
+		}
+		proofs = append(proofs, &p)
+	}
+}
+
+func readBundleProofs(r io.Reader) ([]*proof.FarmProof, *bundle.Bundle, error) {
+	b, err := bundle.Read(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var proofs []*proof.FarmProof
+	for _, chain := range b.Proofs {
+		for _, sp := range chain {
+			proofs = append(proofs, sp.FarmProof)
+		}
+	}
+	return proofs, b, nil
+}
+
+// bundleKeys returns the agents' keys from a bundle's trust store,
+// enrollment key first.
+func bundleKeys(b *bundle.Bundle) (map[string][]ed25519.PublicKey, error) {
+	keys := make(map[string][]ed25519.PublicKey, len(b.Agents))
+	for _, a := range b.Agents {
+		encoded := make([]string, 0, len(a.KeyHistory)+1)
+		for _, k := range a.KeyHistory {
+			encoded = append(encoded, k.PublicKey)
+		}
+		var err error
+		if keys[a.AgentID], err = decodeKeys(a.AgentID, append(encoded, a.PublicKey)); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
+
+// fetchChain pulls every proof of an agent from the tracker, oldest first.
+func fetchChain(ctx context.Context, client *transport.TrackerClient, agentID string) ([]*proof.FarmProof, error) {
+	var proofs []*proof.FarmProof
+	after := ""
+	for {
+		page, err := client.ListProofs(ctx, agentID, after, 500)
+		if err != nil {
+			return nil, fmt.Errorf("list proofs: %w", err)
+		}
+		if len(page) == 0 {
+			return proofs, nil
+		}
+		for _, sp := range page {
+			proofs = append(proofs, sp.FarmProof)
+		}
+		after = page[len(page)-1].ProofID
+	}
+}
+
+// readTrustStore reads agents' keys from a trust store export, enrollment
+// key first.
+func readTrustStore(path string) (map[string][]ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var agents []transport.Agent
+	if err := json.Unmarshal(data, &agents); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	keys := make(map[string][]ed25519.PublicKey, len(agents))
+	for _, a := range agents {
+		encoded := make([]string, 0, len(a.KeyHistory)+1)
+		for _, k := range a.KeyHistory {
+			encoded = append(encoded, k.PublicKey)
+		}
+		if keys[a.AgentID], err = decodeKeys(a.AgentID, append(encoded, a.PublicKey)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return keys, nil
+}
+
+// decodeKeys decodes an agent's public keys, enrollment key first.
+func decodeKeys(agentID string, encoded []string) ([]ed25519.PublicKey, error) {
+	keys := make([]ed25519.PublicKey, 0, len(encoded))
+	for _, e := range encoded {
+		pub, err := proof.DecodePublicKey(e)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: %w", agentID, err)
+		}
+		keys = append(keys, pub)
+	}
+	return keys, nil
+}
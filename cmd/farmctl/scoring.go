@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/scoring"
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+// Scoring config sources for scoring simulate -config, besides a file.
+const (
+	scoringDefault = "default"
+	scoringLive    = "live"
+)
+
+// Scenario is a hypothetical proof to score: what scoring.Compute reads from
+// a proof and the farm.
+type Scenario struct {
+	Name         string
+	Category     string
+	Complexity   string
+	ImpactRadius int
+	StreakDays   int     // 0-based, as scoring.Compute takes it
+	UpgradeMult  float64 // combined multiplier of the category's buildings
+}
+
+// Simulation is one scenario scored under one scoring config, the output of
+// scoring simulate.
+type Simulation struct {
+	Scenario
+	Config string
+	Result scoring.Result
+}
+
+// stringsFlag collects a repeatable string flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string     { return strings.Join(*f, ",") }
+func (f *stringsFlag) Set(v string) error { *f = append(*f, v); return nil }
+
+// cmdScoringSimulate previews the coins a hypothetical proof would earn, or a
+// CSV of them, under one or more scoring configs.
+func cmdScoringSimulate(ctx context.Context, client *transport.TrackerClient, args []string) {
+	fs := flag.NewFlagSet("scoring simulate", flag.ExitOnError)
+	var configs stringsFlag
+	fs.Var(&configs, "config", `scoring config: "default", "live" for the tracker's, or a YAML/JSON file; repeat to compare (default "default")`)
+	category := fs.String("category", proof.CategoryMaintenance, "proof category")
+	complexity := fs.String("complexity", proof.ComplexityLow, "complexity hint: low, medium or high")
+	impact := fs.Int("impact", 1, "impact radius hint, 1-10")
+	streak := fs.Int("streak", 0, "consecutive active days before the proof (0-based)")
+	upgrade := fs.Float64("upgrade", 1.0, "combined upgrade multiplier for the category")
+	buildings := fs.String("buildings", "", "upgrades as shop items and levels, e.g. maintenance-barn:2,ci-windmill:1, instead of -upgrade")
+	proofFile := fs.String("proof", "", "take category, complexity and impact from this proof (JSON)")
+	fromFarm := fs.Bool("farm", false, "take the streak and upgrades from the tracker's farm unless given")
+	scenarios := fs.String("scenarios", "", "CSV of scenarios with a header of name, category, complexity, impact, streak, upgrade; missing columns take the flags' values")
+	_ = fs.Parse(args)
+	if len(configs) == 0 {
+		configs = stringsFlag{scoringDefault}
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["upgrade"] && set["buildings"] {
+		fatalf("scoring simulate: -upgrade and -buildings are mutually exclusive\n")
+	}
+
+	base := Scenario{
+		Category: *category, Complexity: *complexity, ImpactRadius: *impact,
+		StreakDays: *streak, UpgradeMult: *upgrade,
+	}
+	if *proofFile != "" {
+		p, err := readProofJSON(*proofFile)
+		if err != nil {
+			fatalf("%v\n", err)
+		}
+		base.Name = p.ProofID
+		base.Category, base.Complexity, base.ImpactRadius = p.Action.Category, p.ScoringHints.Complexity, p.ScoringHints.ImpactRadius
+	}
+	var world *farm.World
+	if *fromFarm {
+		var err error
+		if world, err = client.GetWorld(ctx); err != nil {
+			fatalf("get farm: %v\n", err)
+		}
+		if !set["streak"] {
+			base.StreakDays = world.StreakBonusDays(time.Now())
+		}
+	}
+	levels, err := parseBuildings(*buildings)
+	if err != nil {
+		fatalf("invalid -buildings: %v\n", err)
+	}
+	upgradeFor := func(s *Scenario) {
+		switch {
+		case levels != nil:
+			s.UpgradeMult = buildingsMultiplier(levels, s.Category)
+		case world != nil && !set["upgrade"]:
+			s.UpgradeMult = world.UpgradeMultiplier(s.Category)
+		}
+	}
+
+	var list []Scenario
+	if *scenarios != "" {
+		if list, err = readScenarios(*scenarios, base, upgradeFor); err != nil {
+			fatalf("%v\n", err)
+		}
+	} else {
+		upgradeFor(&base)
+		list = []Scenario{base}
+	}
+
+	cfgs := make([]scoring.Config, len(configs))
+	for i, name := range configs {
+		if cfgs[i], err = loadScoringConfig(ctx, client, name); err != nil {
+			fatalf("%v\n", err)
+		}
+	}
+	var sims []Simulation
+	for _, s := range list {
+		p := &proof.FarmProof{
+			Action:       proof.ActionInfo{Category: s.Category},
+			ScoringHints: proof.ScoringHints{Complexity: s.Complexity, ImpactRadius: s.ImpactRadius},
+		}
+		for i, cfg := range cfgs {
+			sims = append(sims, Simulation{Scenario: s, Config: configs[i], Result: scoring.Compute(p, cfg, s.UpgradeMult, s.StreakDays)})
+		}
+	}
+
+	switch {
+	case out.structured():
+		out.print(sims)
+	case *scenarios != "":
+		printScenarioTable(sims, configs)
+	default:
+		printBreakdown(sims)
+	}
+}
+
+// printBreakdown prints the factors of a single scenario's score, one column
+// per config.
+func printBreakdown(sims []Simulation) {
+	s := sims[0].Scenario
+	fmt.Printf("Scenario: %s, %s complexity, impact radius %d, streak day %d, upgrades ×%.2f\n\n",
+		s.Category, s.Complexity, s.ImpactRadius, s.StreakDays, s.UpgradeMult)
+	headers := []string{"FACTOR"}
+	rows := [][]string{{"base coins"}, {"× complexity"}, {"× impact"}, {"× streak"}, {"× upgrades"}, {"= coins"}}
+	for _, sim := range sims {
+		headers = append(headers, strings.ToUpper(sim.Config))
+		r := sim.Result
+		rows[0] = append(rows[0], fmt.Sprint(r.BaseCoins))
+		rows[1] = append(rows[1], fmt.Sprintf("%.2f", r.ComplexityMult))
+		rows[2] = append(rows[2], fmt.Sprintf("%.2f", r.ImpactMult))
+		rows[3] = append(rows[3], fmt.Sprintf("%.2f", r.StreakMult))
+		rows[4] = append(rows[4], fmt.Sprintf("%.2f", r.UpgradeMult))
+		rows[5] = append(rows[5], fmt.Sprint(r.TotalCoins))
+	}
+	printTable(headers, rows)
+}
+
+// printScenarioTable prints one row per scenario with its coins under each
+// config; -o wide adds the multipliers.
+func printScenarioTable(sims []Simulation, configs []string) {
+	headers := []string{"SCENARIO", "CATEGORY", "COMPLEXITY", "IMPACT", "STREAK", "UPGRADE"}
+	for _, c := range configs {
+		if out.wide() {
+			headers = append(headers, strings.ToUpper(c)+" MULTIPLIERS")
+		}
+		headers = append(headers, strings.ToUpper(c))
+	}
+	var rows [][]string
+	for i := 0; i < len(sims); i += len(configs) {
+		s := sims[i].Scenario
+		row := []string{s.Name, s.Category, s.Complexity, fmt.Sprint(s.ImpactRadius), fmt.Sprint(s.StreakDays), fmt.Sprintf("%.2f", s.UpgradeMult)}
+		for _, sim := range sims[i : i+len(configs)] {
+			r := sim.Result
+			if out.wide() {
+				row = append(row, fmt.Sprintf("%d×%.2f×%.2f×%.2f×%.2f", r.BaseCoins, r.ComplexityMult, r.ImpactMult, r.StreakMult, r.UpgradeMult))
+			}
+			row = append(row, fmt.Sprint(r.TotalCoins))
+		}
+		rows = append(rows, row)
+	}
+	printTable(headers, rows)
+}
+
+// loadScoringConfig returns the named scoring config. A file overrides the
+// defaults it sets and keeps the rest.
+func loadScoringConfig(ctx context.Context, client *transport.TrackerClient, name string) (scoring.Config, error) {
+	switch name {
+	case scoringDefault:
+		return scoring.DefaultConfig(), nil
+	case scoringLive:
+		cfg, err := client.ScoringConfig(ctx)
+		if err != nil {
+			return scoring.Config{}, fmt.Errorf("get scoring config: %w", err)
+		}
+		return *cfg, nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return scoring.Config{}, err
+	}
+	cfg := scoring.DefaultConfig()
+	// JSON is YAML, so one decoder reads both.
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return scoring.Config{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return cfg, nil
+}
+
+// readProofJSON reads a proof, or a stored proof as the tracker returns it.
+func readProofJSON(path string) (*proof.FarmProof, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p proof.FarmProof
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.Action.Category == "" {
+		return nil, fmt.Errorf("%s: not a proof: no action category", path)
+	}
+	return &p, nil
+}
+
+// parseBuildings parses -buildings: shop item slugs with their levels.
+func parseBuildings(s string) (map[string]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	levels := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		slug, level, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			level = "1"
+		}
+		if _, known := farm.LookupItem(slug); !known {
+			return nil, fmt.Errorf("unknown shop item %q", slug)
+		}
+		n, err := strconv.Atoi(level)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid level %q for %s", level, slug)
+		}
+		levels[slug] = n
+	}
+	return levels, nil
+}
+
+// buildingsMultiplier is farm.World.UpgradeMultiplier for a set of buildings.
+func buildingsMultiplier(levels map[string]int, category string) float64 {
+	w := &farm.World{}
+	for slug, level := range levels {
+		w.Buildings = append(w.Buildings, farm.Building{Slug: slug, Level: level})
+	}
+	return w.UpgradeMultiplier(category)
+}
+
+// readScenarios reads a CSV of scenarios. Columns missing from the header
+// take their values from base; upgradeFor fills in the upgrade multiplier of
+// rows without an upgrade column.
+func readScenarios(path string, base Scenario, upgradeFor func(*Scenario)) ([]Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: read header: %w", path, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(h))
+		if name == "impact_radius" {
+			name = "impact"
+		}
+		switch name {
+		case "name", "category", "complexity", "impact", "streak", "upgrade":
+			cols[name] = i
+		default:
+			return nil, fmt.Errorf("%s: unknown column %q", path, h)
+		}
+	}
+
+	var list []Scenario
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return list, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		s := base
+		s.Name = fmt.Sprint(len(list) + 1)
+		field := func(name string) (string, bool) {
+			i, ok := cols[name]
+			if !ok || rec[i] == "" {
+				return "", false
+			}
+			return rec[i], true
+		}
+		// invalid reports a bad value with the line it is on, which comment
+		// lines and quoted newlines keep from being the record's number.
+		invalid := func(name, v string) error {
+			line, _ := r.FieldPos(cols[name])
+			return fmt.Errorf("%s:%d: invalid %s %q", path, line, name, v)
+		}
+		if v, ok := field("name"); ok {
+			s.Name = v
+		}
+		if v, ok := field("category"); ok {
+			s.Category = v
+		}
+		if v, ok := field("complexity"); ok {
+			s.Complexity = v
+		}
+		if v, ok := field("impact"); ok {
+			if s.ImpactRadius, err = strconv.Atoi(v); err != nil {
+				return nil, invalid("impact", v)
+			}
+		}
+		if v, ok := field("streak"); ok {
+			if s.StreakDays, err = strconv.Atoi(v); err != nil {
+				return nil, invalid("streak", v)
+			}
+		}
+		if v, ok := field("upgrade"); ok {
+			if s.UpgradeMult, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, invalid("upgrade", v)
+			}
+		} else {
+			upgradeFor(&s)
+		}
+		list = append(list, s)
+	}
+}
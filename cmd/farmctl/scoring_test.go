@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/scoring"
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadScenarios(t *testing.T) {
+	base := Scenario{Category: proof.CategoryMaintenance, Complexity: proof.ComplexityMedium, ImpactRadius: 1}
+	upgradeFor := func(s *Scenario) { s.UpgradeMult = 1.5 }
+
+	for _, tc := range []struct {
+		name    string
+		csv     string
+		want    []Scenario
+		wantErr string
+	}{
+		{
+			name: "every column",
+			csv:  "name,category,complexity,impact,streak,upgrade\nbig,security,high,5,3,1.2\n",
+			want: []Scenario{{Name: "big", Category: "security", Complexity: "high", ImpactRadius: 5, StreakDays: 3, UpgradeMult: 1.2}},
+		},
+		{
+			name: "missing columns and empty fields take the base",
+			csv:  "category, impact_radius\nincident,\n,4\n",
+			want: []Scenario{
+				{Name: "1", Category: "incident", Complexity: "medium", ImpactRadius: 1, UpgradeMult: 1.5},
+				{Name: "2", Category: "maintenance", Complexity: "medium", ImpactRadius: 4, UpgradeMult: 1.5},
+			},
+		},
+		{
+			name: "comment lines",
+			csv:  "# scenarios\nname,streak\n# baseline\nnone,0\n\n# a week\nweek,7\n",
+			want: []Scenario{
+				{Name: "none", Category: "maintenance", Complexity: "medium", ImpactRadius: 1, UpgradeMult: 1.5},
+				{Name: "week", Category: "maintenance", Complexity: "medium", ImpactRadius: 1, StreakDays: 7, UpgradeMult: 1.5},
+			},
+		},
+		{
+			name:    "unknown column",
+			csv:     "name,colour\na,red\n",
+			wantErr: `unknown column "colour"`,
+		},
+		{
+			name:    "invalid value after comments",
+			csv:     "name,impact\n# one\n# two\na,1\nb,lots\n",
+			wantErr: `:5: invalid impact "lots"`,
+		},
+		{
+			name:    "invalid value after a quoted newline",
+			csv:     "name,streak\n\"two\nlines\",1\nb,x\n",
+			wantErr: `:4: invalid streak "x"`,
+		},
+		{
+			name:    "invalid upgrade",
+			csv:     "name,upgrade\na,double\n",
+			wantErr: `:2: invalid upgrade "double"`,
+		},
+		{
+			name:    "wrong number of fields",
+			csv:     "name,streak\na,1,2\n",
+			wantErr: "wrong number of fields",
+		},
+		{
+			name:    "empty file",
+			csv:     "",
+			wantErr: "read header",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := readScenarios(writeFile(t, "scenarios.csv", tc.csv), base, upgradeFor)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseBuildings(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    map[string]int
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "maintenance-barn", want: map[string]int{"maintenance-barn": 1}},
+		{in: "maintenance-barn:3, ci-windmill:2", want: map[string]int{"maintenance-barn": 3, "ci-windmill": 2}},
+		{in: "greenhouse:1", wantErr: true},
+		{in: "maintenance-barn:0", wantErr: true},
+		{in: "maintenance-barn:top", wantErr: true},
+	} {
+		got, err := parseBuildings(tc.in)
+		if (err != nil) != tc.wantErr || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseBuildings(%q) = %v, %v; want %v, error %v", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestLoadScoringConfig(t *testing.T) {
+	live := scoring.DefaultConfig()
+	live.StreakCap = 2
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/scoring/config" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(live)
+	}))
+	defer srv.Close()
+	client := transport.NewTrackerClient(srv.URL, "")
+
+	// A file overrides only the fields and map keys it sets.
+	overridden := scoring.DefaultConfig()
+	overridden.BaseCoins[proof.CategoryMaintenance] = 50
+	overridden.ImpactStep = 0.2
+
+	for _, tc := range []struct {
+		name    string
+		source  string
+		want    scoring.Config
+		wantErr bool
+	}{
+		{name: "default", source: scoringDefault, want: scoring.DefaultConfig()},
+		{name: "live", source: scoringLive, want: live},
+		{
+			name:   "yaml file",
+			source: writeFile(t, "scoring.yaml", "base_coins:\n  maintenance: 50\nimpact_step: 0.2\n"),
+			want:   overridden,
+		},
+		{
+			name:   "json file",
+			source: writeFile(t, "scoring.json", `{"base_coins": {"maintenance": 50}, "impact_step": 0.2}`),
+			want:   overridden,
+		},
+		{name: "missing file", source: filepath.Join(t.TempDir(), "missing.yaml"), wantErr: true},
+		{name: "invalid file", source: writeFile(t, "bad.yaml", "impact_step: [1, 2]\n"), wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := loadScoringConfig(context.Background(), client, tc.source)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
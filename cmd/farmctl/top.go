@@ -0,0 +1,501 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/term"
+
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+// feedSize is how many accepted proofs top keeps for its live feed.
+const feedSize = 50
+
+// ledgerPage is the page size top reads the coin ledger with.
+const ledgerPage = 500
+
+// ANSI sequences used by top.
+const (
+	ansiAltScreen  = "\x1b[?1049h\x1b[?25l"
+	ansiMainScreen = "\x1b[?25h\x1b[?1049l"
+	ansiHome       = "\x1b[H"
+	ansiClearLine  = "\x1b[K"
+	ansiClearBelow = "\x1b[J"
+	ansiBold       = "\x1b[1m"
+	ansiDim        = "\x1b[2m"
+	ansiRed        = "\x1b[31m"
+	ansiGreen      = "\x1b[32m"
+	ansiYellow     = "\x1b[33m"
+	ansiReset      = "\x1b[0m"
+)
+
+// cmdTop shows a live dashboard of the farm in the terminal: balance, streak,
+// coins per category today and yesterday, agent health and a feed of accepted
+// proofs. It follows the tracker's event stream and polls as a fallback.
+// When stdout is not a terminal it prints one frame, without the feed, and
+// exits.
+func cmdTop(ctx context.Context, client *transport.TrackerClient, args []string) {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	interval := fs.Duration("interval", 30*time.Second, "how often to poll the tracker besides following its event stream")
+	stale := fs.Duration("stale", 2*time.Hour, "an active agent without a proof for this long is shown as stale")
+	_ = fs.Parse(args)
+
+	m := &topModel{
+		client:    client,
+		stale:     *stale,
+		coins:     make(map[int64]map[string]int),
+		agentDays: make(map[int64]map[string]int),
+		lastProof: make(map[string]time.Time),
+		changed:   make(chan struct{}, 1),
+		refresh:   make(chan struct{}, 1),
+	}
+	if err := m.load(ctx); err != nil {
+		fatalf("top: %v\n", err)
+	}
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		os.Stdout.WriteString(m.frame(time.Now(), 0, 0, false))
+		return
+	}
+
+	// The dashboard runs until quit, not for the usual request timeout.
+	ctx, stop := signal.NotifyContext(context.WithoutCancel(ctx), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, quit := context.WithCancel(ctx)
+	defer quit()
+
+	if state, err := term.MakeRaw(int(os.Stdin.Fd())); err == nil {
+		defer term.Restore(int(os.Stdin.Fd()), state)
+		go m.readKeys(quit)
+	}
+	os.Stdout.WriteString(ansiAltScreen)
+	defer os.Stdout.WriteString(ansiMainScreen)
+
+	go m.follow(ctx)
+	go m.poll(ctx, *interval)
+
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 100, 40
+		}
+		os.Stdout.WriteString(m.frame(time.Now(), width, height, true))
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		case <-m.changed:
+		}
+	}
+}
+
+// topModel is what top shows, kept up to date from the tracker.
+type topModel struct {
+	client *transport.TrackerClient
+	stale  time.Duration
+
+	// refresh asks the poller to fetch now; changed tells the renderer
+	// something new is in the model.
+	refresh chan struct{}
+	changed chan struct{}
+
+	mu        sync.Mutex
+	status    *transport.FarmStatus
+	agents    []transport.Agent
+	lastSeq   uint64                   // last ledger event read
+	coins     map[int64]map[string]int // UTC day → category → coins earned
+	agentDays map[int64]map[string]int // UTC day → agent → proofs rewarded
+	lastProof map[string]time.Time     // agent → time of its last rewarded proof
+	feed      []feedEntry              // newest first
+	streamErr string                   // why the event stream is down, if it is
+	pollErr   string
+	updated   time.Time
+}
+
+type feedEntry struct {
+	At time.Time
+	transport.ProofAccepted
+}
+
+// load fetches the farm status, the agents and the new part of the ledger.
+func (m *topModel) load(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	st, err := m.client.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("get farm status: %w", err)
+	}
+	agents, err := m.client.ListAgents(ctx)
+	if err != nil {
+		return fmt.Errorf("list agents: %w", err)
+	}
+	m.mu.Lock()
+	after := m.lastSeq
+	m.mu.Unlock()
+	var events []*farm.Event
+	for {
+		page, err := m.client.ListLedger(ctx, after, ledgerPage)
+		if err != nil {
+			return fmt.Errorf("read ledger: %w", err)
+		}
+		events = append(events, page...)
+		if len(page) < ledgerPage {
+			break
+		}
+		after = page[len(page)-1].Seq
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status, m.agents, m.updated = st, agents, time.Now()
+	for _, e := range events {
+		m.apply(e)
+	}
+	return nil
+}
+
+// apply adds a ledger event to the per-day totals.
+func (m *topModel) apply(e *farm.Event) {
+	m.lastSeq = max(m.lastSeq, e.Seq)
+	if e.Type != farm.EventProofReward && e.Type != farm.EventRewardReversed {
+		return
+	}
+	day := utcDay(e.At)
+	if m.coins[day] == nil {
+		m.coins[day] = make(map[string]int)
+	}
+	m.coins[day][e.Category] += e.Coins
+	if e.Type == farm.EventProofReward && e.AgentID != "" {
+		if m.agentDays[day] == nil {
+			m.agentDays[day] = make(map[string]int)
+		}
+		m.agentDays[day][e.AgentID]++
+		if e.At.After(m.lastProof[e.AgentID]) {
+			m.lastProof[e.AgentID] = e.At
+		}
+	}
+	// Only today and yesterday are shown.
+	for d := range m.coins {
+		if d < day-1 {
+			delete(m.coins, d)
+			delete(m.agentDays, d)
+		}
+	}
+}
+
+// follow feeds the event stream into the model, reconnecting when it breaks.
+func (m *topModel) follow(ctx context.Context) {
+	var lastID uint64
+	for {
+		err := m.client.StreamEvents(ctx, lastID, nil, func(ev transport.Event) error {
+			lastID = ev.ID
+			m.mu.Lock()
+			m.streamErr = ""
+			if ev.Type == transport.EventProofAccepted {
+				var p transport.ProofAccepted
+				if json.Unmarshal(ev.Data, &p) == nil {
+					m.feed = append([]feedEntry{{At: proofTime(p.ProofID), ProofAccepted: p}}, m.feed...)
+					m.feed = m.feed[:min(len(m.feed), feedSize)]
+				}
+			}
+			m.mu.Unlock()
+			if ev.Type != transport.EventProofAccepted && ev.Type != transport.EventAchievementUnlocked {
+				signalChan(m.refresh)
+			}
+			signalChan(m.changed)
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		m.mu.Lock()
+		m.streamErr = err.Error()
+		m.mu.Unlock()
+		signalChan(m.changed)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+// poll reloads the model every interval and whenever an event asks for it.
+func (m *topModel) poll(ctx context.Context, interval time.Duration) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		case <-m.refresh:
+		}
+		err := m.load(ctx)
+		m.mu.Lock()
+		m.pollErr = ""
+		if err != nil {
+			m.pollErr = err.Error()
+		}
+		m.mu.Unlock()
+		signalChan(m.changed)
+	}
+}
+
+// readKeys quits on q or Ctrl-C and refreshes on r. The terminal is in raw
+// mode, so Ctrl-C arrives as a byte rather than a signal.
+func (m *topModel) readKeys(quit context.CancelFunc) {
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, b := range buf[:n] {
+			switch b {
+			case 'q', 'Q', 3:
+				quit()
+				return
+			case 'r', 'R':
+				signalChan(m.refresh)
+			}
+		}
+	}
+}
+
+// frame renders the dashboard at time now. A width or height of zero means
+// unbounded. With ansi, the frame has colors and the live feed and redraws
+// the terminal in place; without, it is plain text.
+func (m *topModel) frame(now time.Time, width, height int, ansi bool) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	style := func(code, s string) string {
+		if !ansi {
+			return s
+		}
+		return code + s + ansiReset
+	}
+
+	var lines []string
+	st := m.status
+	lines = append(lines,
+		style(ansiBold, fmt.Sprintf("🌾 %s — %s coins", st.Name, thousands(st.CurrentCoins)))+
+			fmt.Sprintf(" (%s earned)", thousands(st.TotalCoins)))
+
+	today := utcDay(now)
+	midnight := time.Unix((today+1)*86400, 0)
+	countdown := formatCountdown(midnight.Sub(now))
+	switch {
+	case st.StreakDays == 0:
+		lines = append(lines, fmt.Sprintf("Streak   none · a proof before UTC midnight starts one (%s)", countdown))
+	case st.LastActiveAt != nil && utcDay(*st.LastActiveAt) == today:
+		lines = append(lines, fmt.Sprintf("Streak   %d days %s · next day starts in %s", st.StreakDays, style(ansiGreen, "✓ kept today"), countdown))
+	default:
+		lines = append(lines, fmt.Sprintf("Streak   %d days %s · %s left before UTC midnight", st.StreakDays, style(ansiYellow, "⚠ needs a proof today"), countdown))
+	}
+	lines = append(lines, "")
+
+	categories := make(map[string]bool)
+	for c := range st.Categories {
+		categories[c] = true
+	}
+	for _, d := range []int64{today, today - 1} {
+		for c := range m.coins[d] {
+			categories[c] = true
+		}
+	}
+	names := make([]string, 0, len(categories))
+	for c := range categories {
+		names = append(names, c)
+	}
+	sort.Strings(names)
+	rows := make([][]string, 0, len(names))
+	for _, c := range names {
+		t, y := m.coins[today][c], m.coins[today-1][c]
+		rows = append(rows, []string{c, fmt.Sprint(t), fmt.Sprint(y), fmt.Sprintf("%+d", t-y)})
+	}
+	lines = append(lines, tableLines([]string{"CATEGORY", "TODAY", "YESTERDAY", "Δ"}, rows)...)
+	lines = append(lines, "")
+
+	rows = rows[:0]
+	for _, a := range m.agents {
+		last := "never"
+		if t, ok := m.lastProof[a.AgentID]; ok {
+			last = formatAge(now.Sub(t)) + " ago"
+		}
+		rows = append(rows, []string{a.AgentID, a.ClusterAlias, a.Status, last, fmt.Sprint(m.agentDays[today][a.AgentID]), m.health(a, now)})
+	}
+	for _, line := range tableLines([]string{"AGENT", "ALIAS", "STATUS", "LAST PROOF", "TODAY", "HEALTH"}, rows) {
+		switch {
+		case strings.HasSuffix(line, "ok"):
+			line = strings.TrimSuffix(line, "ok") + style(ansiGreen, "ok")
+		case strings.HasSuffix(line, "stale"), strings.HasSuffix(line, "silent"), strings.HasSuffix(line, "pending"):
+			line = style(ansiYellow, line)
+		case strings.HasSuffix(line, "revoked"):
+			line = style(ansiRed, line)
+		}
+		lines = append(lines, line)
+	}
+	footer := "q quit · r refresh · updated " + m.updated.Local().Format(time.TimeOnly)
+	switch {
+	case m.streamErr != "":
+		footer += " · " + style(ansiRed, "event stream down: "+m.streamErr)
+	case m.pollErr != "":
+		footer += " · " + style(ansiRed, m.pollErr)
+	}
+	if !ansi {
+		return strings.Join(lines, "\n") + "\n"
+	}
+	lines = append(lines, "", style(ansiBold, "LIVE PROOFS"))
+
+	feedRows := len(m.feed)
+	if height > 0 {
+		feedRows = min(feedRows, max(height-len(lines)-2, 0))
+	}
+	if feedRows == 0 && len(m.feed) == 0 {
+		lines = append(lines, style(ansiDim, "waiting for proofs…"))
+	}
+	rows = rows[:0]
+	for _, e := range m.feed[:feedRows] {
+		coins := fmt.Sprintf("%+d", e.Coins)
+		if e.Status != "" && e.Status != "success" {
+			coins = e.Status
+		}
+		rows = append(rows, []string{e.At.Local().Format(time.TimeOnly), e.AgentID, e.Category, coins, e.Description})
+	}
+	if len(rows) > 0 {
+		lines = append(lines, tableLines(nil, rows)...)
+	}
+	lines = append(lines, "", style(ansiDim, footer))
+
+	var b strings.Builder
+	b.WriteString(ansiHome)
+	for _, line := range lines {
+		if width > 0 {
+			line = truncate(line, width)
+		}
+		// The terminal is in raw mode: no implicit carriage return.
+		b.WriteString(line + ansiClearLine + "\r\n")
+	}
+	b.WriteString(ansiClearBelow)
+	return b.String()
+}
+
+// health summarizes an agent for the health table.
+func (m *topModel) health(a transport.Agent, now time.Time) string {
+	if a.Status != "active" {
+		return a.Status
+	}
+	last, ok := m.lastProof[a.AgentID]
+	switch {
+	case !ok:
+		return "silent"
+	case now.Sub(last) > m.stale:
+		return "stale"
+	}
+	return "ok"
+}
+
+// tableLines lays out a table like printTable, returning its lines. Without
+// headers only the rows are printed.
+func tableLines(headers []string, rows [][]string) []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	if headers != nil {
+		fmt.Fprintln(w, joinTab(headers))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(w, joinTab(row))
+	}
+	w.Flush()
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
+
+// truncate cuts a line to width visible runes, skipping ANSI escapes.
+func truncate(s string, width int) string {
+	var b strings.Builder
+	visible, escape := 0, false
+	for _, r := range s {
+		switch {
+		case escape:
+			escape = r != 'm'
+		case r == '\x1b':
+			escape = true
+		default:
+			if visible == width {
+				continue
+			}
+			visible++
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// proofTime returns when a proof was created, from its UUIDv7 ID, or now if
+// the ID carries no time.
+func proofTime(id string) time.Time {
+	u, err := uuid.Parse(id)
+	if err != nil || u.Version() != 7 {
+		return time.Now()
+	}
+	sec, nsec := u.Time().UnixTime()
+	return time.Unix(sec, nsec)
+}
+
+// utcDay numbers UTC days, the days streaks are counted in.
+func utcDay(t time.Time) int64 {
+	return t.UTC().Unix() / 86400
+}
+
+func formatCountdown(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// thousands formats n with comma separators, as in 1,250.
+func thousands(n int) string {
+	s := fmt.Sprint(n)
+	if n < 0 {
+		return "-" + thousands(-n)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// signalChan notifies a one-slot channel without blocking.
+func signalChan(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/auth"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// principal is the caller a request was authenticated as.
+type principal struct {
+	TokenID string // "bootstrap" for the config api_key
+	Scope   storage.TokenScope
+	AgentID string // set for agent tokens
+}
+
+type principalKey struct{}
+
+// principalFrom returns the authenticated caller of a request passed through require.
+func principalFrom(ctx context.Context) principal {
+	p, _ := ctx.Value(principalKey{}).(principal)
+	return p
+}
+
+// require admits requests bearing a token that allows scope. The config
+// api_key is accepted as a bootstrap admin token, so that the first real
+// tokens can be minted.
+func (h *Handler) require(scope storage.TokenScope, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := h.authenticate(r)
+		if !ok {
+			h.writeError(w, http.StatusUnauthorized, "invalid or missing token")
+			return
+		}
+		if !auth.Allows(p.Scope, scope) {
+			h.writeError(w, http.StatusForbidden, "token scope "+string(p.Scope)+" does not allow this action")
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	}
+}
+
+// withQueryToken lets clients that cannot set headers, such as browser
+// EventSource and WebSocket, pass their token as ?access_token=.
+func withQueryToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if t := r.URL.Query().Get("access_token"); t != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+t)
+		}
+		next(w, r)
+	}
+}
+
+func (h *Handler) authenticate(r *http.Request) (principal, bool) {
+	presented, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || presented == "" {
+		return principal{}, false
+	}
+
+	id, secret, ok := auth.Parse(presented)
+	if !ok {
+		if h.apiKey != "" && auth.Equal(presented, h.apiKey) {
+			return principal{TokenID: "bootstrap", Scope: storage.TokenScopeAdmin}, true
+		}
+		return principal{}, false
+	}
+
+	t, err := h.store.GetToken(r.Context(), id)
+	if err != nil {
+		if err != storage.ErrNotFound {
+			h.log.Error("get token", "token_id", id, "error", err)
+		}
+		return principal{}, false
+	}
+	if !auth.Valid(t, secret, time.Now()) {
+		return principal{}, false
+	}
+	return principal{TokenID: t.ID, Scope: t.Scope, AgentID: t.AgentID}, true
+}
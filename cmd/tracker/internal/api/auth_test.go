@@ -0,0 +1,136 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/auth"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+func TestRequire(t *testing.T) {
+	tr := newTracker(t)
+	tr.enroll("a1")
+	tr.enroll("a2")
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	admin := tr.token(storage.TokenScopeAdmin, "", nil)
+	read := tr.token(storage.TokenScopeRead, "", nil)
+	agent := tr.token(storage.TokenScopeAgent, "a1", nil)
+	revoked := tr.token(storage.TokenScopeAdmin, "", func(r *storage.TokenRecord) { r.RevokedAt = &past })
+	expired := tr.token(storage.TokenScopeAdmin, "", func(r *storage.TokenRecord) { r.ExpiresAt = &past })
+	unexpired := tr.token(storage.TokenScopeAdmin, "", func(r *storage.TokenRecord) { r.ExpiresAt = &future })
+	rotated := tr.token(storage.TokenScopeAdmin, "", func(r *storage.TokenRecord) {
+		r.SecretHash, r.RotatedAt = auth.Hash("the new secret"), &past
+	})
+	id, _, _ := auth.Parse(admin)
+	unknown := auth.Format(id+"0", "secret")
+
+	const (
+		adminRoute = "/api/v1/audit"
+		readRoute  = "/api/v1/agents"
+	)
+	for _, tc := range []struct {
+		name  string
+		path  string
+		token string
+		want  int
+	}{
+		{"no token", readRoute, "", http.StatusUnauthorized},
+		{"malformed token", readRoute, "not-a-token", http.StatusUnauthorized},
+		{"unknown token", readRoute, unknown, http.StatusUnauthorized},
+		{"bootstrap key", adminRoute, bootstrapKey, http.StatusOK},
+		{"wrong bootstrap key", adminRoute, bootstrapKey + "x", http.StatusUnauthorized},
+		{"admin token, admin route", adminRoute, admin, http.StatusOK},
+		{"admin token, read route", readRoute, admin, http.StatusOK},
+		{"read token, read route", readRoute, read, http.StatusOK},
+		{"read token, admin route", adminRoute, read, http.StatusForbidden},
+		{"agent token, read route", readRoute, agent, http.StatusForbidden},
+		{"agent token, admin route", adminRoute, agent, http.StatusForbidden},
+		{"agent token, own agent", "/api/v1/agents/a1/proofs/latest", agent, http.StatusOK},
+		{"agent token, other agent", "/api/v1/agents/a2/proofs/latest", agent, http.StatusForbidden},
+		{"read token, agent route", "/api/v1/agents/a1/proofs/latest", read, http.StatusForbidden},
+		{"admin token, any agent", "/api/v1/agents/a2/proofs/latest", admin, http.StatusOK},
+		{"revoked token", readRoute, revoked, http.StatusUnauthorized},
+		{"expired token", readRoute, expired, http.StatusUnauthorized},
+		{"unexpired token", readRoute, unexpired, http.StatusOK},
+		{"rotated token, old secret", readRoute, rotated, http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tr.do(http.MethodGet, tc.path, tc.token, nil, nil); got != tc.want {
+				t.Errorf("GET %s: status %d, want %d", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRequire_AgentTokenSubmitsForOwnAgentOnly(t *testing.T) {
+	tr := newTracker(t)
+	tr.enroll("a1")
+	a2 := tr.enroll("a2")
+	token := tr.token(storage.TokenScopeAgent, "a1", nil)
+
+	p, _ := tr.submit(a2, nil) // a valid proof, signed by a2
+	p.ProofID += "-again"
+	if code := tr.do(http.MethodPost, "/api/v1/proofs", token, p, nil); code != http.StatusForbidden {
+		t.Errorf("a1 token submitting for a2: status %d, want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestRotateToken_InvalidatesOldSecret(t *testing.T) {
+	tr := newTracker(t)
+
+	var created struct {
+		ID    string
+		Token string
+	}
+	req := map[string]string{"name": "dashboard", "scope": "read"}
+	if code := tr.do(http.MethodPost, "/api/v1/tokens", bootstrapKey, req, &created); code != http.StatusCreated {
+		t.Fatalf("create token: status %d", code)
+	}
+	var rotated struct{ Token string }
+	if code := tr.do(http.MethodPost, "/api/v1/tokens/"+created.ID+"/rotate", bootstrapKey, nil, &rotated); code != http.StatusOK {
+		t.Fatalf("rotate token: status %d", code)
+	}
+
+	if code := tr.do(http.MethodGet, "/api/v1/agents", created.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("old secret after rotation: status %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := tr.do(http.MethodGet, "/api/v1/agents", rotated.Token, nil, nil); code != http.StatusOK {
+		t.Errorf("new secret after rotation: status %d, want %d", code, http.StatusOK)
+	}
+
+	if code := tr.do(http.MethodPost, "/api/v1/tokens/"+created.ID+"/revoke", bootstrapKey, nil, nil); code != http.StatusOK {
+		t.Fatalf("revoke token: status %d", code)
+	}
+	if code := tr.do(http.MethodGet, "/api/v1/agents", rotated.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("revoked token: status %d, want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestTokenSelf(t *testing.T) {
+	tr := newTracker(t)
+	for _, tc := range []struct {
+		name    string
+		token   string
+		scope   storage.TokenScope
+		agentID string
+	}{
+		{"agent", tr.token(storage.TokenScopeAgent, "a1", nil), storage.TokenScopeAgent, "a1"},
+		{"read", tr.token(storage.TokenScopeRead, "", nil), storage.TokenScopeRead, ""},
+		{"bootstrap", bootstrapKey, storage.TokenScopeAdmin, ""},
+	} {
+		var self storage.TokenRecord
+		if code := tr.do(http.MethodGet, "/api/v1/tokens/self", tc.token, nil, &self); code != http.StatusOK {
+			t.Errorf("%s token: status %d", tc.name, code)
+			continue
+		}
+		if self.Scope != tc.scope || self.AgentID != tc.agentID || self.SecretHash != "" {
+			t.Errorf("%s token described as %+v", tc.name, self)
+		}
+	}
+	if code := tr.do(http.MethodGet, "/api/v1/tokens/self", "fa_bogus", nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("invalid token: status %d, want %d", code, http.StatusUnauthorized)
+	}
+}
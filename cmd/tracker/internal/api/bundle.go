@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/farmops/farmops/pkg/bundle"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// Audit log actions for bundles.
+const (
+	auditBundleExported = "bundle_exported"
+	auditBundleImported = "bundle_imported"
+)
+
+// maxBundleSize bounds the size of an uploaded bundle.
+const maxBundleSize = 1 << 30
+
+// importResponse reports what an import loaded.
+type importResponse struct {
+	Farm         string `json:"farm"`
+	SignerKey    string `json:"signer_key"`
+	Agents       int    `json:"agents"`
+	Proofs       int    `json:"proofs"`
+	LedgerEvents int    `json:"ledger_events"`
+	// PepperImported is false for a bundle without the exporting tracker's
+	// actor pepper; this tracker then keeps its own.
+	PepperImported bool `json:"pepper_imported"`
+}
+
+// handleExport writes the tracker's agents, proof chains and ledger as a
+// bundle signed with the tracker's key. They are read in one store
+// transaction, so that a proof accepted meanwhile cannot leave a reward in
+// the ledger without its proof.
+func (h *Handler) handleExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Create the actor pepper if need be, so that the bundle always carries it.
+	_, err := h.store.ActorPepper(ctx)
+	var snap *storage.Snapshot
+	if err == nil {
+		snap, err = h.store.Export(ctx)
+	}
+	if err != nil {
+		h.log.Error("export", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	b := &bundle.Bundle{
+		Agents:      snap.Agents,
+		Proofs:      snap.Proofs,
+		Ledger:      snap.Ledger,
+		ActorPepper: snap.ActorPepper,
+	}
+	b.Manifest.Farm, b.Manifest.CreatedAt = snap.Farm.Name, time.Now().UTC()
+	sort.Slice(b.Agents, func(i, j int) bool { return b.Agents[i].AgentID < b.Agents[j].AgentID })
+
+	key, err := h.store.TrackerKey(ctx)
+	if err != nil {
+		h.log.Error("export: tracker key", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, b, key); err != nil {
+		h.log.Error("export", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "export failed")
+		return
+	}
+
+	proofs := 0
+	for _, ac := range b.Manifest.Agents {
+		proofs += ac.Proofs
+	}
+	h.audit(r, auditBundleExported, "", map[string]string{
+		"agents": strconv.Itoa(len(b.Agents)),
+		"proofs": strconv.Itoa(proofs),
+	})
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="farmops-`+b.Manifest.CreatedAt.Format("20060102-150405")+`.tar.gz"`)
+	w.Write(buf.Bytes())
+}
+
+// handleImport loads a bundle into an empty tracker. The bundle must be
+// signed by the tracker key named in the signer_key query parameter, since
+// anyone can sign a bundle. The signature, every chain and the ledger are
+// verified before anything is stored, and the farm is then rebuilt from the
+// imported ledger.
+func (h *Handler) handleImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	signer := r.URL.Query().Get("signer_key")
+	if signer == "" {
+		h.writeError(w, http.StatusBadRequest, "signer_key is required: the public key of the tracker that exported the bundle")
+		return
+	}
+	signerKey, err := proof.DecodePublicKey(signer)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid signer_key: "+err.Error())
+		return
+	}
+	b, err := bundle.Read(http.MaxBytesReader(w, r.Body, maxBundleSize))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := b.CheckSigner(signerKey); err != nil {
+		h.writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if _, err := b.Verify(); err != nil {
+		h.writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	var proofs []*storage.StoredProof
+	for _, ac := range b.Manifest.Agents {
+		proofs = append(proofs, b.Proofs[ac.AgentID]...)
+	}
+	err = h.store.Import(ctx, b.Agents, proofs, b.Ledger, b.ActorPepper)
+	if errors.Is(err, storage.ErrNotEmpty) {
+		h.writeError(w, http.StatusConflict, "tracker already has agents, proofs or ledger events; import into a fresh tracker")
+		return
+	}
+	if err != nil {
+		h.log.Error("import", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	if b.Manifest.Farm != "" {
+		state, err := h.store.GetFarm(ctx)
+		if err == nil {
+			state.Name = b.Manifest.Farm
+			err = h.store.UpdateFarm(ctx, state)
+		}
+		if err != nil {
+			h.log.Error("import: farm name", "error", err)
+		}
+	}
+	if _, err := h.engine.Rebuild(ctx); err != nil {
+		h.log.Error("import: rebuild farm", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "imported, but rebuilding the farm failed")
+		return
+	}
+
+	resp := importResponse{
+		Farm:           b.Manifest.Farm,
+		SignerKey:      b.Manifest.SignerKey,
+		Agents:         len(b.Agents),
+		Proofs:         len(proofs),
+		LedgerEvents:   len(b.Ledger),
+		PepperImported: b.ActorPepper != nil,
+	}
+	h.audit(r, auditBundleImported, "", map[string]string{
+		"signer_key": resp.SignerKey,
+		"agents":     strconv.Itoa(resp.Agents),
+		"proofs":     strconv.Itoa(resp.Proofs),
+	})
+	h.log.Info("bundle imported", "agents", resp.Agents, "proofs", resp.Proofs, "ledger_events", resp.LedgerEvents)
+	if !resp.PepperImported {
+		h.log.Warn("bundle carries no actor pepper; agents must drop their stored actor_pepper to fetch this tracker's")
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
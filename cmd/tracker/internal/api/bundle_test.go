@@ -0,0 +1,153 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/farmops/farmops/pkg/bundle"
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+// export downloads a bundle from the tracker.
+func (tr *tracker) export() []byte {
+	tr.t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/export", nil)
+	req.Header.Set("Authorization", "Bearer "+bootstrapKey)
+	rec := httptest.NewRecorder()
+	tr.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		tr.t.Fatalf("export: status %d", rec.Code)
+	}
+	return rec.Body.Bytes()
+}
+
+type importResult struct {
+	Proofs         int  `json:"proofs"`
+	PepperImported bool `json:"pepper_imported"`
+}
+
+func (tr *tracker) importBundle(data []byte, signerKey string) (importResult, int) {
+	tr.t.Helper()
+	var res importResult
+	path := "/api/v1/import"
+	if signerKey != "" {
+		path += "?" + url.Values{"signer_key": {signerKey}}.Encode()
+	}
+	return res, tr.do(http.MethodPost, path, bootstrapKey, bytes.NewReader(data), &res)
+}
+
+// source returns a tracker with one agent and two rewarded proofs, and its
+// bundle signing key.
+func source(t *testing.T) (*tracker, ed25519.PrivateKey) {
+	t.Helper()
+	src := newTracker(t)
+	a := src.enroll("a1")
+	for range 2 {
+		if _, code := src.submit(a, nil); code != http.StatusCreated {
+			t.Fatalf("submit: status %d", code)
+		}
+	}
+	key, err := src.store.TrackerKey(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src, key
+}
+
+func TestExport_WhileSubmitting(t *testing.T) {
+	tr := newTracker(t)
+	a := tr.enroll("a1")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 200 {
+			p := signed(t, a, a.head, a.priv, nil)
+			if tr.do(http.MethodPost, "/api/v1/proofs", bootstrapKey, p, nil) == http.StatusCreated {
+				a.head = p
+			}
+		}
+	}()
+	for exports := 0; ; exports++ {
+		select {
+		case <-done:
+			if exports == 0 {
+				t.Skip("no export overlapped the submissions")
+			}
+			return
+		default:
+		}
+		b, err := bundle.Read(bytes.NewReader(tr.export()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.Verify(); err != nil {
+			t.Fatalf("export %d taken while proofs were submitted: %v", exports+1, err)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	src, key := source(t)
+	data := src.export()
+	signer := proof.EncodePublicKey(key.Public().(ed25519.PublicKey))
+
+	dst := newTracker(t)
+	res, code := dst.importBundle(data, signer)
+	if code != http.StatusOK {
+		t.Fatalf("import: status %d", code)
+	}
+	if res.Proofs != 2 || !res.PepperImported {
+		t.Errorf("import result %+v, want 2 proofs and the pepper", res)
+	}
+	srcPepper, _ := src.store.ActorPepper(ctx)
+	if dstPepper, _ := dst.store.ActorPepper(ctx); !bytes.Equal(dstPepper, srcPepper) {
+		t.Error("the importing tracker did not take over the actor pepper")
+	}
+	srcFarm, _ := src.store.GetFarm(ctx)
+	if dstFarm, _ := dst.store.GetFarm(ctx); dstFarm.TotalCoins != srcFarm.TotalCoins || srcFarm.TotalCoins == 0 {
+		t.Errorf("imported farm has %d coins, want %d", dstFarm.TotalCoins, srcFarm.TotalCoins)
+	}
+}
+
+func TestImport_RequiresPinnedSigner(t *testing.T) {
+	src, _ := source(t)
+	data := src.export()
+	other, _, _ := proof.GenerateKeyPair()
+
+	dst := newTracker(t)
+	if _, code := dst.importBundle(data, ""); code != http.StatusBadRequest {
+		t.Errorf("import without signer_key: status %d, want %d", code, http.StatusBadRequest)
+	}
+	if _, code := dst.importBundle(data, proof.EncodePublicKey(other)); code != http.StatusUnprocessableEntity {
+		t.Errorf("import with another signer_key: status %d, want %d", code, http.StatusUnprocessableEntity)
+	}
+	if agents, _ := dst.store.ListAgents(context.Background()); len(agents) != 0 {
+		t.Errorf("a refused import stored %d agents", len(agents))
+	}
+}
+
+func TestImport_ChecksLedgerAgainstProofs(t *testing.T) {
+	src, key := source(t)
+	b, err := bundle.Read(bytes.NewReader(src.export()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A bundle signed by the pinned key, but with a reward inflated before
+	// signing, as a compromised exporter could produce.
+	b.Ledger[0].Coins *= 100
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, b, key); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := newTracker(t)
+	if _, code := dst.importBundle(buf.Bytes(), b.Manifest.SignerKey); code != http.StatusUnprocessableEntity {
+		t.Errorf("import with an inflated reward: status %d, want %d", code, http.StatusUnprocessableEntity)
+	}
+}
@@ -4,11 +4,20 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
-	"strings"
-
+	"strconv"
+	"time"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/events"
+	"github.com/farmops/farmops/cmd/tracker/internal/projection"
+	"github.com/farmops/farmops/cmd/tracker/internal/web"
+	"github.com/farmops/farmops/cmd/tracker/internal/webhooks"
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/pki"
 	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/render"
 	"github.com/farmops/farmops/pkg/scoring"
 	"github.com/farmops/farmops/pkg/storage"
 )
@@ -16,18 +25,28 @@ import (
 // Handler is the root HTTP handler for the Stats Tracker API.
 type Handler struct {
 	store      storage.Store
+	engine     *projection.Engine
+	bus        *events.Bus
+	webhooks   *webhooks.Dispatcher
 	scoringCfg scoring.Config
 	apiKey     string
+	bindCert   bool
 	log        *slog.Logger
 	mux        *http.ServeMux
 }
 
-// NewHandler creates a new Handler and registers all routes.
-func NewHandler(store storage.Store, scoringCfg scoring.Config, apiKey string, log *slog.Logger) http.Handler {
+// NewHandler creates a new Handler and registers all routes. If bindCert is
+// set, proofs are only accepted over mutual TLS from a client certificate
+// issued to the proof's agent.
+func NewHandler(store storage.Store, engine *projection.Engine, bus *events.Bus, hooks *webhooks.Dispatcher, scoringCfg scoring.Config, apiKey string, bindCert bool, log *slog.Logger) http.Handler {
 	h := &Handler{
 		store:      store,
+		engine:     engine,
+		bus:        bus,
+		webhooks:   hooks,
 		scoringCfg: scoringCfg,
 		apiKey:     apiKey,
+		bindCert:   bindCert,
 		log:        log,
 		mux:        http.NewServeMux(),
 	}
@@ -44,34 +63,75 @@ func (h *Handler) routes() {
 	h.mux.HandleFunc("GET /healthz", h.handleHealthz)
 	h.mux.HandleFunc("GET /readyz", h.handleReadyz)
 
-	// Proof ingestion (agent → tracker, requires API key)
-	h.mux.HandleFunc("POST /api/v1/proofs", h.requireAPIKey(h.handleSubmitProof))
+	// Proof ingestion (agent → tracker) and inspection
+	h.mux.HandleFunc("POST /api/v1/proofs", h.require(storage.TokenScopeAgent, h.handleSubmitProof))
+	h.mux.HandleFunc("GET /api/v1/proofs", h.require(storage.TokenScopeRead, h.handleListProofs))
+	h.mux.HandleFunc("GET /api/v1/proofs/{id}", h.require(storage.TokenScopeRead, h.handleGetProof))
+	h.mux.HandleFunc("GET /api/v1/agents/{id}/proofs/latest", h.require(storage.TokenScopeAgent, h.handleLatestProof))
+	h.mux.HandleFunc("GET /api/v1/actor-pepper", h.require(storage.TokenScopeAgent, h.handleActorPepper))
 
 	// Farm state (public read)
 	h.mux.HandleFunc("GET /api/v1/farm", h.handleGetFarm)
+	h.mux.HandleFunc("GET /api/v1/farm/world", h.handleGetWorld)
+	h.mux.HandleFunc("GET /api/v1/farm/render.svg", h.handleRenderSVG)
+	h.mux.HandleFunc("GET /api/v1/farm/stats", h.handleFarmStats)
+	h.mux.HandleFunc("GET /api/v1/ledger", h.handleListLedger)
+
+	// Live event stream
+	h.mux.HandleFunc("GET /api/v1/events", withQueryToken(h.require(storage.TokenScopeRead, h.handleEventStream)))
+	h.mux.HandleFunc("GET /api/v1/events/ws", withQueryToken(h.require(storage.TokenScopeRead, h.eventSocket().ServeHTTP)))
+
+	// Farm management
+	h.mux.HandleFunc("POST /api/v1/farm/plots/{plot}/plant", h.require(storage.TokenScopeAdmin, h.handlePlant))
+	h.mux.HandleFunc("POST /api/v1/farm/plots/{plot}/harvest", h.require(storage.TokenScopeAdmin, h.handleHarvest))
+
+	// Shop
+	h.mux.HandleFunc("GET /api/v1/shop/items", h.handleShopItems)
+
+	// Scoring (public read)
+	h.mux.HandleFunc("GET /api/v1/scoring/config", h.handleScoringConfig)
+	h.mux.HandleFunc("POST /api/v1/shop/purchase", h.require(storage.TokenScopeAdmin, h.handlePurchase))
 
 	// Agent management
-	h.mux.HandleFunc("GET /api/v1/agents", h.handleListAgents)
-	h.mux.HandleFunc("POST /api/v1/agents/enroll", h.requireAPIKey(h.handleEnrollAgent))
-	h.mux.HandleFunc("POST /api/v1/agents/{id}/approve", h.requireAPIKey(h.handleApproveAgent))
-	h.mux.HandleFunc("POST /api/v1/agents/{id}/revoke", h.requireAPIKey(h.handleRevokeAgent))
+	h.mux.HandleFunc("GET /api/v1/agents", h.require(storage.TokenScopeRead, h.handleListAgents))
+	h.mux.HandleFunc("POST /api/v1/agents/enroll", h.require(storage.TokenScopeAdmin, h.handleEnrollAgent))
+	h.mux.HandleFunc("POST /api/v1/agents/join", h.handleJoinAgent) // authenticated by the join token itself
+	h.mux.HandleFunc("POST /api/v1/agents/{id}/approve", h.require(storage.TokenScopeAdmin, h.handleApproveAgent))
+	h.mux.HandleFunc("POST /api/v1/agents/{id}/revoke", h.require(storage.TokenScopeAdmin, h.handleRevokeAgent))
+
+	// Audit log
+	h.mux.HandleFunc("GET /api/v1/audit", h.require(storage.TokenScopeAdmin, h.handleListAudit))
+
+	// Proof bundles: export and import
+	h.mux.HandleFunc("GET /api/v1/export", h.require(storage.TokenScopeAdmin, h.handleExport))
+	h.mux.HandleFunc("POST /api/v1/import", h.require(storage.TokenScopeAdmin, h.handleImport))
+
+	// API tokens
+	h.mux.HandleFunc("GET /api/v1/tokens", h.require(storage.TokenScopeAdmin, h.handleListTokens))
+	h.mux.HandleFunc("GET /api/v1/tokens/self", h.handleTokenSelf) // any valid token, about itself
+	h.mux.HandleFunc("POST /api/v1/tokens", h.require(storage.TokenScopeAdmin, h.handleCreateToken))
+	h.mux.HandleFunc("POST /api/v1/tokens/{id}/rotate", h.require(storage.TokenScopeAdmin, h.handleRotateToken))
+	h.mux.HandleFunc("POST /api/v1/tokens/{id}/revoke", h.require(storage.TokenScopeAdmin, h.handleRevokeToken))
+
+	// Agent join tokens
+	h.mux.HandleFunc("GET /api/v1/join-tokens", h.require(storage.TokenScopeAdmin, h.handleListJoinTokens))
+	h.mux.HandleFunc("POST /api/v1/join-tokens", h.require(storage.TokenScopeAdmin, h.handleCreateJoinToken))
+	h.mux.HandleFunc("POST /api/v1/join-tokens/{id}/revoke", h.require(storage.TokenScopeAdmin, h.handleRevokeJoinToken))
+
+	// Outbound webhooks
+	h.mux.HandleFunc("GET /api/v1/webhooks", h.require(storage.TokenScopeAdmin, h.handleListWebhooks))
+	h.mux.HandleFunc("POST /api/v1/webhooks", h.require(storage.TokenScopeAdmin, h.handleCreateWebhook))
+	h.mux.HandleFunc("DELETE /api/v1/webhooks/{id}", h.require(storage.TokenScopeAdmin, h.handleDeleteWebhook))
+	h.mux.HandleFunc("GET /api/v1/webhooks/{id}/deliveries", h.require(storage.TokenScopeAdmin, h.handleListDeliveries))
+	h.mux.HandleFunc("GET /api/v1/webhooks/dead-letters", h.require(storage.TokenScopeAdmin, h.handleListDeadLetters))
+	h.mux.HandleFunc("POST /api/v1/webhooks/dead-letters/{id}/redeliver", h.require(storage.TokenScopeAdmin, h.handleRedeliver))
 
 	// Public profile (for village servers)
 	h.mux.HandleFunc("GET /api/v1/public/profile", h.handlePublicProfile)
-}
-
-// --- Middleware ---
 
-func (h *Handler) requireAPIKey(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		auth := r.Header.Get("Authorization")
-		token := strings.TrimPrefix(auth, "Bearer ")
-		if token != h.apiKey {
-			h.writeError(w, http.StatusUnauthorized, "invalid or missing API key")
-			return
-		}
-		next(w, r)
-	}
+	// Web dashboard
+	h.mux.Handle("GET /ui/", http.StripPrefix("/ui/", web.Handler()))
+	h.mux.Handle("GET /{$}", http.RedirectHandler("/ui/", http.StatusFound))
 }
 
 // --- Health ---
@@ -96,6 +156,32 @@ func (h *Handler) handleSubmitProof(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusBadRequest, "invalid proof JSON")
 		return
 	}
+	if !proof.SupportedVersion(p.SchemaVersion) {
+		h.writeError(w, http.StatusBadRequest, "unsupported schema_version "+strconv.Quote(p.SchemaVersion))
+		return
+	}
+	if (p.KeyRotation != nil) != (p.Action.ActionType == proof.ActionRotateKey) {
+		h.writeError(w, http.StatusBadRequest, "key_rotation must be set exactly on rotate_key proofs")
+		return
+	}
+
+	// Agent tokens may only submit proofs for their own agent.
+	if caller := principalFrom(r.Context()); caller.Scope == storage.TokenScopeAgent && caller.AgentID != p.Agent.AgentID {
+		h.writeError(w, http.StatusForbidden, "token is not valid for agent "+p.Agent.AgentID)
+		return
+	}
+
+	// With identity binding, the client certificate must belong to the agent.
+	if h.bindCert {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			h.writeError(w, http.StatusForbidden, "client certificate required")
+			return
+		}
+		if id := pki.AgentID(r.TLS.PeerCertificates[0]); id != p.Agent.AgentID {
+			h.writeError(w, http.StatusForbidden, "client certificate is for agent "+id+", not "+p.Agent.AgentID)
+			return
+		}
+	}
 
 	// Look up the agent's public key from the trust store.
 	agent, err := h.store.GetAgent(r.Context(), p.Agent.AgentID)
@@ -126,6 +212,8 @@ func (h *Handler) handleSubmitProof(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify chain linkage against the latest stored proof for this agent.
+	// This only rejects stale proofs early: the store checks the linkage
+	// again in the transaction that appends the proof.
 	latest, err := h.store.LatestProof(r.Context(), p.Agent.AgentID)
 	if err != nil {
 		h.log.Error("get latest proof", "error", err)
@@ -145,48 +233,100 @@ func (h *Handler) handleSubmitProof(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if p.KeyRotation != nil {
+		h.acceptKeyRotation(w, r, &p, agent)
+		return
+	}
+
 	// Only score verified, successful proofs.
-	coins := 0
+	var score scoring.Result
+	now := h.engine.Now()
 	if p.Outcome.Verified && p.Outcome.Status == proof.OutcomeSuccess {
-		farm, err := h.store.GetFarm(r.Context())
+		world, err := h.engine.World(r.Context())
 		if err != nil {
-			h.log.Error("get farm for scoring", "error", err)
+			h.log.Error("get farm world for scoring", "error", err)
+			h.writeError(w, http.StatusInternalServerError, "storage error")
+			return
 		}
-		_ = farm // upgrade multiplier lookup will be added in Phase 1
-		result := scoring.Compute(&p, h.scoringCfg, 1.0, 0)
-		coins = result.TotalCoins
+		score = scoring.Compute(&p, h.scoringCfg, world.UpgradeMultiplier(p.Action.Category), world.StreakBonusDays(now))
 	}
+	coins := score.TotalCoins
 
-	if err := h.store.AppendProof(r.Context(), &p, coins); err == storage.ErrDuplicateProof {
+	// Append the proof and its reward in one transaction; the farm world
+	// grows from the ledger.
+	var reward []*farm.Event
+	if coins > 0 {
+		reward = append(reward, farm.Reward(p.ProofID, p.Agent.AgentID, p.Action.Category, coins, now))
+	}
+	if _, err := h.engine.AppendProof(r.Context(), &p, score, reward...); errors.Is(err, storage.ErrDuplicateProof) {
 		h.writeJSON(w, http.StatusOK, map[string]any{
 			"accepted":         false,
 			"rejection_reason": "duplicate proof_id",
 			"coins_awarded":    0,
 		})
 		return
+	} else if errors.Is(err, storage.ErrChainConflict) || errors.Is(err, storage.ErrKeyRotated) {
+		// Another proof linked to the same head, or a key rotation, was
+		// appended in the meantime.
+		h.writeError(w, http.StatusConflict, err.Error())
+		return
 	} else if err != nil {
-		h.log.Error("append proof", "error", err)
+		h.log.Error("append proof", "proof_id", p.ProofID, "error", err)
 		h.writeError(w, http.StatusInternalServerError, "storage error")
 		return
 	}
 
-	// Update farm coin balance.
-	if coins > 0 {
-		farm, _ := h.store.GetFarm(r.Context())
-		if farm != nil {
-			farm.TotalCoins += coins
-			farm.CurrentCoins += coins
-			_ = h.store.UpdateFarm(r.Context(), farm)
-		}
-	}
-
 	h.log.Info("proof accepted", "proof_id", p.ProofID, "agent_id", p.Agent.AgentID, "coins", coins)
+	h.bus.Publish(events.TypeProofAccepted, events.ProofAccepted{
+		ProofID:      p.ProofID,
+		AgentID:      p.Agent.AgentID,
+		ClusterAlias: p.Agent.ClusterAlias,
+		Category:     p.Action.Category,
+		Description:  p.Action.Description,
+		Status:       p.Outcome.Status,
+		Coins:        coins,
+	})
 	h.writeJSON(w, http.StatusCreated, map[string]any{
 		"accepted":      true,
 		"coins_awarded": coins,
 	})
 }
 
+func (h *Handler) handleListProofs(w http.ResponseWriter, r *http.Request) {
+	limit := queryInt(r, "limit", 50)
+	var (
+		proofs []*storage.StoredProof
+		err    error
+	)
+	if agentID := r.URL.Query().Get("agent_id"); agentID != "" {
+		proofs, err = h.store.ListProofs(r.Context(), agentID, r.URL.Query().Get("after"), limit)
+	} else {
+		proofs, err = h.store.RecentProofs(r.Context(), limit)
+	}
+	if err != nil {
+		h.log.Error("list proofs", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	if proofs == nil {
+		proofs = []*storage.StoredProof{}
+	}
+	h.writeJSON(w, http.StatusOK, proofs)
+}
+
+func (h *Handler) handleGetProof(w http.ResponseWriter, r *http.Request) {
+	sp, err := h.store.GetProof(r.Context(), r.PathValue("id"))
+	if err == storage.ErrNotFound {
+		h.writeError(w, http.StatusNotFound, "proof not found")
+		return
+	}
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, sp)
+}
+
 // --- Farm ---
 
 func (h *Handler) handleGetFarm(w http.ResponseWriter, r *http.Request) {
@@ -198,12 +338,163 @@ func (h *Handler) handleGetFarm(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, farm)
 }
 
+func (h *Handler) handleGetWorld(w http.ResponseWriter, r *http.Request) {
+	world, err := h.engine.World(r.Context())
+	if err != nil {
+		h.log.Error("get farm world", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, world)
+}
+
+func (h *Handler) handleRenderSVG(w http.ResponseWriter, r *http.Request) {
+	world, err := h.engine.World(r.Context())
+	if err != nil {
+		h.log.Error("get farm world", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	state, err := h.store.GetFarm(r.Context())
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-cache")
+	opts := render.Options{Title: state.Name, GrowthToRipe: h.engine.Rules().GrowthToRipe}
+	if err := render.SVG(w, world, opts); err != nil {
+		h.log.Warn("render farm svg", "error", err)
+	}
+}
+
+func (h *Handler) handleFarmStats(w http.ResponseWriter, r *http.Request) {
+	world, err := h.engine.World(r.Context())
+	if err != nil {
+		h.log.Error("get farm world", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"categories": world.Categories,
+	})
+}
+
+func (h *Handler) handleListLedger(w http.ResponseWriter, r *http.Request) {
+	after, err := strconv.ParseUint(r.URL.Query().Get("after"), 10, 64)
+	if err != nil && r.URL.Query().Get("after") != "" {
+		h.writeError(w, http.StatusBadRequest, "invalid after")
+		return
+	}
+	events, err := h.store.ListEvents(r.Context(), after, queryInt(r, "limit", 100))
+	if err != nil {
+		h.log.Error("list ledger", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	if events == nil {
+		events = []*farm.Event{}
+	}
+	h.writeJSON(w, http.StatusOK, events)
+}
+
+type plantRequest struct {
+	Category string `json:"category"`
+}
+
+func (h *Handler) handlePlant(w http.ResponseWriter, r *http.Request) {
+	plot, err := strconv.Atoi(r.PathValue("plot"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid plot")
+		return
+	}
+	var req plantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if _, ok := h.scoringCfg.BaseCoins[req.Category]; !ok {
+		h.writeError(w, http.StatusBadRequest, "unknown category: "+req.Category)
+		return
+	}
+	h.executeFarmCommand(w, r, func(world *farm.World) ([]*farm.Event, error) {
+		ev, err := world.Plant(h.engine.Rules(), plot, req.Category, h.engine.Now())
+		if err != nil {
+			return nil, err
+		}
+		return []*farm.Event{ev}, nil
+	})
+}
+
+func (h *Handler) handleHarvest(w http.ResponseWriter, r *http.Request) {
+	plot, err := strconv.Atoi(r.PathValue("plot"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid plot")
+		return
+	}
+	h.executeFarmCommand(w, r, func(world *farm.World) ([]*farm.Event, error) {
+		ev, err := world.Harvest(h.engine.Rules(), plot, h.engine.Now())
+		if err != nil {
+			return nil, err
+		}
+		return []*farm.Event{ev}, nil
+	})
+}
+
+// executeFarmCommand runs a farm command through the projection engine and
+// writes the resulting world, mapping rule violations to 409 Conflict.
+func (h *Handler) executeFarmCommand(w http.ResponseWriter, r *http.Request, cmd func(*farm.World) ([]*farm.Event, error)) {
+	world, err := h.engine.Execute(r.Context(), cmd)
+	var ruleErr farm.RuleError
+	if errors.As(err, &ruleErr) {
+		h.writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		h.log.Error("farm command", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, world)
+}
+
+// --- Shop ---
+
+func (h *Handler) handleShopItems(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, http.StatusOK, farm.Catalog())
+}
+
+// handleScoringConfig returns the scoring parameters proofs are rewarded
+// with, so that clients can preview rewards.
+func (h *Handler) handleScoringConfig(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.scoringCfg)
+}
+
+type purchaseRequest struct {
+	Item string `json:"item"`
+}
+
+func (h *Handler) handlePurchase(w http.ResponseWriter, r *http.Request) {
+	var req purchaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	h.executeFarmCommand(w, r, func(world *farm.World) ([]*farm.Event, error) {
+		ev, err := world.Purchase(req.Item, h.engine.Now())
+		if err != nil {
+			return nil, err
+		}
+		return []*farm.Event{ev}, nil
+	})
+}
+
 // --- Agents ---
 
 type enrollRequest struct {
 	AgentID      string `json:"agent_id"`
 	ClusterAlias string `json:"cluster_alias"`
-	PublicKey    string `json:"public_key"` // hex-encoded Ed25519 public key
+	PublicKey    string `json:"public_key"` // Ed25519 public key: hex, PEM, OpenSSH or JWK
 }
 
 func (h *Handler) handleEnrollAgent(w http.ResponseWriter, r *http.Request) {
@@ -216,8 +507,9 @@ func (h *Handler) handleEnrollAgent(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusBadRequest, "agent_id, cluster_alias, and public_key are required")
 		return
 	}
-	// Validate the public key is parseable.
-	if _, err := proof.DecodePublicKey(req.PublicKey); err != nil {
+	// Accept any supported encoding; the trust store keeps the canonical hex.
+	pub, err := proof.DecodePublicKey(req.PublicKey)
+	if err != nil {
 		h.writeError(w, http.StatusBadRequest, "invalid public_key: "+err.Error())
 		return
 	}
@@ -225,32 +517,36 @@ func (h *Handler) handleEnrollAgent(w http.ResponseWriter, r *http.Request) {
 	record := &storage.AgentRecord{
 		AgentID:      req.AgentID,
 		ClusterAlias: req.ClusterAlias,
-		PublicKey:    req.PublicKey,
+		PublicKey:    proof.EncodePublicKey(pub),
 		Status:       storage.AgentStatusPending,
+		EnrolledAt:   time.Now().UTC(),
 	}
-	if err := h.store.UpsertAgent(r.Context(), record); err != nil {
+	// Enrolling must not replace the key, history or revocation of an agent
+	// that is already registered; rotation and approval do that.
+	if err := h.store.CreateAgent(r.Context(), record); err == storage.ErrAgentExists {
+		h.writeError(w, http.StatusConflict, "agent "+req.AgentID+" is already enrolled")
+		return
+	} else if err != nil {
 		h.log.Error("enroll agent", "error", err)
 		h.writeError(w, http.StatusInternalServerError, "storage error")
 		return
 	}
 	h.log.Info("agent enrolled (pending approval)", "agent_id", req.AgentID, "alias", req.ClusterAlias)
+	h.bus.Publish(events.TypeAgentEnrolled, events.AgentEnrolled{
+		AgentID:      record.AgentID,
+		ClusterAlias: record.ClusterAlias,
+		Status:       string(record.Status),
+	})
 	h.writeJSON(w, http.StatusCreated, map[string]string{
 		"status":  "pending",
 		"message": "Agent enrolled. Approve it with: farmctl agent approve " + req.AgentID,
 	})
 }
 
+// handleApproveAgent trusts a pending agent, or a revoked one again. Proofs
+// invalidated by the revocation stay invalid.
 func (h *Handler) handleApproveAgent(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	h.setAgentStatus(w, r, id, storage.AgentStatusActive)
-}
-
-func (h *Handler) handleRevokeAgent(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	h.setAgentStatus(w, r, id, storage.AgentStatusRevoked)
-}
-
-func (h *Handler) setAgentStatus(w http.ResponseWriter, r *http.Request, agentID string, status storage.AgentStatus) {
+	agentID := r.PathValue("id")
 	agent, err := h.store.GetAgent(r.Context(), agentID)
 	if err == storage.ErrNotFound {
 		h.writeError(w, http.StatusNotFound, "agent not found")
@@ -260,13 +556,14 @@ func (h *Handler) setAgentStatus(w http.ResponseWriter, r *http.Request, agentID
 		h.writeError(w, http.StatusInternalServerError, "storage error")
 		return
 	}
-	agent.Status = status
+	agent.Status, agent.RevokedAt = storage.AgentStatusActive, nil
 	if err := h.store.UpsertAgent(r.Context(), agent); err != nil {
 		h.writeError(w, http.StatusInternalServerError, "storage error")
 		return
 	}
-	h.log.Info("agent status updated", "agent_id", agentID, "status", status)
-	h.writeJSON(w, http.StatusOK, map[string]string{"status": string(status)})
+	h.audit(r, auditAgentApproved, agentID, nil)
+	h.log.Info("agent status updated", "agent_id", agentID, "status", agent.Status)
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": string(agent.Status)})
 }
 
 func (h *Handler) handleListAgents(w http.ResponseWriter, r *http.Request) {
@@ -306,3 +603,12 @@ func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
 func (h *Handler) writeError(w http.ResponseWriter, status int, msg string) {
 	h.writeJSON(w, status, map[string]string{"error": msg})
 }
+
+// queryInt returns the named query parameter as a positive int, or def.
+func queryInt(r *http.Request, name string, def int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,282 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/api"
+	"github.com/farmops/farmops/cmd/tracker/internal/auth"
+	"github.com/farmops/farmops/cmd/tracker/internal/events"
+	"github.com/farmops/farmops/cmd/tracker/internal/projection"
+	"github.com/farmops/farmops/cmd/tracker/internal/webhooks"
+	"github.com/farmops/farmops/pkg/bundle"
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/scoring"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// bootstrapKey is the config api_key of test trackers.
+const bootstrapKey = "test-bootstrap-key"
+
+// tracker is an API handler over a fresh store.
+type tracker struct {
+	t      *testing.T
+	store  *storage.BoltStore
+	engine *projection.Engine
+	http.Handler
+}
+
+func newTracker(t *testing.T) *tracker {
+	t.Helper()
+	store, err := storage.OpenBolt(filepath.Join(t.TempDir(), "tracker.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	bus := events.NewBus(0)
+	engine := projection.New(store, farm.DefaultRules(), time.Now, bus)
+	hooks := webhooks.New(store, webhooks.DefaultPolicy(), log)
+	h := api.NewHandler(store, engine, bus, hooks, scoring.DefaultConfig(), bootstrapKey, false, log)
+	return &tracker{t: t, store: store, engine: engine, Handler: h}
+}
+
+// do sends a request with the given bearer token and body, both optional,
+// and decodes a JSON response into out if it is not nil. A body that is not
+// an io.Reader is sent as JSON.
+func (tr *tracker) do(method, path, token string, body, out any) int {
+	tr.t.Helper()
+	var rd io.Reader
+	switch body := body.(type) {
+	case nil:
+	case io.Reader:
+		rd = body
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			tr.t.Fatal(err)
+		}
+		rd = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, rd)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	tr.ServeHTTP(rec, req)
+	if out != nil && rec.Code < 300 {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			tr.t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+// token stores a token of the given scope, lets edit adjust the record, and
+// returns the token string.
+func (tr *tracker) token(scope storage.TokenScope, agentID string, edit func(*storage.TokenRecord)) string {
+	tr.t.Helper()
+	id, secret, token, err := auth.Mint()
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	rec := &storage.TokenRecord{
+		ID:         id,
+		Name:       "test",
+		Scope:      scope,
+		AgentID:    agentID,
+		SecretHash: auth.Hash(secret),
+		CreatedAt:  time.Now().UTC(),
+	}
+	if edit != nil {
+		edit(rec)
+	}
+	if err := tr.store.PutToken(context.Background(), rec); err != nil {
+		tr.t.Fatal(err)
+	}
+	return token
+}
+
+// agent is an enrolled, approved test agent that signs its own proofs.
+type agent struct {
+	id   string
+	priv ed25519.PrivateKey
+	head *proof.FarmProof
+}
+
+// enroll enrolls and approves an agent with a fresh key.
+func (tr *tracker) enroll(id string) *agent {
+	tr.t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	req := map[string]string{"agent_id": id, "cluster_alias": id + "-cluster", "public_key": proof.EncodePublicKey(pub)}
+	if code := tr.do(http.MethodPost, "/api/v1/agents/enroll", bootstrapKey, req, nil); code != http.StatusCreated {
+		tr.t.Fatalf("enroll %s: status %d", id, code)
+	}
+	if code := tr.do(http.MethodPost, "/api/v1/agents/"+id+"/approve", bootstrapKey, nil, nil); code != http.StatusOK {
+		tr.t.Fatalf("approve %s: status %d", id, code)
+	}
+	return &agent{id: id, priv: priv}
+}
+
+// signed returns a successful, verified proof of the agent chained to prev,
+// letting edit adjust it before it is signed with key.
+func signed(t *testing.T, a *agent, prev *proof.FarmProof, key ed25519.PrivateKey, edit func(*proof.FarmProof)) *proof.FarmProof {
+	t.Helper()
+	p, err := proof.New(
+		proof.AgentInfo{AgentID: a.id, ClusterAlias: a.id + "-cluster"},
+		proof.ActorInfo{},
+		proof.ActionInfo{ActionType: "pod_restart", Category: proof.CategoryMaintenance, Description: "restart crashlooping pod"},
+		proof.OutcomeInfo{Status: proof.OutcomeSuccess, Verified: true},
+		proof.ScoringHints{Complexity: "medium", ImpactRadius: 1},
+		prev,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edit != nil {
+		edit(p)
+	}
+	if err := proof.Sign(p, key); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// submitAll submits the proofs concurrently and returns their statuses.
+func (tr *tracker) submitAll(proofs ...*proof.FarmProof) []int {
+	codes := make([]int, len(proofs))
+	var wg sync.WaitGroup
+	for i, p := range proofs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = tr.do(http.MethodPost, "/api/v1/proofs", bootstrapKey, p, nil)
+		}()
+	}
+	wg.Wait()
+	return codes
+}
+
+// submit signs a successful, verified proof chained to the agent's previous
+// one, letting edit adjust it first, and submits it, returning the response
+// status.
+func (tr *tracker) submit(a *agent, edit func(*proof.FarmProof)) (*proof.FarmProof, int) {
+	tr.t.Helper()
+	p := signed(tr.t, a, a.head, a.priv, edit)
+	code := tr.do(http.MethodPost, "/api/v1/proofs", bootstrapKey, p, nil)
+	if code == http.StatusCreated {
+		a.head = p
+	}
+	return p, code
+}
+
+func TestEnrollAgent_RejectsExistingID(t *testing.T) {
+	tr := newTracker(t)
+	a := tr.enroll("a1")
+	before, err := tr.store.GetAgent(context.Background(), a.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	req := map[string]string{"agent_id": a.id, "cluster_alias": "elsewhere", "public_key": proof.EncodePublicKey(pub)}
+	if code := tr.do(http.MethodPost, "/api/v1/agents/enroll", bootstrapKey, req, nil); code != http.StatusConflict {
+		t.Fatalf("re-enroll: status %d, want %d", code, http.StatusConflict)
+	}
+
+	after, err := tr.store.GetAgent(context.Background(), a.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.PublicKey != before.PublicKey || after.Status != storage.AgentStatusActive || after.ClusterAlias != before.ClusterAlias {
+		t.Errorf("re-enroll changed the agent: before %+v, after %+v", before, after)
+	}
+}
+
+func TestSubmitProof_RecordsReward(t *testing.T) {
+	tr := newTracker(t)
+	a := tr.enroll("a1")
+	p, code := tr.submit(a, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("submit: status %d", code)
+	}
+
+	ledger, err := tr.store.ListEvents(context.Background(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp, err := tr.store.GetProof(context.Background(), p.ProofID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ledger) != 1 || ledger[0].ProofID != p.ProofID || ledger[0].Coins != sp.CoinsAwarded || sp.CoinsAwarded == 0 {
+		t.Errorf("ledger = %+v, want one reward of %d coins for %s", ledger, sp.CoinsAwarded, p.ProofID)
+	}
+
+	// A replayed proof is not rewarded twice.
+	if code := tr.do(http.MethodPost, "/api/v1/proofs", bootstrapKey, p, nil); code != http.StatusConflict {
+		t.Errorf("replay: status %d, want %d", code, http.StatusConflict)
+	}
+	if ledger, _ := tr.store.ListEvents(context.Background(), 0, 0); len(ledger) != 1 {
+		t.Errorf("ledger holds %d events after a replay, want 1", len(ledger))
+	}
+}
+
+func TestSubmitProof_ConcurrentSubmissionsDoNotForkChain(t *testing.T) {
+	tr := newTracker(t)
+	a := tr.enroll("a1")
+	if _, code := tr.submit(a, nil); code != http.StatusCreated {
+		t.Fatalf("submit: status %d", code)
+	}
+
+	// Sign several proofs that all link to the same head, then submit them
+	// at once: only one may extend the chain.
+	const n = 8
+	proofs := make([]*proof.FarmProof, n)
+	for i := range proofs {
+		proofs[i] = signed(t, a, a.head, a.priv, nil)
+	}
+	codes := tr.submitAll(proofs...)
+
+	var accepted int
+	for i, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			accepted++
+		case http.StatusConflict:
+		default:
+			t.Errorf("proof %d: status %d, want %d or %d", i, code, http.StatusCreated, http.StatusConflict)
+		}
+	}
+	if accepted != 1 {
+		t.Fatalf("%d of %d proofs linked to the same head were accepted, want 1", accepted, n)
+	}
+	if ledger, _ := tr.store.ListEvents(context.Background(), 0, 0); len(ledger) != 2 {
+		t.Errorf("ledger holds %d events, want 2 rewards", len(ledger))
+	}
+
+	// The tracker's own export still verifies.
+	b, err := bundle.Read(bytes.NewReader(tr.export()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Verify(); err != nil {
+		t.Errorf("verify export: %v", err)
+	}
+}
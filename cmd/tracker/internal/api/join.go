@@ -0,0 +1,227 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/auth"
+	"github.com/farmops/farmops/cmd/tracker/internal/events"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// defaultJoinTTL is how long a join token is valid when no expiry is given.
+const defaultJoinTTL = time.Hour
+
+type createJoinTokenRequest struct {
+	Name         string `json:"name"`
+	ClusterAlias string `json:"cluster_alias"` // optional preset alias for the joining agent
+	AutoApprove  bool   `json:"auto_approve"`
+	ExpiresIn    string `json:"expires_in"` // Go duration; defaults to 1h
+}
+
+// joinTokenResponse returns a join token record together with its secret
+// token string, which is only ever shown on creation.
+type joinTokenResponse struct {
+	*storage.JoinToken
+	Token string
+}
+
+// joinResponse tells a newly joined agent how it was enrolled and hands it
+// the agent token it submits proofs with.
+type joinResponse struct {
+	AgentID      string `json:"agent_id"`
+	ClusterAlias string `json:"cluster_alias"`
+	Status       string `json:"status"`
+	TokenID      string `json:"token_id"`
+	Token        string `json:"token"`
+	ActorPepper  string `json:"actor_pepper"` // hex; keys the agent's actor hashes
+}
+
+func (h *Handler) handleCreateJoinToken(w http.ResponseWriter, r *http.Request) {
+	var req createJoinTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	ttl := defaultJoinTTL
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			h.writeError(w, http.StatusBadRequest, "expires_in must be a positive duration such as 30m")
+			return
+		}
+		ttl = d
+	}
+
+	id, secret, token, err := auth.MintJoin()
+	if err != nil {
+		h.log.Error("mint join token", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "could not mint token")
+		return
+	}
+	now := time.Now().UTC()
+	record := &storage.JoinToken{
+		ID:           id,
+		Name:         req.Name,
+		ClusterAlias: req.ClusterAlias,
+		AutoApprove:  req.AutoApprove,
+		SecretHash:   auth.Hash(secret),
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(ttl),
+	}
+	if err := h.store.PutJoinToken(r.Context(), record); err != nil {
+		h.log.Error("create join token", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.log.Info("join token created", "join_token_id", id, "alias", req.ClusterAlias, "auto_approve", req.AutoApprove, "by", principalFrom(r.Context()).TokenID)
+	record.SecretHash = ""
+	h.writeJSON(w, http.StatusCreated, joinTokenResponse{JoinToken: record, Token: token})
+}
+
+func (h *Handler) handleListJoinTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.store.ListJoinTokens(r.Context())
+	if err != nil {
+		h.log.Error("list join tokens", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	for _, t := range tokens {
+		t.SecretHash = ""
+	}
+	h.writeJSON(w, http.StatusOK, tokens)
+}
+
+func (h *Handler) handleRevokeJoinToken(w http.ResponseWriter, r *http.Request) {
+	record, err := h.store.GetJoinToken(r.Context(), r.PathValue("id"))
+	if err == storage.ErrNotFound {
+		h.writeError(w, http.StatusNotFound, "join token not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("get join token", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	if record.RevokedAt == nil {
+		now := time.Now().UTC()
+		record.RevokedAt = &now
+		if err := h.store.PutJoinToken(r.Context(), record); err != nil {
+			h.log.Error("revoke join token", "error", err)
+			h.writeError(w, http.StatusInternalServerError, "storage error")
+			return
+		}
+		h.log.Info("join token revoked", "join_token_id", record.ID, "by", principalFrom(r.Context()).TokenID)
+	}
+	record.SecretHash = ""
+	h.writeJSON(w, http.StatusOK, record)
+}
+
+// handleJoinAgent lets an agent enroll itself with a join token presented as
+// its bearer token. The token is consumed, the agent is registered (active if
+// the token auto-approves, pending otherwise), and an agent-scoped API token
+// is minted for it.
+func (h *Handler) handleJoinAgent(w http.ResponseWriter, r *http.Request) {
+	presented, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	id, secret, ok := auth.ParseJoin(presented)
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "invalid or missing join token")
+		return
+	}
+	join, err := h.store.GetJoinToken(r.Context(), id)
+	if err != nil && err != storage.ErrNotFound {
+		h.log.Error("get join token", "join_token_id", id, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	now := time.Now().UTC()
+	if err == storage.ErrNotFound || !auth.ValidJoin(join, secret, now) {
+		h.writeError(w, http.StatusUnauthorized, "join token is invalid, expired or already used")
+		return
+	}
+
+	var req enrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if join.ClusterAlias != "" {
+		req.ClusterAlias = join.ClusterAlias
+	}
+	if req.AgentID == "" || req.PublicKey == "" || req.ClusterAlias == "" {
+		h.writeError(w, http.StatusBadRequest, "agent_id, cluster_alias, and public_key are required")
+		return
+	}
+	pub, err := proof.DecodePublicKey(req.PublicKey)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid public_key: "+err.Error())
+		return
+	}
+
+	pepper, err := h.store.ActorPepper(r.Context())
+	if err != nil {
+		h.log.Error("get actor pepper", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	tokenID, tokenSecret, token, err := auth.Mint()
+	if err != nil {
+		h.log.Error("mint token", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "could not mint token")
+		return
+	}
+
+	status := storage.AgentStatusPending
+	if join.AutoApprove {
+		status = storage.AgentStatusActive
+	}
+	agent := &storage.AgentRecord{
+		AgentID:      req.AgentID,
+		ClusterAlias: req.ClusterAlias,
+		PublicKey:    proof.EncodePublicKey(pub),
+		Status:       status,
+		EnrolledAt:   now,
+	}
+	// Redeeming the token, registering the agent and storing its API token
+	// happen in one transaction, so that concurrent joins cannot both
+	// redeem a token, and a join token never replaces an existing agent.
+	err = h.store.JoinAgent(r.Context(), join.ID, agent, &storage.TokenRecord{
+		ID:         tokenID,
+		Name:       "join " + join.ID,
+		Scope:      storage.TokenScopeAgent,
+		AgentID:    req.AgentID,
+		SecretHash: auth.Hash(tokenSecret),
+		CreatedAt:  now,
+	}, now)
+	switch {
+	case err == storage.ErrTokenUsed || err == storage.ErrNotFound:
+		h.writeError(w, http.StatusUnauthorized, "join token is invalid, expired or already used")
+		return
+	case err == storage.ErrAgentExists:
+		h.writeError(w, http.StatusConflict, "agent "+req.AgentID+" is already enrolled")
+		return
+	case err != nil:
+		h.log.Error("join agent", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+
+	h.log.Info("agent joined", "agent_id", req.AgentID, "alias", req.ClusterAlias, "status", status, "join_token_id", join.ID)
+	h.bus.Publish(events.TypeAgentEnrolled, events.AgentEnrolled{
+		AgentID:      agent.AgentID,
+		ClusterAlias: agent.ClusterAlias,
+		Status:       string(agent.Status),
+	})
+	h.writeJSON(w, http.StatusCreated, joinResponse{
+		AgentID:      agent.AgentID,
+		ClusterAlias: agent.ClusterAlias,
+		Status:       string(agent.Status),
+		TokenID:      tokenID,
+		Token:        token,
+		ActorPepper:  hex.EncodeToString(pepper),
+	})
+}
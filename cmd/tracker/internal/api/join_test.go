@@ -0,0 +1,93 @@
+package api_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+// joinToken creates a join token and returns its ID and token string.
+func (tr *tracker) joinToken() (id, token string) {
+	tr.t.Helper()
+	var created struct {
+		ID    string
+		Token string
+	}
+	req := map[string]any{"name": "test", "cluster_alias": "joined", "auto_approve": true}
+	if code := tr.do(http.MethodPost, "/api/v1/join-tokens", bootstrapKey, req, &created); code != http.StatusCreated {
+		tr.t.Fatalf("create join token: status %d", code)
+	}
+	return created.ID, created.Token
+}
+
+func joinRequest(agentID string) map[string]string {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	return map[string]string{"agent_id": agentID, "public_key": proof.EncodePublicKey(pub)}
+}
+
+func TestJoinAgent_RedeemsTokenOnce(t *testing.T) {
+	tr := newTracker(t)
+	id, token := tr.joinToken()
+
+	const joiners = 8
+	codes := make([]int, joiners)
+	var wg sync.WaitGroup
+	for i := range joiners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = tr.do(http.MethodPost, "/api/v1/agents/join", token, joinRequest(fmt.Sprintf("a%d", i)), nil)
+		}()
+	}
+	wg.Wait()
+
+	joined := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			joined++
+		case http.StatusUnauthorized:
+		default:
+			t.Errorf("join: unexpected status %d", code)
+		}
+	}
+	if joined != 1 {
+		t.Errorf("%d agents joined with one token, want 1", joined)
+	}
+	agents, _ := tr.store.ListAgents(context.Background())
+	if len(agents) != 1 {
+		t.Errorf("%d agents registered, want 1", len(agents))
+	}
+	if jt, _ := tr.store.GetJoinToken(context.Background(), id); jt.UsedBy != agents[0].AgentID {
+		t.Errorf("join token used by %q, want %q", jt.UsedBy, agents[0].AgentID)
+	}
+}
+
+func TestJoinAgent_RejectsExistingID(t *testing.T) {
+	tr := newTracker(t)
+	existing := tr.enroll("a1")
+	before, _ := tr.store.GetAgent(context.Background(), existing.id)
+	id, token := tr.joinToken()
+
+	if code := tr.do(http.MethodPost, "/api/v1/agents/join", token, joinRequest("a1"), nil); code != http.StatusConflict {
+		t.Fatalf("join as existing agent: status %d, want %d", code, http.StatusConflict)
+	}
+	after, _ := tr.store.GetAgent(context.Background(), existing.id)
+	if after.PublicKey != before.PublicKey || after.ClusterAlias != before.ClusterAlias {
+		t.Errorf("join replaced the existing agent: before %+v, after %+v", before, after)
+	}
+	if jt, _ := tr.store.GetJoinToken(context.Background(), id); jt.UsedAt != nil {
+		t.Error("a rejected join used up the token")
+	}
+
+	// The token is still good for a new agent.
+	if code := tr.do(http.MethodPost, "/api/v1/agents/join", token, joinRequest("a2"), nil); code != http.StatusCreated {
+		t.Errorf("join as new agent: status %d, want %d", code, http.StatusCreated)
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/events"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// acceptKeyRotation stores a key rotation proof whose signature (by the
+// agent's current key) and chain linkage have been verified, and swaps the
+// agent's key in the same transaction, which checks both again so that
+// concurrent rotations cannot both succeed. Rotation proofs earn no coins.
+func (h *Handler) acceptKeyRotation(w http.ResponseWriter, r *http.Request, p *proof.FarmProof, agent *storage.AgentRecord) {
+	if _, err := p.KeyRotation.PublicKey(); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid new_public_key: "+err.Error())
+		return
+	}
+	if agent.UsedKey(p.KeyRotation.NewPublicKey) {
+		h.writeError(w, http.StatusUnprocessableEntity, "new_public_key has already been used by this agent")
+		return
+	}
+
+	if err := h.store.RotateAgentKey(r.Context(), p); err == storage.ErrDuplicateProof {
+		h.writeJSON(w, http.StatusOK, map[string]any{
+			"accepted":         false,
+			"rejection_reason": "duplicate proof_id",
+			"coins_awarded":    0,
+		})
+		return
+	} else if err == storage.ErrChainConflict || err == storage.ErrKeyRotated {
+		// Another proof or rotation was accepted since this one was verified.
+		h.writeError(w, http.StatusConflict, err.Error())
+		return
+	} else if err != nil {
+		h.log.Error("rotate agent key", "agent_id", agent.AgentID, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+
+	h.log.Info("agent key rotated", "agent_id", agent.AgentID, "proof_id", p.ProofID, "retired_keys", len(agent.KeyHistory)+1)
+	h.bus.Publish(events.TypeProofAccepted, events.ProofAccepted{
+		ProofID:      p.ProofID,
+		AgentID:      p.Agent.AgentID,
+		ClusterAlias: p.Agent.ClusterAlias,
+		Category:     p.Action.Category,
+		Description:  p.Action.Description,
+		Status:       p.Outcome.Status,
+	})
+	h.writeJSON(w, http.StatusCreated, map[string]any{
+		"accepted":      true,
+		"coins_awarded": 0,
+	})
+}
+
+// handleLatestProof returns the head of an agent's proof chain, or null
+// before its first proof, so that the agent can link its next proof to it.
+// Agent tokens may only read their own chain.
+func (h *Handler) handleLatestProof(w http.ResponseWriter, r *http.Request) {
+	agentID := r.PathValue("id")
+	if caller := principalFrom(r.Context()); caller.Scope == storage.TokenScopeAgent && caller.AgentID != agentID {
+		h.writeError(w, http.StatusForbidden, "token is not valid for agent "+agentID)
+		return
+	}
+	latest, err := h.store.LatestProof(r.Context(), agentID)
+	if err != nil {
+		h.log.Error("get latest proof", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, latest)
+}
+
+// handleActorPepper hands agents the pepper they key actor hashes with.
+// Joining agents receive it in the join response; agents enrolled by hand
+// fetch it here. It is only given to agent and admin tokens.
+func (h *Handler) handleActorPepper(w http.ResponseWriter, r *http.Request) {
+	pepper, err := h.store.ActorPepper(r.Context())
+	if err != nil {
+		h.log.Error("get actor pepper", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]string{"actor_pepper": hex.EncodeToString(pepper)})
+}
@@ -0,0 +1,98 @@
+package api_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/scoring"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// rotation returns a key rotation proof chained to the agent's head and
+// signed with its current key, and the new private key.
+func rotation(t *testing.T, a *agent) (*proof.FarmProof, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := proof.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := proof.NewKeyRotation(proof.AgentInfo{AgentID: a.id, ClusterAlias: a.id + "-cluster"}, pub, []byte("pepper"), a.head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proof.Sign(p, a.priv); err != nil {
+		t.Fatal(err)
+	}
+	return p, priv
+}
+
+func TestRotateKey_ConcurrentRotations(t *testing.T) {
+	tr := newTracker(t)
+	a := tr.enroll("a1")
+	if _, code := tr.submit(a, nil); code != http.StatusCreated {
+		t.Fatalf("submit: status %d", code)
+	}
+
+	const n = 8
+	rotations := make([]*proof.FarmProof, n)
+	for i := range rotations {
+		rotations[i], _ = rotation(t, a)
+	}
+	var accepted *proof.FarmProof
+	for i, code := range tr.submitAll(rotations...) {
+		switch code {
+		case http.StatusCreated:
+			if accepted != nil {
+				t.Fatalf("rotations %s and %s were both accepted", accepted.ProofID, rotations[i].ProofID)
+			}
+			accepted = rotations[i]
+		case http.StatusConflict, http.StatusUnprocessableEntity:
+		default:
+			t.Errorf("rotation %d: status %d", i, code)
+		}
+	}
+	if accepted == nil {
+		t.Fatal("no rotation was accepted")
+	}
+
+	rec, err := tr.store.GetAgent(context.Background(), a.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.KeyHistory) != 1 || rec.PublicKey != accepted.KeyRotation.NewPublicKey {
+		t.Errorf("agent has key %s and %d retired keys; want the accepted rotation's key and 1 retired key",
+			rec.PublicKey, len(rec.KeyHistory))
+	}
+}
+
+func TestRotateKey_StoreRejectsRetiredKey(t *testing.T) {
+	tr := newTracker(t)
+	a := tr.enroll("a1")
+	if _, code := tr.submit(a, nil); code != http.StatusCreated {
+		t.Fatalf("submit: status %d", code)
+	}
+	r, _ := rotation(t, a)
+	if code := tr.do(http.MethodPost, "/api/v1/proofs", bootstrapKey, r, nil); code != http.StatusCreated {
+		t.Fatalf("rotate: status %d", code)
+	}
+
+	// These proofs were verified against the retired key before the rotation
+	// was stored; the store must still refuse them.
+	ctx := context.Background()
+	stale := signed(t, a, r, a.priv, nil)
+	if _, err := tr.engine.AppendProof(ctx, stale, scoring.Result{}); !errors.Is(err, storage.ErrKeyRotated) {
+		t.Errorf("append a proof signed with the retired key: err = %v, want ErrKeyRotated", err)
+	}
+	a.head = r
+	again, _ := rotation(t, a)
+	if err := tr.store.RotateAgentKey(ctx, again); !errors.Is(err, storage.ErrKeyRotated) {
+		t.Errorf("rotate with the retired key: err = %v, want ErrKeyRotated", err)
+	}
+	if sp, _ := tr.store.LatestProof(ctx, a.id); sp == nil || sp.ProofID != r.ProofID {
+		t.Errorf("chain head is %v, want the rotation proof %s", sp, r.ProofID)
+	}
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// Audit log actions.
+const (
+	auditAgentApproved = "agent_approved"
+	auditAgentRevoked  = "agent_revoked"
+)
+
+// revokeRequest is the optional body of an agent revocation. With neither
+// FromProofID nor FromTime set, the agent is revoked without touching its
+// past proofs.
+type revokeRequest struct {
+	FromProofID string    `json:"from_proof_id"` // invalidate this proof and every one chained after it
+	FromTime    time.Time `json:"from_time"`     // invalidate proofs received at or after this time
+	Reason      string    `json:"reason"`
+}
+
+// revokeResponse reports what a revocation undid.
+type revokeResponse struct {
+	Status            string   `json:"status"`
+	InvalidatedProofs []string `json:"invalidated_proofs"`
+	CoinsReversed     int      `json:"coins_reversed"`
+}
+
+// handleRevokeAgent stops trusting an agent. If the request names a point in
+// its chain, the proofs from that point on are marked invalid and their coins
+// are reversed with compensating ledger entries, which recomputes the farm,
+// its achievements and the public stats.
+func (h *Handler) handleRevokeAgent(w http.ResponseWriter, r *http.Request) {
+	var req revokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if req.FromProofID != "" && !req.FromTime.IsZero() {
+		h.writeError(w, http.StatusBadRequest, "set at most one of from_proof_id and from_time")
+		return
+	}
+
+	agent, err := h.store.GetAgent(r.Context(), r.PathValue("id"))
+	if err == storage.ErrNotFound {
+		h.writeError(w, http.StatusNotFound, "agent not found")
+		return
+	}
+	if err != nil {
+		h.log.Error("get agent", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+
+	var invalid []string
+	if req.FromProofID != "" || !req.FromTime.IsZero() {
+		proofs, err := h.store.ListProofs(r.Context(), agent.AgentID, "", 0)
+		if err != nil {
+			h.log.Error("list proofs", "error", err)
+			h.writeError(w, http.StatusInternalServerError, "storage error")
+			return
+		}
+		if req.FromProofID != "" {
+			if invalid = descendants(proofs, req.FromProofID); invalid == nil {
+				h.writeError(w, http.StatusNotFound, "proof "+req.FromProofID+" not found in the chain of agent "+agent.AgentID)
+				return
+			}
+		} else {
+			// Cut by the time the tracker received each proof: a compromised
+			// key can sign any timestamp and proof ID it likes.
+			for _, sp := range proofs {
+				if !sp.ReceivedAt.Before(req.FromTime) {
+					invalid = append(invalid, sp.ProofID)
+				}
+			}
+		}
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "agent revoked"
+	}
+	// Revoking, invalidating and reversing happen in one transaction; a
+	// failed request changes nothing and can be retried.
+	reversals, err := h.engine.Revoke(r.Context(), agent.AgentID, invalid, reason)
+	if err != nil {
+		h.log.Error("revoke agent", "agent_id", agent.AgentID, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	resp := revokeResponse{Status: string(storage.AgentStatusRevoked), InvalidatedProofs: []string{}}
+	if invalid != nil {
+		resp.InvalidatedProofs = invalid
+	}
+	for _, ev := range reversals {
+		resp.CoinsReversed -= ev.Coins
+	}
+
+	detail := map[string]string{
+		"invalidated_proofs": strconv.Itoa(len(resp.InvalidatedProofs)),
+		"coins_reversed":     strconv.Itoa(resp.CoinsReversed),
+	}
+	if req.FromProofID != "" {
+		detail["from_proof_id"] = req.FromProofID
+	}
+	if !req.FromTime.IsZero() {
+		detail["from_time"] = req.FromTime.UTC().Format(time.RFC3339)
+	}
+	if req.Reason != "" {
+		detail["reason"] = req.Reason
+	}
+	h.audit(r, auditAgentRevoked, agent.AgentID, detail)
+
+	h.log.Info("agent revoked", "agent_id", agent.AgentID, "invalidated_proofs", len(resp.InvalidatedProofs), "coins_reversed", resp.CoinsReversed)
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// descendants returns the ID of the proof fromID and of every proof chained
+// after it, following prev_proof_id links rather than proof ID order, which
+// the signer controls. It returns nil if fromID is not among proofs.
+func descendants(proofs []*storage.StoredProof, fromID string) []string {
+	children := map[string][]string{}
+	found := false
+	for _, sp := range proofs {
+		children[sp.PrevProofID] = append(children[sp.PrevProofID], sp.ProofID)
+		found = found || sp.ProofID == fromID
+	}
+	if !found {
+		return nil
+	}
+	ids, seen := []string{fromID}, map[string]bool{fromID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, id := range children[ids[i]] {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+func (h *Handler) handleListAudit(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.store.ListAudit(r.Context(), queryInt(r, "limit", 100))
+	if err != nil {
+		h.log.Error("list audit log", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	if entries == nil {
+		entries = []*storage.AuditEntry{}
+	}
+	h.writeJSON(w, http.StatusOK, entries)
+}
+
+// audit records an administrative action taken by the caller of r. A failure
+// is logged but does not fail the action, which has already happened.
+func (h *Handler) audit(r *http.Request, action, target string, detail map[string]string) {
+	e := &storage.AuditEntry{
+		At:     time.Now().UTC(),
+		Actor:  principalFrom(r.Context()).TokenID,
+		Action: action,
+		Target: target,
+		Detail: detail,
+	}
+	if err := h.store.AppendAudit(r.Context(), e); err != nil {
+		h.log.Error("append audit log", "action", action, "target", target, "error", err)
+	}
+}
@@ -0,0 +1,262 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/auth"
+	"github.com/farmops/farmops/pkg/bundle"
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// backdated gives a proof an ID that sorts before every real UUID v7, as a
+// leaked key could.
+func backdated(p *proof.FarmProof) {
+	p.ProofID = "00000000-0000-7000-8000-000000000001"
+}
+
+type revokeResult struct {
+	Status            string   `json:"status"`
+	InvalidatedProofs []string `json:"invalidated_proofs"`
+	CoinsReversed     int      `json:"coins_reversed"`
+}
+
+func (tr *tracker) revoke(agentID, token string, req map[string]any) (revokeResult, int) {
+	tr.t.Helper()
+	var res revokeResult
+	code := tr.do(http.MethodPost, "/api/v1/agents/"+agentID+"/revoke", token, req, &res)
+	return res, code
+}
+
+// twoProofs enrolls an agent and submits two proofs, the second with a
+// backdated ID and received after the returned cut time.
+func twoProofs(tr *tracker) (a *agent, p1, p2 *proof.FarmProof, cut time.Time) {
+	tr.t.Helper()
+	a = tr.enroll("a1")
+	p1, code := tr.submit(a, nil)
+	if code != http.StatusCreated {
+		tr.t.Fatalf("submit p1: status %d", code)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cut = time.Now()
+	time.Sleep(10 * time.Millisecond)
+	p2, code = tr.submit(a, backdated)
+	if code != http.StatusCreated {
+		tr.t.Fatalf("submit p2: status %d", code)
+	}
+	return a, p1, p2, cut
+}
+
+func (tr *tracker) invalidated(proofID string) bool {
+	tr.t.Helper()
+	sp, err := tr.store.GetProof(context.Background(), proofID)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return sp.InvalidatedAt != nil
+}
+
+func TestRevokeAgent_FromTimeUsesReceivedAt(t *testing.T) {
+	tr := newTracker(t)
+	a, p1, p2, cut := twoProofs(tr)
+
+	res, code := tr.revoke(a.id, bootstrapKey, map[string]any{"from_time": cut, "reason": "key leaked"})
+	if code != http.StatusOK {
+		t.Fatalf("revoke: status %d", code)
+	}
+	if !slices.Equal(res.InvalidatedProofs, []string{p2.ProofID}) {
+		t.Errorf("invalidated %v, want only %s", res.InvalidatedProofs, p2.ProofID)
+	}
+	if tr.invalidated(p1.ProofID) || !tr.invalidated(p2.ProofID) {
+		t.Errorf("p1 invalid: %v, p2 invalid: %v; want false, true", tr.invalidated(p1.ProofID), tr.invalidated(p2.ProofID))
+	}
+	sp2, _ := tr.store.GetProof(context.Background(), p2.ProofID)
+	if res.CoinsReversed != sp2.CoinsAwarded {
+		t.Errorf("reversed %d coins, want %d", res.CoinsReversed, sp2.CoinsAwarded)
+	}
+}
+
+func TestRevokeAgent_FromProofFollowsChain(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		from func(p1, p2 *proof.FarmProof) string
+		want func(p1, p2 *proof.FarmProof) []string
+	}{
+		{
+			"from the last proof",
+			func(p1, p2 *proof.FarmProof) string { return p2.ProofID },
+			func(p1, p2 *proof.FarmProof) []string { return []string{p2.ProofID} },
+		},
+		{
+			"from the first proof",
+			func(p1, p2 *proof.FarmProof) string { return p1.ProofID },
+			func(p1, p2 *proof.FarmProof) []string { return []string{p1.ProofID, p2.ProofID} },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := newTracker(t)
+			a, p1, p2, _ := twoProofs(tr)
+
+			res, code := tr.revoke(a.id, bootstrapKey, map[string]any{"from_proof_id": tc.from(p1, p2)})
+			if code != http.StatusOK {
+				t.Fatalf("revoke: status %d", code)
+			}
+			if want := tc.want(p1, p2); !slices.Equal(res.InvalidatedProofs, want) {
+				t.Errorf("invalidated %v, want %v", res.InvalidatedProofs, want)
+			}
+		})
+	}
+
+	tr := newTracker(t)
+	a := tr.enroll("a1")
+	if _, code := tr.revoke(a.id, bootstrapKey, map[string]any{"from_proof_id": "no-such-proof"}); code != http.StatusNotFound {
+		t.Errorf("revoke from unknown proof: status %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestRevokeAgent_AfterCoinsWereSpent(t *testing.T) {
+	ctx := context.Background()
+	tr := newTracker(t)
+	a := tr.enroll("a1")
+	item, _ := farm.LookupItem("maintenance-barn")
+	var first *proof.FarmProof
+	for {
+		p, code := tr.submit(a, nil)
+		if code != http.StatusCreated {
+			t.Fatalf("submit: status %d", code)
+		}
+		if first == nil {
+			first = p
+		}
+		if w, _ := tr.engine.World(ctx); w.Balance >= item.Cost(1) {
+			break
+		}
+	}
+	buy := map[string]string{"item": item.Slug}
+	if code := tr.do(http.MethodPost, "/api/v1/shop/purchase", bootstrapKey, buy, nil); code != http.StatusOK {
+		t.Fatalf("purchase: status %d", code)
+	}
+
+	if _, code := tr.revoke(a.id, bootstrapKey, map[string]any{"from_proof_id": first.ProofID}); code != http.StatusOK {
+		t.Fatalf("revoke: status %d", code)
+	}
+	w, err := tr.engine.World(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Balance != -item.Cost(1) || w.TotalCoins != 0 {
+		t.Errorf("balance %d, total %d after revoking every reward; want a debt of %d", w.Balance, w.TotalCoins, item.Cost(1))
+	}
+	if b := w.Building(item.Slug); b == nil {
+		t.Error("the building bought with reversed coins is gone, want it kept")
+	}
+	if code := tr.do(http.MethodPost, "/api/v1/shop/purchase", bootstrapKey, buy, nil); code != http.StatusConflict {
+		t.Errorf("purchase in debt: status %d, want %d", code, http.StatusConflict)
+	}
+
+	// Rewards of another agent pay the debt off, and the ledger still
+	// verifies.
+	a2 := tr.enroll("a2")
+	if _, code := tr.submit(a2, nil); code != http.StatusCreated {
+		t.Fatalf("submit a2: status %d", code)
+	}
+	if after, _ := tr.engine.World(ctx); after.Balance <= w.Balance {
+		t.Errorf("balance %d after a new reward, want more than %d", after.Balance, w.Balance)
+	}
+	b, err := bundle.Read(bytes.NewReader(tr.export()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Verify(); err != nil {
+		t.Errorf("verify export: %v", err)
+	}
+}
+
+func TestRevokeAgent_Retry(t *testing.T) {
+	tr := newTracker(t)
+	a, p1, _, _ := twoProofs(tr)
+	req := map[string]any{"from_proof_id": p1.ProofID}
+
+	first, code := tr.revoke(a.id, bootstrapKey, req)
+	if code != http.StatusOK || first.CoinsReversed == 0 {
+		t.Fatalf("revoke: status %d, reversed %d coins", code, first.CoinsReversed)
+	}
+	agent, _ := tr.store.GetAgent(context.Background(), a.id)
+	revokedAt := *agent.RevokedAt
+
+	second, code := tr.revoke(a.id, bootstrapKey, req)
+	if code != http.StatusOK || second.CoinsReversed != 0 {
+		t.Errorf("revoke again: status %d, reversed %d coins; want 200, 0", code, second.CoinsReversed)
+	}
+
+	agent, _ = tr.store.GetAgent(context.Background(), a.id)
+	if agent.Status != storage.AgentStatusRevoked || !agent.RevokedAt.Equal(revokedAt) {
+		t.Errorf("agent after second revoke: status %s, revoked at %s; want revoked at %s", agent.Status, agent.RevokedAt, revokedAt)
+	}
+	ledger, _ := tr.store.ListEvents(context.Background(), 0, 0)
+	reversals := 0
+	for _, ev := range ledger {
+		if ev.Type == farm.EventRewardReversed {
+			reversals++
+		}
+	}
+	if reversals != 2 {
+		t.Errorf("ledger holds %d reversals, want 2", reversals)
+	}
+	if state, _ := tr.store.GetFarm(context.Background()); state.TotalCoins != 0 {
+		t.Errorf("farm total %d coins after reversing every reward, want 0", state.TotalCoins)
+	}
+
+	if _, code := tr.submit(a, nil); code != http.StatusForbidden {
+		t.Errorf("submit after revoke: status %d, want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	tr := newTracker(t)
+	a, p1, _, _ := twoProofs(tr) // approved with the bootstrap key
+	admin := tr.token(storage.TokenScopeAdmin, "", nil)
+	adminID, _, _ := auth.Parse(admin)
+
+	res, code := tr.revoke(a.id, admin, map[string]any{"from_proof_id": p1.ProofID, "reason": "key leaked"})
+	if code != http.StatusOK {
+		t.Fatalf("revoke: status %d", code)
+	}
+
+	var entries []storage.AuditEntry
+	if code := tr.do(http.MethodGet, "/api/v1/audit", admin, nil, &entries); code != http.StatusOK {
+		t.Fatalf("list audit log: status %d", code)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("audit log holds %d entries, want 2", len(entries))
+	}
+	revoked, approved := entries[0], entries[1] // newest first
+	if approved.Action != "agent_approved" || approved.Actor != "bootstrap" || approved.Target != a.id {
+		t.Errorf("approval entry = %+v", approved)
+	}
+	wantDetail := map[string]string{
+		"from_proof_id":      p1.ProofID,
+		"reason":             "key leaked",
+		"invalidated_proofs": "2",
+		"coins_reversed":     strconv.Itoa(res.CoinsReversed),
+	}
+	if revoked.Action != "agent_revoked" || revoked.Actor != adminID || revoked.Target != a.id {
+		t.Errorf("revocation entry = %+v", revoked)
+	}
+	for k, v := range wantDetail {
+		if revoked.Detail[k] != v {
+			t.Errorf("revocation detail %s = %q, want %q", k, revoked.Detail[k], v)
+		}
+	}
+
+	if code := tr.do(http.MethodGet, "/api/v1/audit?limit=1", admin, nil, &entries); code != http.StatusOK || len(entries) != 1 {
+		t.Errorf("audit log with limit=1: status %d, %d entries", code, len(entries))
+	}
+}
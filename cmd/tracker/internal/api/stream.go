@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/events"
+)
+
+// streamKeepAlive is how often an idle stream sends a keep-alive so that
+// proxies do not close it.
+const streamKeepAlive = 15 * time.Second
+
+// handleEventStream streams bus events as Server-Sent Events. Clients resume
+// with the Last-Event-ID header (or the last_event_id query parameter) and may
+// restrict the stream with ?types=proof_accepted,coins_awarded.
+func (h *Handler) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	// The server's write timeout is meant for ordinary requests; a stream
+	// lives until the client goes away.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	after, _ := strconv.ParseUint(lastID, 10, 64)
+	match := typeFilter(r)
+
+	backlog, ch, cancel := h.bus.Subscribe(after)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	send := func(ev events.Event) error {
+		if !match(ev.Type) {
+			return nil
+		}
+		data, err := json.Marshal(ev.Data)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+		return err
+	}
+	for _, ev := range backlog {
+		if err := send(ev); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := send(ev); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// eventSocket streams bus events over a WebSocket as JSON text frames, one
+// event per frame. It accepts the same last_event_id and types parameters as
+// the SSE stream. The socket is read-only: anything the client sends is ignored.
+func (h *Handler) eventSocket() http.Handler {
+	return websocket.Server{
+		// Accept connections from any origin, including non-browser clients
+		// that send none. The route requires a read token, which arrives as
+		// ?access_token= rather than in a cookie, so another site cannot
+		// open a socket with the user's credentials.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			r := ws.Request()
+			after, _ := strconv.ParseUint(r.URL.Query().Get("last_event_id"), 10, 64)
+			match := typeFilter(r)
+
+			backlog, ch, cancel := h.bus.Subscribe(after)
+			defer cancel()
+
+			// Detect the client going away by draining its frames.
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var discard []byte
+				for websocket.Message.Receive(ws, &discard) == nil {
+				}
+			}()
+
+			send := func(ev events.Event) error {
+				if !match(ev.Type) {
+					return nil
+				}
+				return websocket.JSON.Send(ws, ev)
+			}
+			for _, ev := range backlog {
+				if err := send(ev); err != nil {
+					return
+				}
+			}
+			for {
+				select {
+				case <-closed:
+					return
+				case ev, ok := <-ch:
+					if !ok {
+						return
+					}
+					if err := send(ev); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+}
+
+// typeFilter returns a predicate for the event types listed in the ?types
+// query parameter. An absent parameter matches every type.
+func typeFilter(r *http.Request) func(string) bool {
+	raw := r.URL.Query().Get("types")
+	if raw == "" {
+		return func(string) bool { return true }
+	}
+	want := map[string]bool{}
+	for _, t := range strings.Split(raw, ",") {
+		want[strings.TrimSpace(t)] = true
+	}
+	return func(t string) bool { return want[t] }
+}
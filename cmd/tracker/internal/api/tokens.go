@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/auth"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+type createTokenRequest struct {
+	Name      string `json:"name"`
+	Scope     string `json:"scope"`      // agent, admin or read
+	AgentID   string `json:"agent_id"`   // required for agent tokens
+	ExpiresIn string `json:"expires_in"` // optional Go duration, e.g. "2160h"
+}
+
+// tokenResponse returns a token record together with its secret token
+// string. The token string is only ever shown on creation and rotation.
+type tokenResponse struct {
+	*storage.TokenRecord
+	Token string
+}
+
+func (h *Handler) handleCreateToken(w http.ResponseWriter, r *http.Request) {
+	var req createTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	scope := storage.TokenScope(req.Scope)
+	switch scope {
+	case storage.TokenScopeAgent:
+		if req.AgentID == "" {
+			h.writeError(w, http.StatusBadRequest, "agent tokens require agent_id")
+			return
+		}
+	case storage.TokenScopeAdmin, storage.TokenScopeRead:
+		if req.AgentID != "" {
+			h.writeError(w, http.StatusBadRequest, "agent_id is only valid for agent tokens")
+			return
+		}
+	default:
+		h.writeError(w, http.StatusBadRequest, "scope must be agent, admin or read")
+		return
+	}
+
+	now := time.Now().UTC()
+	var expires *time.Time
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			h.writeError(w, http.StatusBadRequest, "expires_in must be a positive duration such as 2160h")
+			return
+		}
+		at := now.Add(d)
+		expires = &at
+	}
+
+	id, secret, token, err := auth.Mint()
+	if err != nil {
+		h.log.Error("mint token", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "could not mint token")
+		return
+	}
+	record := &storage.TokenRecord{
+		ID:         id,
+		Name:       req.Name,
+		Scope:      scope,
+		AgentID:    req.AgentID,
+		SecretHash: auth.Hash(secret),
+		CreatedAt:  now,
+		ExpiresAt:  expires,
+	}
+	if err := h.store.PutToken(r.Context(), record); err != nil {
+		h.log.Error("create token", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.log.Info("token created", "token_id", id, "scope", scope, "agent_id", req.AgentID, "by", principalFrom(r.Context()).TokenID)
+	h.writeJSON(w, http.StatusCreated, tokenResponse{TokenRecord: redact(record), Token: token})
+}
+
+func (h *Handler) handleListTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.store.ListTokens(r.Context())
+	if err != nil {
+		h.log.Error("list tokens", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	for _, t := range tokens {
+		redact(t)
+	}
+	h.writeJSON(w, http.StatusOK, tokens)
+}
+
+// handleRotateToken replaces a token's secret. The old secret stops working immediately.
+func (h *Handler) handleRotateToken(w http.ResponseWriter, r *http.Request) {
+	record, ok := h.lookupToken(w, r)
+	if !ok {
+		return
+	}
+	if record.RevokedAt != nil {
+		h.writeError(w, http.StatusConflict, "token is revoked")
+		return
+	}
+	_, secret, _, err := auth.Mint()
+	if err != nil {
+		h.log.Error("mint token", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "could not mint token")
+		return
+	}
+	now := time.Now().UTC()
+	record.SecretHash = auth.Hash(secret)
+	record.RotatedAt = &now
+	if err := h.store.PutToken(r.Context(), record); err != nil {
+		h.log.Error("rotate token", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.log.Info("token rotated", "token_id", record.ID, "by", principalFrom(r.Context()).TokenID)
+	h.writeJSON(w, http.StatusOK, tokenResponse{TokenRecord: redact(record), Token: auth.Format(record.ID, secret)})
+}
+
+func (h *Handler) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	record, ok := h.lookupToken(w, r)
+	if !ok {
+		return
+	}
+	if record.RevokedAt == nil {
+		now := time.Now().UTC()
+		record.RevokedAt = &now
+		if err := h.store.PutToken(r.Context(), record); err != nil {
+			h.log.Error("revoke token", "error", err)
+			h.writeError(w, http.StatusInternalServerError, "storage error")
+			return
+		}
+		h.log.Info("token revoked", "token_id", record.ID, "by", principalFrom(r.Context()).TokenID)
+	}
+	h.writeJSON(w, http.StatusOK, redact(record))
+}
+
+// handleTokenSelf describes the token the request is made with, without its
+// secret, so that a client can tell what a token it holds may do. Any valid
+// token may ask; the config api_key is described as the bootstrap token.
+func (h *Handler) handleTokenSelf(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.authenticate(r)
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "invalid or missing token")
+		return
+	}
+	if p.TokenID == "bootstrap" {
+		h.writeJSON(w, http.StatusOK, &storage.TokenRecord{ID: p.TokenID, Name: "config api_key", Scope: p.Scope})
+		return
+	}
+	record, err := h.store.GetToken(r.Context(), p.TokenID)
+	if err != nil {
+		h.log.Error("get token", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, redact(record))
+}
+
+func (h *Handler) lookupToken(w http.ResponseWriter, r *http.Request) (*storage.TokenRecord, bool) {
+	record, err := h.store.GetToken(r.Context(), r.PathValue("id"))
+	if err == storage.ErrNotFound {
+		h.writeError(w, http.StatusNotFound, "token not found")
+		return nil, false
+	}
+	if err != nil {
+		h.log.Error("get token", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return nil, false
+	}
+	return record, true
+}
+
+// redact clears the secret hash before a token record leaves the tracker.
+func redact(t *storage.TokenRecord) *storage.TokenRecord {
+	t.SecretHash = ""
+	return t
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/events"
+	"github.com/farmops/farmops/cmd/tracker/internal/webhooks"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"` // empty subscribes to every event type
+	Format string   `json:"format"` // json (default), slack or discord
+	Secret string   `json:"secret"` // generated when empty
+}
+
+func (h *Handler) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		h.writeError(w, http.StatusBadRequest, "url must be an absolute http or https URL")
+		return
+	}
+	for _, t := range req.Events {
+		if !slices.Contains(events.Types, t) {
+			h.writeError(w, http.StatusBadRequest, "unknown event type: "+t)
+			return
+		}
+	}
+	format := storage.WebhookFormat(req.Format)
+	if format == "" {
+		format = storage.WebhookFormatJSON
+	}
+	if !webhooks.ValidFormat(format) {
+		h.writeError(w, http.StatusBadRequest, "format must be json, slack or discord")
+		return
+	}
+	if req.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			h.log.Error("generate webhook secret", "error", err)
+			h.writeError(w, http.StatusInternalServerError, "could not generate secret")
+			return
+		}
+		req.Secret = hex.EncodeToString(secret)
+	}
+
+	hook := &storage.Webhook{
+		ID:        uuid.Must(uuid.NewV7()).String(),
+		URL:       req.URL,
+		Events:    req.Events,
+		Format:    format,
+		Secret:    req.Secret,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := h.store.PutWebhook(r.Context(), hook); err != nil {
+		h.log.Error("create webhook", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.log.Info("webhook created", "webhook_id", hook.ID, "format", hook.Format, "events", hook.Events)
+	// The secret is only ever returned here, on creation.
+	h.writeJSON(w, http.StatusCreated, hook)
+}
+
+func (h *Handler) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.store.ListWebhooks(r.Context())
+	if err != nil {
+		h.log.Error("list webhooks", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	for _, hook := range hooks {
+		hook.Secret = ""
+	}
+	h.writeJSON(w, http.StatusOK, hooks)
+}
+
+func (h *Handler) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.store.DeleteWebhook(r.Context(), id); err == storage.ErrNotFound {
+		h.writeError(w, http.StatusNotFound, "webhook not found")
+		return
+	} else if err != nil {
+		h.log.Error("delete webhook", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.log.Info("webhook deleted", "webhook_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := h.store.ListDeliveries(r.Context(), r.PathValue("id"), queryInt(r, "limit", 50))
+	if err != nil {
+		h.log.Error("list webhook deliveries", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, deliveries)
+}
+
+func (h *Handler) handleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	letters, err := h.store.ListDeadLetters(r.Context())
+	if err != nil {
+		h.log.Error("list webhook dead letters", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "storage error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, letters)
+}
+
+func (h *Handler) handleRedeliver(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.webhooks.Redeliver(r.Context(), id); err == storage.ErrNotFound {
+		h.writeError(w, http.StatusNotFound, "dead letter or its webhook not found")
+		return
+	} else if err != nil {
+		h.log.Error("redeliver webhook", "dead_letter_id", id, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "redelivery failed")
+		return
+	}
+	h.writeJSON(w, http.StatusAccepted, map[string]string{"status": "redelivering"})
+}
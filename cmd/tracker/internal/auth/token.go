@@ -0,0 +1,111 @@
+// Package auth mints and checks the tracker's scoped API tokens.
+//
+// A token reads "fo_<id>.<secret>". The ID locates the token record in the
+// trust store; only the SHA-256 of the secret is stored, and presented
+// secrets are compared against it in constant time. Agent join tokens have
+// the same shape with the prefix "fj_".
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+const (
+	tokenPrefix = "fo_"
+	joinPrefix  = "fj_"
+)
+
+// Mint returns a new token ID and secret and the token string that joins them.
+func Mint() (id, secret, token string, err error) {
+	return mint(tokenPrefix)
+}
+
+// MintJoin is like Mint but returns an agent join token.
+func MintJoin() (id, secret, token string, err error) {
+	return mint(joinPrefix)
+}
+
+func mint(prefix string) (id, secret, token string, err error) {
+	idBytes := make([]byte, 8)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", "", fmt.Errorf("auth: generate token id: %w", err)
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", fmt.Errorf("auth: generate token secret: %w", err)
+	}
+	id, secret = hex.EncodeToString(idBytes), hex.EncodeToString(secretBytes)
+	return id, secret, prefix + id + "." + secret, nil
+}
+
+// Format joins a token ID and secret into a token string.
+func Format(id, secret string) string {
+	return tokenPrefix + id + "." + secret
+}
+
+// Parse splits a token string into its ID and secret.
+// ok is false if s is not shaped like a token.
+func Parse(s string) (id, secret string, ok bool) {
+	return parse(tokenPrefix, s)
+}
+
+// ParseJoin splits a join token string into its ID and secret.
+func ParseJoin(s string) (id, secret string, ok bool) {
+	return parse(joinPrefix, s)
+}
+
+func parse(prefix, s string) (id, secret string, ok bool) {
+	rest, found := strings.CutPrefix(s, prefix)
+	if !found {
+		return "", "", false
+	}
+	id, secret, found = strings.Cut(rest, ".")
+	if !found || id == "" || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+// Hash returns the hex SHA-256 of a token secret, as kept in the trust store.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Equal compares two secrets in constant time. Both are hashed first so that
+// the comparison does not leak their lengths.
+func Equal(a, b string) bool {
+	ha, hb := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
+
+// Valid reports whether secret matches the token record and the token is
+// neither revoked nor expired at now.
+func Valid(t *storage.TokenRecord, secret string, now time.Time) bool {
+	match := subtle.ConstantTimeCompare([]byte(Hash(secret)), []byte(t.SecretHash)) == 1
+	if !match || t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
+
+// ValidJoin reports whether secret matches the join token and the token is
+// unused, unrevoked and unexpired at now.
+func ValidJoin(t *storage.JoinToken, secret string, now time.Time) bool {
+	match := subtle.ConstantTimeCompare([]byte(Hash(secret)), []byte(t.SecretHash)) == 1
+	return match && t.UsedAt == nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// Allows reports whether a token of scope have may be used where want is required.
+// Admin tokens may do anything; agent and read tokens only what they are for.
+func Allows(have, want storage.TokenScope) bool {
+	return have == storage.TokenScopeAdmin || have == want
+}
@@ -0,0 +1,90 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/auth"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+func TestMintParse(t *testing.T) {
+	id, secret, token, err := auth.Mint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotID, gotSecret, ok := auth.Parse(token)
+	if !ok || gotID != id || gotSecret != secret {
+		t.Errorf("Parse(%q) = %q, %q, %v; want %q, %q, true", token, gotID, gotSecret, ok, id, secret)
+	}
+	if _, _, ok := auth.ParseJoin(token); ok {
+		t.Error("ParseJoin accepted an API token")
+	}
+	for _, s := range []string{"", "fo_", "fo_abc", "fo_.secret", "fo_abc.", "fj_abc.secret", "bootstrap-key"} {
+		if _, _, ok := auth.Parse(s); ok {
+			t.Errorf("Parse(%q) ok, want rejected", s)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	for _, tc := range []struct {
+		name   string
+		record storage.TokenRecord
+		secret string
+		want   bool
+	}{
+		{"valid", storage.TokenRecord{SecretHash: auth.Hash("s")}, "s", true},
+		{"wrong secret", storage.TokenRecord{SecretHash: auth.Hash("s")}, "t", false},
+		{"revoked", storage.TokenRecord{SecretHash: auth.Hash("s"), RevokedAt: &past}, "s", false},
+		{"expired", storage.TokenRecord{SecretHash: auth.Hash("s"), ExpiresAt: &past}, "s", false},
+		{"expires at now", storage.TokenRecord{SecretHash: auth.Hash("s"), ExpiresAt: &now}, "s", false},
+		{"not yet expired", storage.TokenRecord{SecretHash: auth.Hash("s"), ExpiresAt: &future}, "s", true},
+		{"rotated, old secret", storage.TokenRecord{SecretHash: auth.Hash("new"), RotatedAt: &past}, "s", false},
+		{"rotated, new secret", storage.TokenRecord{SecretHash: auth.Hash("new"), RotatedAt: &past}, "new", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := auth.Valid(&tc.record, tc.secret, now); got != tc.want {
+				t.Errorf("Valid = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidJoin(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	for _, tc := range []struct {
+		name  string
+		token storage.JoinToken
+		want  bool
+	}{
+		{"valid", storage.JoinToken{SecretHash: auth.Hash("s"), ExpiresAt: future}, true},
+		{"expired", storage.JoinToken{SecretHash: auth.Hash("s"), ExpiresAt: past}, false},
+		{"used", storage.JoinToken{SecretHash: auth.Hash("s"), ExpiresAt: future, UsedAt: &past}, false},
+		{"revoked", storage.JoinToken{SecretHash: auth.Hash("s"), ExpiresAt: future, RevokedAt: &past}, false},
+		{"wrong secret", storage.JoinToken{SecretHash: auth.Hash("t"), ExpiresAt: future}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := auth.ValidJoin(&tc.token, "s", now); got != tc.want {
+				t.Errorf("ValidJoin = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAllows(t *testing.T) {
+	scopes := []storage.TokenScope{storage.TokenScopeAgent, storage.TokenScopeRead, storage.TokenScopeAdmin}
+	for _, have := range scopes {
+		for _, want := range scopes {
+			expected := have == storage.TokenScopeAdmin || have == want
+			if got := auth.Allows(have, want); got != expected {
+				t.Errorf("Allows(%s, %s) = %v, want %v", have, want, got, expected)
+			}
+		}
+	}
+}
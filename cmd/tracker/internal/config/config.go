@@ -2,10 +2,16 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/webhooks"
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/pki"
 )
 
 // Config holds all tracker configuration.
@@ -16,9 +22,136 @@ type Config struct {
 	// DBPath is the path to the BoltDB database file.
 	DBPath string `yaml:"db_path"`
 
-	// APIKey is the shared secret agents must present to submit proofs.
+	// APIKey is the bootstrap admin credential, accepted wherever an admin
+	// token is. Use it to mint scoped tokens for agents, admins and
+	// dashboards with `farmctl token create`, then keep it out of daily use.
 	// Set via FARMOPS_API_KEY env var or directly in config.
 	APIKey string `yaml:"api_key"`
+
+	// TLS serves the API over HTTPS, optionally with client certificates.
+	TLS TLSConfig `yaml:"tls"`
+
+	// Farm tunes the farm simulation.
+	Farm FarmConfig `yaml:"farm"`
+
+	// Webhooks tunes outbound webhook delivery. Subscriptions themselves are
+	// managed through the API and kept in the store.
+	Webhooks WebhooksConfig `yaml:"webhooks"`
+}
+
+// TLSConfig configures HTTPS and mutual TLS. Leaving CertFile empty serves
+// plain HTTP.
+type TLSConfig struct {
+	// CertFile and KeyFile are the tracker's PEM certificate and key.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+
+	// ClientCAFile is the CA bundle that signs agent client certificates.
+	// When set, client certificates are verified if presented.
+	ClientCAFile string `yaml:"client_ca_file"`
+
+	// RequireClientCert rejects connections without a valid client certificate.
+	RequireClientCert bool `yaml:"require_client_cert"`
+
+	// BindAgentIdentity requires the client certificate's common name to
+	// match the agent_id of every submitted proof.
+	BindAgentIdentity bool `yaml:"bind_agent_identity"`
+}
+
+// Enabled reports whether the tracker should serve TLS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// ServerConfig builds the server TLS config.
+func (c TLSConfig) ServerConfig() (*tls.Config, error) {
+	pair, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("config: load tls certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{pair},
+	}
+	if c.ClientCAFile != "" {
+		pool, err := pki.LoadCertPool(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		if c.RequireClientCert {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return cfg, nil
+}
+
+// FarmConfig tunes farm decay. Zero durations keep the defaults from
+// farm.DefaultRules; negative durations disable the corresponding process.
+type FarmConfig struct {
+	// WiltAfter is how long a category may stay idle before its crops wilt (e.g. "72h").
+	WiltAfter time.Duration `yaml:"wilt_after"`
+
+	// UpkeepInterval is how often buildings charge upkeep (e.g. "168h").
+	UpkeepInterval time.Duration `yaml:"upkeep_interval"`
+
+	// DecayInterval is how often the tracker applies decay in the background.
+	// Zero applies decay lazily, on the next read or write of the farm.
+	DecayInterval time.Duration `yaml:"decay_interval"`
+}
+
+// Rules returns the farm rules with the configured overrides applied.
+func (c FarmConfig) Rules() farm.Rules {
+	rules := farm.DefaultRules()
+	rules.WiltAfter = override(rules.WiltAfter, c.WiltAfter)
+	rules.UpkeepInterval = override(rules.UpkeepInterval, c.UpkeepInterval)
+	return rules
+}
+
+func override(def, v time.Duration) time.Duration {
+	switch {
+	case v < 0:
+		return 0
+	case v > 0:
+		return v
+	default:
+		return def
+	}
+}
+
+// WebhooksConfig tunes webhook retries. Zero values keep the defaults from
+// webhooks.DefaultPolicy.
+type WebhooksConfig struct {
+	// MaxAttempts is how many times a delivery is tried before it is dead-lettered.
+	MaxAttempts int `yaml:"max_attempts"`
+
+	// InitialBackoff is the wait before the first retry; it doubles on each retry (e.g. "5s").
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+
+	// MaxBackoff caps the wait between retries (e.g. "10m").
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+
+	// Timeout bounds each delivery attempt (e.g. "10s").
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Policy returns the retry policy with the configured overrides applied.
+func (c WebhooksConfig) Policy() webhooks.Policy {
+	p := webhooks.DefaultPolicy()
+	if c.MaxAttempts > 0 {
+		p.MaxAttempts = c.MaxAttempts
+	}
+	if c.InitialBackoff > 0 {
+		p.InitialBackoff = c.InitialBackoff
+	}
+	if c.MaxBackoff > 0 {
+		p.MaxBackoff = c.MaxBackoff
+	}
+	if c.Timeout > 0 {
+		p.Timeout = c.Timeout
+	}
+	return p
 }
 
 // Load reads and validates the tracker config from a YAML file.
@@ -57,5 +190,14 @@ func (c *Config) validate() error {
 	if c.APIKey == "" {
 		return fmt.Errorf("config: api_key is required (or set FARMOPS_API_KEY)")
 	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("config: tls.cert_file and tls.key_file must be set together")
+	}
+	if (c.TLS.RequireClientCert || c.TLS.BindAgentIdentity) && c.TLS.ClientCAFile == "" {
+		return fmt.Errorf("config: tls.client_ca_file is required to verify client certificates")
+	}
+	if c.TLS.ClientCAFile != "" && !c.TLS.Enabled() {
+		return fmt.Errorf("config: tls.client_ca_file needs tls.cert_file and tls.key_file")
+	}
 	return nil
 }
@@ -0,0 +1,158 @@
+// Package events is the tracker's in-process event bus. Handlers and the
+// projection engine publish notifications about proofs, coins, agents and the
+// farm; live streams (SSE, WebSocket) subscribe to them. The bus keeps a short
+// history so that reconnecting clients can resume from the last event they saw.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published by the tracker.
+const (
+	TypeProofAccepted       = "proof_accepted"
+	TypeCoinsAwarded        = "coins_awarded"
+	TypeAgentEnrolled       = "agent_enrolled"
+	TypeUpgradePurchased    = "upgrade_purchased"
+	TypeAchievementUnlocked = "achievement_unlocked"
+	TypeStreakBroken        = "streak_broken"
+	TypeRewardReversed      = "reward_reversed"
+)
+
+// Types lists every event type the tracker publishes.
+var Types = []string{
+	TypeProofAccepted,
+	TypeCoinsAwarded,
+	TypeAgentEnrolled,
+	TypeUpgradePurchased,
+	TypeAchievementUnlocked,
+	TypeStreakBroken,
+	TypeRewardReversed,
+}
+
+// DefaultHistory is the number of events kept for resuming subscribers.
+const DefaultHistory = 256
+
+// subscriberBuffer is how many events a subscriber may fall behind before the
+// bus drops it. A dropped subscriber reconnects and resumes from history.
+const subscriberBuffer = 64
+
+// Event is a notification published on the bus.
+type Event struct {
+	ID   uint64    `json:"id"`
+	Type string    `json:"type"`
+	At   time.Time `json:"at"`
+	Data any       `json:"data"`
+}
+
+// Bus fans published events out to subscribers.
+type Bus struct {
+	mu      sync.Mutex
+	lastID  uint64
+	history []Event // ring buffer of the most recent events
+	next    int     // index in history the next event is written to
+	full    bool
+	subs    map[chan Event]struct{}
+	closed  bool
+}
+
+// NewBus creates a Bus that keeps the last history events for resumption.
+func NewBus(history int) *Bus {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &Bus{
+		// IDs start from the boot time so that they keep increasing across
+		// tracker restarts and a stale Last-Event-ID never skips new events.
+		lastID:  uint64(time.Now().UnixMicro()),
+		history: make([]Event, history),
+		subs:    map[chan Event]struct{}{},
+	}
+}
+
+// Publish stamps data as an event of the given type and delivers it to every
+// subscriber. Publishing on a nil Bus is a no-op.
+func (b *Bus) Publish(typ string, data any) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	ev := Event{ID: b.lastID, Type: typ, At: time.Now().UTC(), Data: data}
+	b.history[b.next] = ev
+	b.next = (b.next + 1) % len(b.history)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe registers a subscriber. If after is non-zero, the events after
+// that ID still held in history are returned as the backlog. The returned
+// channel is closed when the subscriber falls too far behind or the bus is
+// closed; cancel must be called once the subscriber is done.
+func (b *Bus) Subscribe(after uint64) (backlog []Event, ch <-chan Event, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := make(chan Event, subscriberBuffer)
+	if b.closed {
+		close(c)
+		return nil, c, func() {}
+	}
+	if after > 0 {
+		backlog = b.since(after)
+	}
+	b.subs[c] = struct{}{}
+
+	return backlog, c, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[c]; ok {
+			delete(b.subs, c)
+			close(c)
+		}
+	}
+}
+
+// Close disconnects every subscriber and stops accepting events.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// since returns the events in history with IDs greater than after, oldest
+// first. Callers must hold b.mu.
+func (b *Bus) since(after uint64) []Event {
+	start, n := 0, b.next
+	if b.full {
+		start, n = b.next, len(b.history)
+	}
+	var out []Event
+	for i := 0; i < n; i++ {
+		ev := b.history[(start+i)%len(b.history)]
+		if ev.ID > after {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package events
+
+import "time"
+
+// ProofAccepted is the payload of a proof_accepted event.
+type ProofAccepted struct {
+	ProofID      string `json:"proof_id"`
+	AgentID      string `json:"agent_id"`
+	ClusterAlias string `json:"cluster_alias"`
+	Category     string `json:"category"`
+	Description  string `json:"description"`
+	Status       string `json:"status"`
+	Coins        int    `json:"coins"`
+}
+
+// CoinsAwarded is the payload of a coins_awarded event.
+type CoinsAwarded struct {
+	ProofID    string `json:"proof_id"`
+	AgentID    string `json:"agent_id"`
+	Category   string `json:"category"`
+	Coins      int    `json:"coins"`
+	Balance    int    `json:"balance"`
+	TotalCoins int    `json:"total_coins"`
+}
+
+// AgentEnrolled is the payload of an agent_enrolled event.
+type AgentEnrolled struct {
+	AgentID      string `json:"agent_id"`
+	ClusterAlias string `json:"cluster_alias"`
+	Status       string `json:"status"`
+}
+
+// UpgradePurchased is the payload of an upgrade_purchased event.
+type UpgradePurchased struct {
+	Item     string `json:"item"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Level    int    `json:"level"`
+	Cost     int    `json:"cost"`
+	Balance  int    `json:"balance"`
+}
+
+// AchievementUnlocked is the payload of an achievement_unlocked event.
+type AchievementUnlocked struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// StreakBroken is the payload of a streak_broken event.
+type StreakBroken struct {
+	Days         int       `json:"days"` // length of the streak that was lost
+	LastActiveAt time.Time `json:"last_active_at"`
+}
+
+// RewardReversed is the payload of a reward_reversed event. Coins is the
+// amount taken back.
+type RewardReversed struct {
+	ProofID    string `json:"proof_id"`
+	AgentID    string `json:"agent_id"`
+	Category   string `json:"category"`
+	Coins      int    `json:"coins"`
+	Balance    int    `json:"balance"`
+	TotalCoins int    `json:"total_coins"`
+}
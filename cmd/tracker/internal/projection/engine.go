@@ -0,0 +1,352 @@
+// Package projection maintains the farm world as a materialized projection of
+// the coin ledger. All writes to the farm go through the Engine, which appends
+// ledger events and folds them into the stored world under a single lock so
+// that rule checks (balance, plot state) and the resulting events stay consistent.
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/events"
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/scoring"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// Engine projects the farm world from the ledger.
+type Engine struct {
+	store storage.Store
+	rules farm.Rules
+	now   func() time.Time
+	bus   *events.Bus
+	mu    sync.Mutex
+}
+
+// New creates an Engine over the given store. now is the clock used to
+// timestamp commands and apply decay; nil means time.Now. Coins, purchases
+// and achievements recorded by the engine are published on bus, which may be nil.
+func New(store storage.Store, rules farm.Rules, now func() time.Time, bus *events.Bus) *Engine {
+	if now == nil {
+		now = time.Now
+	}
+	return &Engine{store: store, rules: rules, now: now, bus: bus}
+}
+
+// Rules returns the farm rules the engine projects with.
+func (e *Engine) Rules() farm.Rules {
+	return e.rules
+}
+
+// Now returns the engine's current time in UTC.
+func (e *Engine) Now() time.Time {
+	return e.now().UTC()
+}
+
+// World returns the current farm world, catching up on any ledger events the
+// stored projection has not seen yet and applying decay that has fallen due.
+func (e *Engine) World(ctx context.Context) (*farm.World, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	w, err := e.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.decay(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Decay records the wilt and upkeep events that have fallen due and returns
+// them. Reads and commands decay lazily as well; Decay lets a scheduler do it
+// eagerly so that decay events reach the ledger even when nobody is looking.
+func (e *Engine) Decay(ctx context.Context) ([]*farm.Event, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	w, err := e.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return e.decay(ctx, w)
+}
+
+// Execute runs cmd against the current world and records the events it
+// returns. cmd must not mutate the world it is given.
+func (e *Engine) Execute(ctx context.Context, cmd func(w *farm.World) ([]*farm.Event, error)) (*farm.World, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	w, err := e.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.decay(ctx, w); err != nil {
+		return nil, err
+	}
+	events, err := cmd(w)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.record(ctx, w, events); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Record appends events to the ledger unconditionally and returns the updated world.
+func (e *Engine) Record(ctx context.Context, events ...*farm.Event) (*farm.World, error) {
+	return e.Execute(ctx, func(*farm.World) ([]*farm.Event, error) { return events, nil })
+}
+
+// AppendProof appends a scored proof to the chain and, in the same store
+// transaction, the ledger events it earned, so that an accepted proof never
+// goes without its reward. It returns the updated world,
+// storage.ErrDuplicateProof if the proof is already in the chain, or
+// storage.ErrChainConflict if another proof took its place at the head of the
+// agent's chain first, or storage.ErrKeyRotated if the agent's key was
+// rotated since the proof was verified.
+func (e *Engine) AppendProof(ctx context.Context, p *proof.FarmProof, score scoring.Result, ledger ...*farm.Event) (*farm.World, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	w, err := e.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.decay(ctx, w); err != nil {
+		return nil, err
+	}
+	if err := e.store.AppendProof(ctx, p, score, ledger...); err != nil {
+		if errors.Is(err, storage.ErrDuplicateProof) || errors.Is(err, storage.ErrChainConflict) ||
+			errors.Is(err, storage.ErrKeyRotated) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("projection: append proof: %w", err)
+	}
+	if err := e.apply(ctx, w, ledger); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Rebuild discards the stored world and replays the whole ledger.
+func (e *Engine) Rebuild(ctx context.Context) (*farm.World, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.openLedger(ctx); err != nil {
+		return nil, err
+	}
+	events, err := e.store.ListEvents(ctx, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("projection: list ledger: %w", err)
+	}
+	w := farm.Replay(events, e.rules)
+	if err := e.save(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Revoke stops trusting an agent and takes back the rewards of the given
+// proofs. The store marks the agent revoked, invalidates the proofs and
+// appends a compensating reward_reversed event for each reward not yet
+// reversed in one transaction; the engine then replays the ledger so that
+// streaks, crops, category stats and achievements no longer count them.
+// Coins already spent are not refunded, so the farm may be left in debt; see
+// farm.Replay. It returns the reversals.
+func (e *Engine) Revoke(ctx context.Context, agentID string, proofIDs []string, reason string) ([]*farm.Event, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, err := e.current(ctx); err != nil {
+		return nil, err
+	}
+	reversals, err := e.store.RevokeAgent(ctx, agentID, proofIDs, reason, e.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("projection: revoke agent: %w", err)
+	}
+	ledger, err := e.store.ListEvents(ctx, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("projection: list ledger: %w", err)
+	}
+
+	w := farm.Replay(ledger, e.rules)
+	if err := e.save(ctx, w); err != nil {
+		return nil, err
+	}
+	for _, rev := range reversals {
+		e.bus.Publish(events.TypeRewardReversed, events.RewardReversed{
+			ProofID:    rev.ProofID,
+			AgentID:    rev.AgentID,
+			Category:   rev.Category,
+			Coins:      -rev.Coins,
+			Balance:    w.Balance,
+			TotalCoins: w.TotalCoins,
+		})
+	}
+	return reversals, nil
+}
+
+// current loads the stored world and applies any newer ledger events.
+// Callers must hold e.mu.
+func (e *Engine) current(ctx context.Context) (*farm.World, error) {
+	w, err := e.store.GetWorld(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("projection: get world: %w", err)
+	}
+	if w == nil {
+		w = farm.NewWorld(e.rules)
+		if err := e.openLedger(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := e.store.ListEvents(ctx, w.LastSeq, 0)
+	if err != nil {
+		return nil, fmt.Errorf("projection: list ledger: %w", err)
+	}
+	if len(events) == 0 {
+		return w, nil
+	}
+	for _, ev := range events {
+		w.Apply(ev, e.rules)
+	}
+	if err := e.save(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// decay records the decay events due by now into w. Callers must hold e.mu.
+func (e *Engine) decay(ctx context.Context, w *farm.World) ([]*farm.Event, error) {
+	events := w.Decay(e.rules, e.Now())
+	if err := e.record(ctx, w, events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// openLedger carries coins earned before the ledger existed into it, so that
+// farms created by older trackers keep their balance.
+func (e *Engine) openLedger(ctx context.Context) error {
+	existing, err := e.store.ListEvents(ctx, 0, 1)
+	if err != nil {
+		return fmt.Errorf("projection: list ledger: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+	state, err := e.store.GetFarm(ctx)
+	if err != nil {
+		return fmt.Errorf("projection: get farm: %w", err)
+	}
+	if state.TotalCoins == 0 {
+		return nil
+	}
+	return e.store.AppendEvent(ctx, &farm.Event{
+		Type:  farm.EventOpeningBalance,
+		At:    state.UpdatedAt,
+		Coins: state.TotalCoins,
+	})
+}
+
+// record appends events and applies them to w. Callers must hold e.mu.
+func (e *Engine) record(ctx context.Context, w *farm.World, ledger []*farm.Event) error {
+	for _, ev := range ledger {
+		if err := e.store.AppendEvent(ctx, ev); err != nil {
+			return fmt.Errorf("projection: append %s event: %w", ev.Type, err)
+		}
+	}
+	return e.apply(ctx, w, ledger)
+}
+
+// apply folds events already in the ledger into w, saves it and publishes
+// what changed. Callers must hold e.mu.
+func (e *Engine) apply(ctx context.Context, w *farm.World, ledger []*farm.Event) error {
+	if len(ledger) == 0 {
+		return nil
+	}
+	type notice struct {
+		typ  string
+		data any
+	}
+	var notices []notice
+	for _, ev := range ledger {
+		unlocked, streak, lastActive := len(w.Achievements), w.StreakDays, w.LastActiveAt
+		w.Apply(ev, e.rules)
+
+		switch ev.Type {
+		case farm.EventProofReward:
+			notices = append(notices, notice{events.TypeCoinsAwarded, events.CoinsAwarded{
+				ProofID:    ev.ProofID,
+				AgentID:    ev.AgentID,
+				Category:   ev.Category,
+				Coins:      ev.Coins,
+				Balance:    w.Balance,
+				TotalCoins: w.TotalCoins,
+			}})
+		case farm.EventPurchase:
+			if b := w.Building(ev.Item); b != nil {
+				notices = append(notices, notice{events.TypeUpgradePurchased, events.UpgradePurchased{
+					Item:     b.Slug,
+					Name:     b.Name,
+					Category: b.Category,
+					Level:    b.Level,
+					Cost:     -ev.Coins,
+					Balance:  w.Balance,
+				}})
+			}
+		case farm.EventStreakBroken:
+			if lastActive != nil {
+				notices = append(notices, notice{events.TypeStreakBroken, events.StreakBroken{
+					Days:         streak,
+					LastActiveAt: *lastActive,
+				}})
+			}
+		}
+		for _, a := range w.Achievements[unlocked:] {
+			notices = append(notices, notice{events.TypeAchievementUnlocked, events.AchievementUnlocked{
+				ID:          a.ID,
+				Name:        a.Name,
+				Description: a.Description,
+			}})
+		}
+	}
+	if err := e.save(ctx, w); err != nil {
+		return err
+	}
+	for _, n := range notices {
+		e.bus.Publish(n.typ, n.data)
+	}
+	return nil
+}
+
+// save stores the world and mirrors its balances into the farm state.
+func (e *Engine) save(ctx context.Context, w *farm.World) error {
+	if err := e.store.PutWorld(ctx, w); err != nil {
+		return fmt.Errorf("projection: put world: %w", err)
+	}
+	state, err := e.store.GetFarm(ctx)
+	if err != nil {
+		return fmt.Errorf("projection: get farm: %w", err)
+	}
+	state.TotalCoins = w.TotalCoins
+	state.CurrentCoins = w.Balance
+	state.StreakDays = w.StreakDays
+	state.LastActiveAt = w.LastActiveAt
+	if err := e.store.UpdateFarm(ctx, state); err != nil {
+		return fmt.Errorf("projection: update farm: %w", err)
+	}
+	return nil
+}
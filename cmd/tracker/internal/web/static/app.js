@@ -0,0 +1,256 @@
+// FarmOps dashboard. Reads everything from the tracker's REST API and
+// refreshes whenever the live event stream reports a change, falling back to
+// periodic polling. The token entered in the header is kept in
+// sessionStorage and sent with every request: a read token shows agents,
+// proofs and the live stream, and approvals need an admin token.
+"use strict";
+
+const API = "../api/v1";
+const TOKEN_KEY = "farmops-token";
+const REFRESH_MS = 15000;
+const LIVE_DEBOUNCE_MS = 500;
+const STREAM_TYPES = ["proof_accepted", "coins_awarded", "agent_enrolled", "upgrade_purchased", "achievement_unlocked", "streak_broken", "reward_reversed"];
+
+const CATEGORY_COLORS = {
+  maintenance: "#7cb342",
+  toil: "#fbc02d",
+  reliability: "#29b6f6",
+  security: "#8e24aa",
+  incident: "#e53935",
+  upgrade: "#fb8c00",
+};
+
+const fmt = new Intl.NumberFormat();
+
+function token() {
+  return sessionStorage.getItem(TOKEN_KEY) || "";
+}
+
+async function api(path, options = {}) {
+  const headers = Object.assign({}, options.headers);
+  if (token()) {
+    headers["Authorization"] = "Bearer " + token();
+  }
+  const resp = await fetch(API + path, Object.assign({}, options, { headers }));
+  const body = await resp.json().catch(() => ({}));
+  if (!resp.ok) {
+    throw new Error(body.error || resp.statusText);
+  }
+  return body;
+}
+
+// el builds a DOM element; children may be strings or elements.
+function el(tag, attrs = {}, ...children) {
+  const node = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs)) {
+    if (k === "onclick") node.onclick = v;
+    else node.setAttribute(k, v);
+  }
+  for (const c of children) {
+    node.append(c instanceof Node ? c : document.createTextNode(String(c)));
+  }
+  return node;
+}
+
+function when(ts) {
+  if (!ts || ts.startsWith("0001-")) return "—";
+  return new Date(ts).toLocaleString();
+}
+
+function fill(selector, rows) {
+  const tbody = document.querySelector(selector + " tbody");
+  tbody.replaceChildren(...rows);
+}
+
+function showError(selector, err) {
+  const section = document.querySelector(selector);
+  let msg = section.querySelector(".error");
+  if (!msg) {
+    msg = el("p", { class: "error" });
+    section.append(msg);
+  }
+  msg.textContent = err ? err.message : "";
+}
+
+async function loadWorld() {
+  const world = await api("/farm/world");
+  document.getElementById("summary").textContent =
+    `${fmt.format(world.balance)} coins · ${fmt.format(world.total_coins)} earned · ${world.streak_days}-day streak`;
+
+  const chart = document.getElementById("category-chart");
+  const cats = Object.entries(world.categories || {}).sort((a, b) => b[1].coins - a[1].coins);
+  const top = cats.length ? cats[0][1].coins : 1;
+  chart.replaceChildren(...cats.map(([name, s]) =>
+    el("div", { class: "bar-row" },
+      el("span", { class: "label" }, name),
+      el("span", { class: "bar", style: `width:${Math.max(2, 60 * s.coins / top)}%;background:${CATEGORY_COLORS[name] || "#bdbdbd"}` }),
+      el("span", { class: "value" }, `${fmt.format(s.coins)}c · ${s.proofs} proofs`))));
+  if (!cats.length) chart.replaceChildren(el("p", { class: "breakdown" }, "No rewarded proofs yet."));
+
+  document.getElementById("farm-image").src = `${API}/farm/render.svg?seq=${world.last_seq}`;
+  return world;
+}
+
+async function loadAgents() {
+  const agents = await api("/agents");
+  fill("#agents", (agents || []).map((a) => {
+    const action = a.Status === "pending"
+      ? el("button", { onclick: () => approve(a.AgentID) }, "Approve")
+      : "";
+    return el("tr", {},
+      el("td", {}, a.AgentID),
+      el("td", {}, a.ClusterAlias),
+      el("td", {}, el("span", { class: "status " + a.Status }, a.Status)),
+      el("td", {}, when(a.EnrolledAt)),
+      el("td", {}, action));
+  }));
+}
+
+async function approve(agentID) {
+  if (!token()) {
+    showError("#agents", new Error("Enter an admin token in the header to approve agents."));
+    return;
+  }
+  try {
+    await api(`/agents/${encodeURIComponent(agentID)}/approve`, { method: "POST" });
+    showError("#agents", null);
+    await loadAgents();
+  } catch (err) {
+    showError("#agents", err);
+  }
+}
+
+function breakdown(s) {
+  if (!s || !s.TotalCoins) return "";
+  const m = (v) => "×" + Number(v).toFixed(2);
+  return `${s.BaseCoins} base ${m(s.ComplexityMult)} complexity ${m(s.ImpactMult)} impact ${m(s.StreakMult)} streak ${m(s.UpgradeMult)} upgrades`;
+}
+
+async function loadProofs() {
+  const proofs = await api("/proofs?limit=25");
+  fill("#proofs", proofs.map((p) =>
+    el("tr", {},
+      el("td", {}, when(p.ReceivedAt)),
+      el("td", {}, p.agent.cluster_alias),
+      el("td", {}, p.action.category),
+      el("td", {}, p.action.description),
+      el("td", { class: "num" }, fmt.format(p.CoinsAwarded)),
+      el("td", { class: "breakdown" }, breakdown(p.Scoring)))));
+}
+
+function ledgerDetail(e) {
+  return [e.category, e.item, e.plot ? "plot " + e.plot : "", e.proof_id].filter(Boolean).join(" · ");
+}
+
+async function loadLedger(world) {
+  const after = Math.max(0, world.last_seq - 50);
+  const events = await api(`/ledger?after=${after}&limit=50`);
+  fill("#ledger", events.reverse().map((e) =>
+    el("tr", {},
+      el("td", { class: "num" }, e.seq),
+      el("td", {}, when(e.at)),
+      el("td", {}, e.type.replace(/_/g, " ")),
+      el("td", {}, ledgerDetail(e)),
+      el("td", { class: "num" + (e.coins < 0 ? " neg" : "") }, fmt.format(e.coins)))));
+}
+
+// guarded runs a panel loader, reporting its failure in the panel itself so
+// that a missing read token does not blank the public panels.
+async function guarded(selector, load) {
+  try {
+    await load();
+    showError(selector, null);
+  } catch (err) {
+    showError(selector, err);
+  }
+}
+
+async function refresh() {
+  await guarded("#farm", async () => {
+    const world = await loadWorld();
+    await loadLedger(world);
+  });
+  await Promise.all([guarded("#agents", loadAgents), guarded("#proofs", loadProofs)]);
+}
+
+document.getElementById("auth").addEventListener("submit", (ev) => {
+  ev.preventDefault();
+  const input = document.getElementById("token");
+  sessionStorage.setItem(TOKEN_KEY, input.value);
+  input.value = "";
+  input.placeholder = token() ? "Token saved" : "Read or admin token";
+  refresh();
+  connectStream();
+});
+
+// --- Live stream ---
+
+let audio = null;
+
+// ding plays a short two-note chime. Browsers only allow audio after a user
+// gesture, so the bell button has to be clicked once on the team TV.
+function ding() {
+  if (!audio) return;
+  const now = audio.currentTime;
+  [880, 1318.5].forEach((freq, i) => {
+    const osc = audio.createOscillator();
+    const gain = audio.createGain();
+    osc.frequency.value = freq;
+    gain.gain.setValueAtTime(0.3, now + i * 0.15);
+    gain.gain.exponentialRampToValueAtTime(0.001, now + i * 0.15 + 0.8);
+    osc.connect(gain).connect(audio.destination);
+    osc.start(now + i * 0.15);
+    osc.stop(now + i * 0.15 + 0.8);
+  });
+}
+
+function toast(text) {
+  const node = el("div", { class: "toast" }, text);
+  document.body.append(node);
+  setTimeout(() => node.remove(), 6000);
+}
+
+let pending = null;
+function scheduleRefresh() {
+  clearTimeout(pending);
+  pending = setTimeout(refresh, LIVE_DEBOUNCE_MS);
+}
+
+let stream = null;
+
+function connectStream() {
+  const live = document.getElementById("live");
+  if (stream) stream.close();
+  // EventSource cannot send headers, so the token goes in the query string.
+  stream = new EventSource(API + "/events?access_token=" + encodeURIComponent(token()));
+  stream.onopen = () => live.classList.add("on");
+  stream.onerror = () => live.classList.remove("on");
+  for (const type of STREAM_TYPES) {
+    stream.addEventListener(type, (msg) => {
+      const data = JSON.parse(msg.data);
+      if (type === "proof_accepted" && data.category === "incident" && data.status === "success") {
+        ding();
+        toast(`🔔 Incident closed on ${data.cluster_alias}: ${data.description}`);
+      }
+      if (type === "achievement_unlocked") toast(`🏆 ${data.name} — ${data.description}`);
+      scheduleRefresh();
+    });
+  }
+}
+
+document.getElementById("bell").addEventListener("click", (ev) => {
+  if (!audio) {
+    audio = new AudioContext();
+    ev.target.classList.add("on");
+    ding();
+  } else {
+    audio.close();
+    audio = null;
+    ev.target.classList.remove("on");
+  }
+});
+
+if (token()) document.getElementById("token").placeholder = "Token saved";
+refresh();
+connectStream();
+setInterval(refresh, REFRESH_MS);
@@ -0,0 +1,24 @@
+// Package web embeds the tracker's read-only dashboard. The dashboard is a
+// static single page that talks to the tracker's own REST API with the token
+// entered in its header: a read token shows agents, proofs and the live
+// stream, and the only write it performs, approving pending agents, needs an
+// admin token.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard assets. Mount it with the URL prefix stripped.
+func Handler() http.Handler {
+	sub, err := fs.Sub(static, "static")
+	if err != nil {
+		panic("web: " + err.Error()) // static is embedded at build time
+	}
+	return http.FileServer(http.FS(sub))
+}
@@ -0,0 +1,386 @@
+// Package webhooks delivers tracker events to outbound webhook subscriptions.
+// The Dispatcher subscribes to the event bus, renders each event in the
+// subscription's format, signs it with HMAC-SHA256 and POSTs it, retrying
+// failures with exponential backoff. Each delivery is written to the outbox
+// before its first attempt and stays there until it succeeds, so retries
+// resume after a restart. Every attempt is written to the delivery log;
+// events that exhaust their retries are moved to the dead-letter list, from
+// where they can be redelivered.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/events"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// Request headers set on every delivery. HeaderDelivery is the same on every
+// attempt of a delivery, so receivers can drop retries they already handled;
+// HeaderEventID is the same for every webhook the event goes to.
+const (
+	HeaderEvent     = "X-FarmOps-Event"
+	HeaderEventID   = "X-FarmOps-Event-ID"
+	HeaderDelivery  = "X-FarmOps-Delivery"
+	HeaderTimestamp = "X-FarmOps-Timestamp"
+	HeaderSignature = "X-FarmOps-Signature"
+)
+
+// Policy controls how deliveries are retried.
+type Policy struct {
+	MaxAttempts    int           // attempts before an event is dead-lettered
+	InitialBackoff time.Duration // wait before the first retry; doubles on each retry
+	MaxBackoff     time.Duration // upper bound on the wait between retries
+	Timeout        time.Duration // per-attempt HTTP timeout
+}
+
+// DefaultPolicy returns the retry policy used when none is configured.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:    6,
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     10 * time.Minute,
+		Timeout:        10 * time.Second,
+	}
+}
+
+// Backoff returns the wait before the given retry (1-based).
+func (p Policy) Backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < retry && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxBackoff)
+}
+
+// Dispatcher delivers bus events to webhook subscriptions.
+type Dispatcher struct {
+	store  storage.WebhookStore
+	policy Policy
+	client *http.Client
+	log    *slog.Logger
+	retry  chan redelivery
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	active map[string]bool // IDs of the outbox entries being delivered
+}
+
+// redelivery is a dead letter handed back to Run for another set of attempts.
+type redelivery struct {
+	hook *storage.Webhook
+	ev   events.Event
+	ob   *storage.OutboxDelivery
+}
+
+// New creates a Dispatcher that reads subscriptions from store.
+func New(store storage.WebhookStore, policy Policy, log *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		policy: policy,
+		client: &http.Client{Timeout: policy.Timeout},
+		log:    log,
+		retry:  make(chan redelivery),
+		active: map[string]bool{},
+	}
+}
+
+// Run resumes the deliveries left in the outbox, then delivers events
+// published on bus until ctx is cancelled, and finally waits for in-flight
+// attempts to finish. Deliveries still waiting to be retried when ctx is
+// cancelled stay in the outbox for the next Run.
+func (d *Dispatcher) Run(ctx context.Context, bus *events.Bus) {
+	defer d.wg.Wait()
+
+	d.resume(ctx)
+	var lastID uint64
+	for {
+		backlog, ch, cancel := bus.Subscribe(lastID)
+		for _, ev := range backlog {
+			d.dispatch(ctx, ev)
+			lastID = ev.ID
+		}
+	stream:
+		for {
+			select {
+			case <-ctx.Done():
+				cancel()
+				return
+			case ev, ok := <-ch:
+				if !ok {
+					break stream
+				}
+				d.dispatch(ctx, ev)
+				lastID = ev.ID
+			case r := <-d.retry:
+				d.start(ctx, r.hook, r.ev, r.ob)
+			}
+		}
+		cancel()
+		// The bus dropped us for falling behind (or was closed); resubscribe
+		// from the last event seen to pick up the rest from its history.
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// Redeliver retries a dead letter with a fresh set of attempts. The dead
+// letter moves to the outbox and is handed to Run; if the retries fail again
+// it returns to the list under a new ID.
+func (d *Dispatcher) Redeliver(ctx context.Context, id string) error {
+	dl, err := d.store.GetDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+	hook, err := d.store.GetWebhook(ctx, dl.WebhookID)
+	if err != nil {
+		return err
+	}
+	var ev events.Event
+	if err := json.Unmarshal(dl.Payload, &ev); err != nil {
+		return fmt.Errorf("webhooks: decode dead letter %s: %w", id, err)
+	}
+	ob := newOutbox(hook, ev, dl.Payload)
+	if err := d.store.PutOutbox(ctx, ob); err != nil {
+		return err
+	}
+	if err := d.store.DeleteDeadLetter(ctx, id); err != nil {
+		return err
+	}
+	// Once in the outbox the delivery is resumed by the next Run even if
+	// this one is shutting down.
+	select {
+	case d.retry <- redelivery{hook: hook, ev: ev, ob: ob}:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// resume restarts the deliveries a previous Run left in the outbox.
+func (d *Dispatcher) resume(ctx context.Context) {
+	pending, err := d.store.ListOutbox(ctx)
+	if err != nil {
+		d.log.Error("list webhook outbox", "error", err)
+		return
+	}
+	for _, ob := range pending {
+		hook, err := d.store.GetWebhook(ctx, ob.WebhookID)
+		if err == storage.ErrNotFound {
+			// The subscription was deleted; nobody is waiting for it.
+			d.dropOutbox(ob)
+			continue
+		}
+		if err != nil {
+			d.log.Error("get webhook", "webhook_id", ob.WebhookID, "error", err)
+			continue
+		}
+		var ev events.Event
+		if err := json.Unmarshal(ob.Payload, &ev); err != nil {
+			d.log.Error("decode webhook outbox entry", "id", ob.ID, "error", err)
+			continue
+		}
+		d.log.Info("resuming webhook delivery", "webhook_id", hook.ID, "event_id", ev.ID, "attempts", ob.Attempts)
+		d.start(ctx, hook, ev, ob)
+	}
+}
+
+// dispatch starts a delivery of ev to every subscription that wants it.
+func (d *Dispatcher) dispatch(ctx context.Context, ev events.Event) {
+	hooks, err := d.store.ListWebhooks(ctx)
+	if err != nil {
+		d.log.Error("list webhooks", "error", err)
+		return
+	}
+	envelope, err := json.Marshal(ev)
+	if err != nil {
+		d.log.Error("encode webhook event", "event_id", ev.ID, "error", err)
+		return
+	}
+	for _, hook := range hooks {
+		if len(hook.Events) > 0 && !slices.Contains(hook.Events, ev.Type) {
+			continue
+		}
+		ob := newOutbox(hook, ev, envelope)
+		if err := d.store.PutOutbox(ctx, ob); err != nil {
+			// Still try; the delivery just won't survive a restart.
+			d.log.Error("store webhook outbox entry", "webhook_id", hook.ID, "event_id", ev.ID, "error", err)
+		}
+		d.start(ctx, hook, ev, ob)
+	}
+}
+
+// newOutbox returns a fresh outbox entry for delivering ev to hook.
+func newOutbox(hook *storage.Webhook, ev events.Event, envelope []byte) *storage.OutboxDelivery {
+	now := time.Now().UTC()
+	return &storage.OutboxDelivery{
+		ID:        uuid.Must(uuid.NewV7()).String(),
+		WebhookID: hook.ID,
+		EventID:   ev.ID,
+		EventType: ev.Type,
+		Payload:   envelope,
+		NextAt:    now,
+		CreatedAt: now,
+	}
+}
+
+// start delivers ev to hook in the background, unless ob is already being
+// delivered, e.g. because resume found a redelivery in the outbox.
+func (d *Dispatcher) start(ctx context.Context, hook *storage.Webhook, ev events.Event, ob *storage.OutboxDelivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.active[ob.ID] {
+		return
+	}
+	d.active[ob.ID] = true
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.deliver(ctx, hook, ev, ob)
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.active, ob.ID)
+	}()
+}
+
+// deliver POSTs ev to hook until it succeeds or the policy gives up, logging
+// every attempt and keeping ob up to date so that a later Run can pick up
+// where this one stopped.
+func (d *Dispatcher) deliver(ctx context.Context, hook *storage.Webhook, ev events.Event, ob *storage.OutboxDelivery) {
+	body, err := Render(hook.Format, ev)
+	if err != nil {
+		ob.LastError = err.Error()
+		d.deadLetter(hook, ob)
+		return
+	}
+
+	for ob.Attempts < d.policy.MaxAttempts {
+		if wait := time.Until(ob.NextAt); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		start := time.Now()
+		status, err := d.post(hook, ev, ob, body)
+		ob.Attempts++
+		rec := &storage.WebhookDelivery{
+			WebhookID:  hook.ID,
+			EventID:    ev.ID,
+			EventType:  ev.Type,
+			Attempt:    ob.Attempts,
+			StatusCode: status,
+			Duration:   time.Since(start),
+			At:         start.UTC(),
+		}
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		if logErr := d.store.AppendDelivery(context.WithoutCancel(ctx), rec); logErr != nil {
+			d.log.Error("record webhook delivery", "webhook_id", hook.ID, "error", logErr)
+		}
+		if err == nil {
+			d.dropOutbox(ob)
+			return
+		}
+		d.log.Warn("webhook delivery failed", "webhook_id", hook.ID, "event_id", ev.ID, "attempt", ob.Attempts, "error", err)
+
+		ob.LastError = err.Error()
+		ob.NextAt = time.Now().Add(d.policy.Backoff(ob.Attempts)).UTC()
+		if ob.Attempts < d.policy.MaxAttempts {
+			if err := d.store.PutOutbox(context.WithoutCancel(ctx), ob); err != nil {
+				d.log.Error("store webhook outbox entry", "webhook_id", hook.ID, "event_id", ev.ID, "error", err)
+			}
+		}
+	}
+	d.deadLetter(hook, ob)
+}
+
+// post sends one attempt of delivery ob and returns the response status.
+func (d *Dispatcher) post(hook *storage.Webhook, ev events.Event, ob *storage.OutboxDelivery, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "farmops-tracker")
+	req.Header.Set(HeaderEvent, ev.Type)
+	req.Header.Set(HeaderEventID, strconv.FormatUint(ev.ID, 10))
+	req.Header.Set(HeaderDelivery, ob.ID)
+	req.Header.Set(HeaderTimestamp, ts)
+	if hook.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(hook.Secret, ts, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// deadLetter moves ob from the outbox to the dead-letter list. The dead letter
+// takes the outbox ID, so a move interrupted by a restart is simply redone.
+func (d *Dispatcher) deadLetter(hook *storage.Webhook, ob *storage.OutboxDelivery) {
+	dl := &storage.DeadLetter{
+		ID:        ob.ID,
+		WebhookID: hook.ID,
+		EventID:   ob.EventID,
+		EventType: ob.EventType,
+		Payload:   ob.Payload,
+		Attempts:  ob.Attempts,
+		LastError: ob.LastError,
+		FailedAt:  time.Now().UTC(),
+	}
+	if err := d.store.PutDeadLetter(context.Background(), dl); err != nil {
+		d.log.Error("store webhook dead letter", "webhook_id", hook.ID, "event_id", ob.EventID, "error", err)
+		return
+	}
+	d.log.Error("webhook delivery dead-lettered", "webhook_id", hook.ID, "event_id", ob.EventID, "attempts", ob.Attempts, "error", ob.LastError)
+	d.dropOutbox(ob)
+}
+
+// dropOutbox removes a finished delivery from the outbox.
+func (d *Dispatcher) dropOutbox(ob *storage.OutboxDelivery) {
+	if err := d.store.DeleteOutbox(context.Background(), ob.ID); err != nil && err != storage.ErrNotFound {
+		d.log.Error("delete webhook outbox entry", "id", ob.ID, "error", err)
+	}
+}
+
+// Sign returns the X-FarmOps-Signature value for a delivery: the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+// Receivers should recompute it and compare in constant time.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
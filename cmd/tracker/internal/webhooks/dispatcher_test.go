@@ -0,0 +1,260 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/events"
+	"github.com/farmops/farmops/cmd/tracker/internal/webhooks"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+func TestSign(t *testing.T) {
+	got := webhooks.Sign("s3cret", "1700000000", []byte(`{"ok":true}`))
+	want := "sha256=95e532fd0d484d8db110942dc66b379dd229f38f9f5df856e97709db2bb1e3ac"
+	if got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if other := webhooks.Sign("s3cret", "1700000001", []byte(`{"ok":true}`)); other == got {
+		t.Error("signature does not cover the timestamp")
+	}
+	if other := webhooks.Sign("other", "1700000000", []byte(`{"ok":true}`)); other == got {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestPolicy_Backoff(t *testing.T) {
+	p := webhooks.Policy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	for retry, want := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		4:  8 * time.Second,
+		5:  10 * time.Second,
+		20: 10 * time.Second,
+	} {
+		if got := p.Backoff(retry); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", retry, got, want)
+		}
+	}
+}
+
+func TestDispatcher_DeadLettersAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	store := openStore(t)
+	hook := addHook(t, store, srv.URL)
+	ob := pending(t, store, hook, 0)
+
+	_, stop := run(t, store, fastPolicy(3))
+	waitFor(t, func() bool {
+		letters, _ := store.ListDeadLetters(context.Background())
+		return len(letters) == 1
+	})
+	stop()
+
+	letters, _ := store.ListDeadLetters(context.Background())
+	if dl := letters[0]; dl.ID != ob.ID || dl.Attempts != 3 || dl.LastError == "" {
+		t.Errorf("dead letter = %+v, want ID %s after 3 attempts with an error", dl, ob.ID)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("webhook called %d times, want 3", n)
+	}
+	if left, _ := store.ListOutbox(context.Background()); len(left) != 0 {
+		t.Errorf("outbox holds %d entries after dead-lettering, want 0", len(left))
+	}
+	if deliveries, _ := store.ListDeliveries(context.Background(), hook.ID, 0); len(deliveries) != 3 {
+		t.Errorf("delivery log holds %d attempts, want 3", len(deliveries))
+	}
+}
+
+func TestDispatcher_ResumesOutbox(t *testing.T) {
+	var signature atomic.Value
+	var header atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(webhooks.HeaderSignature) == webhooks.Sign("s3cret", r.Header.Get(webhooks.HeaderTimestamp), body) {
+			signature.Store(true)
+		}
+		header.Store(r.Header.Clone())
+	}))
+	defer srv.Close()
+
+	store := openStore(t)
+	hook := addHook(t, store, srv.URL)
+	ob := pending(t, store, hook, 2) // a previous run already failed twice
+
+	_, stop := run(t, store, fastPolicy(3))
+	waitFor(t, func() bool {
+		left, _ := store.ListOutbox(context.Background())
+		return len(left) == 0
+	})
+	stop()
+
+	if signature.Load() == nil {
+		t.Error("resumed delivery was not signed with the webhook secret")
+	}
+	h, _ := header.Load().(http.Header)
+	if got := h.Get(webhooks.HeaderDelivery); got != ob.ID {
+		t.Errorf("%s = %q, want the outbox delivery ID %q", webhooks.HeaderDelivery, got, ob.ID)
+	}
+	if got := h.Get(webhooks.HeaderEventID); got != "7" {
+		t.Errorf("%s = %q, want the event ID 7", webhooks.HeaderEventID, got)
+	}
+	if got := h.Get(webhooks.HeaderEvent); got != events.TypeStreakBroken {
+		t.Errorf("%s = %q, want %s", webhooks.HeaderEvent, got, events.TypeStreakBroken)
+	}
+	deliveries, _ := store.ListDeliveries(context.Background(), hook.ID, 0)
+	if len(deliveries) != 1 || deliveries[0].Attempt != 3 || deliveries[0].Error != "" {
+		t.Errorf("deliveries = %+v, want one successful attempt 3", deliveries)
+	}
+	if letters, _ := store.ListDeadLetters(context.Background()); len(letters) != 0 {
+		t.Errorf("%d dead letters after a successful delivery, want 0", len(letters))
+	}
+}
+
+func TestDispatcher_Redeliver(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	store := openStore(t)
+	hook := addHook(t, store, srv.URL)
+	if err := store.PutDeadLetter(context.Background(), &storage.DeadLetter{
+		ID:        "dl-1",
+		WebhookID: hook.ID,
+		EventID:   7,
+		EventType: events.TypeStreakBroken,
+		Payload:   envelope(t, 7),
+		Attempts:  6,
+		LastError: "webhook responded 500",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	d, stop := run(t, store, fastPolicy(3))
+	if err := d.Redeliver(context.Background(), "dl-1"); err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	waitFor(t, func() bool {
+		left, _ := store.ListOutbox(context.Background())
+		return len(left) == 0
+	})
+	stop()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("webhook called %d times, want 1", n)
+	}
+	if _, err := store.GetDeadLetter(context.Background(), "dl-1"); err != storage.ErrNotFound {
+		t.Errorf("GetDeadLetter after redelivery: err = %v, want ErrNotFound", err)
+	}
+}
+
+func fastPolicy(attempts int) webhooks.Policy {
+	return webhooks.Policy{
+		MaxAttempts:    attempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     4 * time.Millisecond,
+		Timeout:        time.Second,
+	}
+}
+
+func discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func openStore(t *testing.T) *storage.BoltStore {
+	t.Helper()
+	store, err := storage.OpenBolt(filepath.Join(t.TempDir(), "tracker.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func addHook(t *testing.T, store storage.Store, url string) *storage.Webhook {
+	t.Helper()
+	hook := &storage.Webhook{ID: "wh-1", URL: url, Format: storage.WebhookFormatJSON, Secret: "s3cret"}
+	if err := store.PutWebhook(context.Background(), hook); err != nil {
+		t.Fatal(err)
+	}
+	return hook
+}
+
+// pending puts a delivery of a streak_broken event in the outbox, as if a
+// previous run had made the given number of attempts.
+func pending(t *testing.T, store storage.Store, hook *storage.Webhook, attempts int) *storage.OutboxDelivery {
+	t.Helper()
+	ob := &storage.OutboxDelivery{
+		ID:        "ob-1",
+		WebhookID: hook.ID,
+		EventID:   7,
+		EventType: events.TypeStreakBroken,
+		Payload:   envelope(t, 7),
+		Attempts:  attempts,
+		NextAt:    time.Now().UTC(),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := store.PutOutbox(context.Background(), ob); err != nil {
+		t.Fatal(err)
+	}
+	return ob
+}
+
+func envelope(t *testing.T, id uint64) []byte {
+	t.Helper()
+	data, err := json.Marshal(events.Event{
+		ID:   id,
+		Type: events.TypeStreakBroken,
+		At:   time.Now().UTC(),
+		Data: events.StreakBroken{Days: 3, LastActiveAt: time.Now().UTC()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// run starts a dispatcher over store and returns it with a function that
+// stops it and waits for it to finish.
+func run(t *testing.T, store storage.Store, policy webhooks.Policy) (*webhooks.Dispatcher, func()) {
+	t.Helper()
+	d := webhooks.New(store, policy, discard())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx, events.NewBus(0))
+	}()
+	return d, func() {
+		cancel()
+		<-done
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
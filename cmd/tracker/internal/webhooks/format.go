@@ -0,0 +1,83 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/farmops/farmops/cmd/tracker/internal/events"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// slackMessage is a Slack incoming-webhook payload.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// discordMessage is a Discord webhook payload.
+type discordMessage struct {
+	Content string `json:"content"`
+}
+
+// Render returns the request body for ev in the given format.
+func Render(format storage.WebhookFormat, ev events.Event) ([]byte, error) {
+	switch format {
+	case storage.WebhookFormatJSON, "":
+		return json.Marshal(ev)
+	case storage.WebhookFormatSlack:
+		return json.Marshal(slackMessage{Text: Message(ev)})
+	case storage.WebhookFormatDiscord:
+		return json.Marshal(discordMessage{Content: Message(ev)})
+	default:
+		return nil, fmt.Errorf("webhooks: unknown format %q", format)
+	}
+}
+
+// ValidFormat reports whether format is a payload format Render supports.
+func ValidFormat(format storage.WebhookFormat) bool {
+	switch format {
+	case storage.WebhookFormatJSON, storage.WebhookFormatSlack, storage.WebhookFormatDiscord:
+		return true
+	}
+	return false
+}
+
+// Message returns a one-line chat announcement for ev. Both Slack and Discord
+// render *bold* and the emoji used here.
+func Message(ev events.Event) string {
+	// Events resumed from the outbox or a dead letter were decoded from JSON,
+	// so their payload is a generic map; re-decode it into the typed payload.
+	data, _ := json.Marshal(ev.Data)
+
+	switch ev.Type {
+	case events.TypeProofAccepted:
+		var p events.ProofAccepted
+		_ = json.Unmarshal(data, &p)
+		return fmt.Sprintf("✅ *%s* on %s: %s (+%d coins)", p.Category, p.ClusterAlias, p.Description, p.Coins)
+	case events.TypeCoinsAwarded:
+		var p events.CoinsAwarded
+		_ = json.Unmarshal(data, &p)
+		return fmt.Sprintf("🪙 +%d coins for %s. Balance: %d", p.Coins, p.Category, p.Balance)
+	case events.TypeAgentEnrolled:
+		var p events.AgentEnrolled
+		_ = json.Unmarshal(data, &p)
+		return fmt.Sprintf("🛰️ Agent *%s* (%s) enrolled and is awaiting approval", p.AgentID, p.ClusterAlias)
+	case events.TypeUpgradePurchased:
+		var p events.UpgradePurchased
+		_ = json.Unmarshal(data, &p)
+		return fmt.Sprintf("🏗️ *%s* upgraded to level %d for %d coins", p.Name, p.Level, p.Cost)
+	case events.TypeAchievementUnlocked:
+		var p events.AchievementUnlocked
+		_ = json.Unmarshal(data, &p)
+		return fmt.Sprintf("🏆 Achievement unlocked: *%s*: %s", p.Name, p.Description)
+	case events.TypeStreakBroken:
+		var p events.StreakBroken
+		_ = json.Unmarshal(data, &p)
+		return fmt.Sprintf("💔 The %d-day streak is broken. Close a chore today to start a new one.", p.Days)
+	case events.TypeRewardReversed:
+		var p events.RewardReversed
+		_ = json.Unmarshal(data, &p)
+		return fmt.Sprintf("↩️ %d coins for %s taken back from agent %s. Balance: %d", p.Coins, p.Category, p.AgentID, p.Balance)
+	default:
+		return fmt.Sprintf("FarmOps event: %s", ev.Type)
+	}
+}
@@ -15,6 +15,9 @@ import (
 
 	"github.com/farmops/farmops/cmd/tracker/internal/api"
 	"github.com/farmops/farmops/cmd/tracker/internal/config"
+	"github.com/farmops/farmops/cmd/tracker/internal/events"
+	"github.com/farmops/farmops/cmd/tracker/internal/projection"
+	"github.com/farmops/farmops/cmd/tracker/internal/webhooks"
 	"github.com/farmops/farmops/pkg/scoring"
 	"github.com/farmops/farmops/pkg/storage"
 )
@@ -42,8 +45,12 @@ func main() {
 	defer store.Close()
 
 	scoringCfg := scoring.DefaultConfig()
+	bus := events.NewBus(events.DefaultHistory)
+	engine := projection.New(store, cfg.Farm.Rules(), time.Now, bus)
 
-	handler := api.NewHandler(store, scoringCfg, cfg.APIKey, logger)
+	dispatcher := webhooks.New(store, cfg.Webhooks.Policy(), logger)
+
+	handler := api.NewHandler(store, engine, bus, dispatcher, scoringCfg, cfg.APIKey, cfg.TLS.BindAgentIdentity, logger)
 
 	srv := &http.Server{
 		Addr:         cfg.ListenAddr,
@@ -52,13 +59,39 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	if cfg.TLS.Enabled() {
+		tlsCfg, err := cfg.TLS.ServerConfig()
+		if err != nil {
+			slog.Error("failed to load TLS config", "error", err)
+			os.Exit(1)
+		}
+		srv.TLSConfig = tlsCfg
+	}
+	// Close live event streams so that Shutdown does not wait on them.
+	srv.RegisterOnShutdown(bus.Close)
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	if cfg.Farm.DecayInterval > 0 {
+		go runDecay(ctx, engine, cfg.Farm.DecayInterval)
+	}
+
+	dispatcherDone := make(chan struct{})
 	go func() {
-		slog.Info("farmops-tracker listening", "addr", cfg.ListenAddr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		defer close(dispatcherDone)
+		dispatcher.Run(ctx, bus)
+	}()
+
+	go func() {
+		slog.Info("farmops-tracker listening", "addr", cfg.ListenAddr, "tls", cfg.TLS.Enabled())
+		var err error
+		if cfg.TLS.Enabled() {
+			err = srv.ListenAndServeTLS("", "") // certificates are in srv.TLSConfig
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			slog.Error("server error", "error", err)
 			cancel()
 		}
@@ -72,6 +105,28 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		slog.Error("shutdown error", "error", err)
 	}
+	<-dispatcherDone
 
 	slog.Info("farmops-tracker stopped")
 }
+
+// runDecay applies farm decay on a fixed interval until ctx is cancelled.
+func runDecay(ctx context.Context, engine *projection.Engine, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			events, err := engine.Decay(ctx)
+			if err != nil {
+				slog.Error("farm decay failed", "error", err)
+				continue
+			}
+			for _, e := range events {
+				slog.Info("farm decay", "type", e.Type, "plot", e.Plot, "item", e.Item, "coins", e.Coins)
+			}
+		}
+	}
+}
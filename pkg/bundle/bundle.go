@@ -0,0 +1,478 @@
+// Package bundle defines the portable proof bundle: a gzipped tar archive of
+// a tracker's agents, their proof chains and its coin ledger, signed by the
+// tracker. Bundles move history between trackers and keep it archived
+// independently of the tracker's database.
+//
+// An archive holds:
+//
+//	manifest.json         the Manifest: chain heads, counts and file digests
+//	manifest.sig          hex-encoded Ed25519 signature of manifest.json
+//	agents.json           the trust store: agents with their key history
+//	proofs/<agent>.jsonl  each agent's stored proofs, oldest first
+//	ledger.jsonl          the coin ledger, oldest first
+//	actor_pepper          the tracker's hex-encoded actor hashing pepper
+//
+// The signature covers the manifest, and the manifest records the SHA-256
+// of every other file, so a bundle cannot be altered without breaking it.
+// The pepper lets agents keep their actor hashes on the importing tracker,
+// which makes a bundle as confidential as the tracker's database.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+// Version is the bundle format version written by Write.
+const Version = 1
+
+const (
+	manifestFile  = "manifest.json"
+	signatureFile = "manifest.sig"
+	agentsFile    = "agents.json"
+	ledgerFile    = "ledger.jsonl"
+	pepperFile    = "actor_pepper"
+)
+
+// MaxSize bounds the uncompressed size of a bundle that Read accepts, since
+// it holds the whole bundle in memory.
+const MaxSize = 1 << 30
+
+// Manifest describes the contents of a bundle.
+type Manifest struct {
+	Version      int               `json:"version"`
+	CreatedAt    time.Time         `json:"created_at"`
+	Farm         string            `json:"farm"`
+	SignerKey    string            `json:"signer_key"` // hex-encoded Ed25519 key of the exporting tracker
+	Agents       []AgentChain      `json:"agents"`
+	LedgerEvents int               `json:"ledger_events"`
+	Files        map[string]string `json:"files"` // path to hex-encoded SHA-256
+}
+
+// AgentChain summarizes one agent's proof chain in the manifest.
+type AgentChain struct {
+	AgentID string `json:"agent_id"`
+	File    string `json:"file"`
+	Proofs  int    `json:"proofs"`
+	Head    string `json:"head,omitempty"`
+}
+
+// Bundle is the contents of a proof bundle.
+type Bundle struct {
+	Manifest Manifest
+	Agents   []*storage.AgentRecord
+	Proofs   map[string][]*storage.StoredProof // by agent ID, oldest first
+	Ledger   []*farm.Event
+
+	// ActorPepper is the tracker's actor hashing pepper. It is nil in
+	// bundles from trackers that did not export it.
+	ActorPepper []byte
+}
+
+// IsBundle reports whether data starts like a bundle archive.
+func IsBundle(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// Write signs b with key and writes it to w. It fills in the manifest's
+// version, signer key, chains, counts and digests; Farm and CreatedAt are
+// taken from b.Manifest as set by the caller.
+func Write(w io.Writer, b *Bundle, key ed25519.PrivateKey) error {
+	files := map[string][]byte{}
+	m := &b.Manifest
+	m.Version = Version
+	m.SignerKey = proof.EncodePublicKey(key.Public().(ed25519.PublicKey))
+	m.Agents = nil
+	m.LedgerEvents = len(b.Ledger)
+	m.Files = map[string]string{}
+
+	agents, err := json.MarshalIndent(b.Agents, "", "  ")
+	if err != nil {
+		return fmt.Errorf("bundle: marshal agents: %w", err)
+	}
+	files[agentsFile] = agents
+
+	ids := make([]string, 0, len(b.Proofs))
+	for id := range b.Proofs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		chain := b.Proofs[id]
+		ac := AgentChain{AgentID: id, File: "proofs/" + url.PathEscape(id) + ".jsonl", Proofs: len(chain)}
+		if len(chain) > 0 {
+			ac.Head = chain[len(chain)-1].ProofID
+		}
+		data, err := marshalLines(chain)
+		if err != nil {
+			return fmt.Errorf("bundle: marshal proofs of %s: %w", id, err)
+		}
+		files[ac.File] = data
+		m.Agents = append(m.Agents, ac)
+	}
+
+	ledger, err := marshalLines(b.Ledger)
+	if err != nil {
+		return fmt.Errorf("bundle: marshal ledger: %w", err)
+	}
+	files[ledgerFile] = ledger
+	if len(b.ActorPepper) > 0 {
+		files[pepperFile] = []byte(hex.EncodeToString(b.ActorPepper) + "\n")
+	}
+
+	for name, data := range files {
+		m.Files[name] = digest(data)
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("bundle: marshal manifest: %w", err)
+	}
+	sig := hex.EncodeToString(ed25519.Sign(key, manifest))
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: m.CreatedAt}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := add(manifestFile, manifest); err != nil {
+		return fmt.Errorf("bundle: write: %w", err)
+	}
+	if err := add(signatureFile, []byte(sig+"\n")); err != nil {
+		return fmt.Errorf("bundle: write: %w", err)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := add(name, files[name]); err != nil {
+			return fmt.Errorf("bundle: write: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("bundle: write: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("bundle: write: %w", err)
+	}
+	return nil
+}
+
+// Read reads a bundle, checking the manifest's signature against the signer
+// key it names and every file against its digest. The proof chains are not
+// verified; see Verify. Anyone can sign a bundle with a key of their own, so
+// callers must also pin the exporting tracker's key with CheckSigner before
+// trusting the bundle's trust store.
+func Read(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("bundle: not a bundle: %w", err)
+	}
+	files := map[string][]byte{}
+	limited := &io.LimitedReader{R: gz, N: MaxSize + 1}
+	tr := tar.NewReader(limited)
+	for {
+		hdr, err := tr.Next()
+		if limited.N <= 0 {
+			return nil, fmt.Errorf("bundle: larger than %d bytes uncompressed", MaxSize)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bundle: read: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if limited.N <= 0 {
+			return nil, fmt.Errorf("bundle: larger than %d bytes uncompressed", MaxSize)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bundle: read %s: %w", hdr.Name, err)
+		}
+		files[hdr.Name] = data
+	}
+
+	manifest, sig := files[manifestFile], files[signatureFile]
+	if manifest == nil || sig == nil {
+		return nil, errors.New("bundle: missing manifest or signature")
+	}
+	b := &Bundle{Proofs: map[string][]*storage.StoredProof{}}
+	if err := json.Unmarshal(manifest, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("bundle: parse manifest: %w", err)
+	}
+	m := &b.Manifest
+	if m.Version != Version {
+		return nil, fmt.Errorf("bundle: unsupported version %d", m.Version)
+	}
+	signer, err := proof.DecodePublicKey(m.SignerKey)
+	if err != nil {
+		return nil, fmt.Errorf("bundle: signer key: %w", err)
+	}
+	sigBytes, err := hex.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || !ed25519.Verify(signer, manifest, sigBytes) {
+		return nil, errors.New("bundle: manifest signature is invalid")
+	}
+
+	delete(files, manifestFile)
+	delete(files, signatureFile)
+	for name, data := range files {
+		want, ok := m.Files[name]
+		if !ok {
+			return nil, fmt.Errorf("bundle: %s is not in the manifest", name)
+		}
+		if digest(data) != want {
+			return nil, fmt.Errorf("bundle: %s does not match its digest", name)
+		}
+	}
+	for name := range m.Files {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("bundle: %s is missing", name)
+		}
+	}
+
+	if err := json.Unmarshal(files[agentsFile], &b.Agents); err != nil {
+		return nil, fmt.Errorf("bundle: parse %s: %w", agentsFile, err)
+	}
+	for _, ac := range m.Agents {
+		data, ok := files[ac.File]
+		if !ok {
+			return nil, fmt.Errorf("bundle: %s is missing", ac.File)
+		}
+		chain, err := unmarshalLines[storage.StoredProof](data)
+		if err != nil {
+			return nil, fmt.Errorf("bundle: parse %s: %w", ac.File, err)
+		}
+		b.Proofs[ac.AgentID] = chain
+	}
+	if b.Ledger, err = unmarshalLines[farm.Event](files[ledgerFile]); err != nil {
+		return nil, fmt.Errorf("bundle: parse %s: %w", ledgerFile, err)
+	}
+	if data, ok := files[pepperFile]; ok {
+		if b.ActorPepper, err = hex.DecodeString(strings.TrimSpace(string(data))); err != nil || len(b.ActorPepper) == 0 {
+			return nil, fmt.Errorf("bundle: %s is not a hex-encoded pepper", pepperFile)
+		}
+	}
+	return b, nil
+}
+
+// ErrUntrustedSigner is returned by CheckSigner for a bundle signed by none
+// of the trusted keys.
+var ErrUntrustedSigner = errors.New("bundle: signed by an untrusted key")
+
+// CheckSigner checks that the bundle was signed by one of trusted.
+func (b *Bundle) CheckSigner(trusted ...ed25519.PublicKey) error {
+	for _, k := range trusted {
+		if proof.EncodePublicKey(k) == b.Manifest.SignerKey {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %s", ErrUntrustedSigner, b.Manifest.SignerKey)
+}
+
+// Verify checks every agent's chain with proof.CheckChain against the keys
+// in the bundle's trust store, and that each chain starts at genesis and
+// matches the manifest's count and head. It then checks the ledger against
+// the proofs; see checkLedger. It returns the report for each agent, and an
+// error naming every agent whose chain failed and every bad ledger event.
+func (b *Bundle) Verify() (map[string]*proof.ChainReport, error) {
+	agents := make(map[string]*storage.AgentRecord, len(b.Agents))
+	for _, a := range b.Agents {
+		agents[a.AgentID] = a
+	}
+	reports := make(map[string]*proof.ChainReport, len(b.Manifest.Agents))
+	var errs []error
+agents:
+	for _, ac := range b.Manifest.Agents {
+		a, ok := agents[ac.AgentID]
+		if !ok {
+			errs = append(errs, fmt.Errorf("agent %s: not in the trust store", ac.AgentID))
+			continue
+		}
+		keys, err := agentKeys(a)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("agent %s: %w", ac.AgentID, err))
+			continue
+		}
+		chain := b.Proofs[ac.AgentID]
+		proofs := make([]*proof.FarmProof, len(chain))
+		for i, sp := range chain {
+			if sp.FarmProof == nil || sp.Agent.AgentID != ac.AgentID {
+				errs = append(errs, fmt.Errorf("agent %s: proof %d belongs to another agent", ac.AgentID, i+1))
+				continue agents
+			}
+			proofs[i] = sp.FarmProof
+		}
+		r := proof.CheckChain(proofs, keys[0], keys[1:]...)
+		reports[ac.AgentID] = r
+		switch {
+		case !r.OK():
+			errs = append(errs, fmt.Errorf("agent %s: %d chain issues, first: %s", ac.AgentID, len(r.Issues), r.Issues[0]))
+		case r.StartsAfter != "":
+			errs = append(errs, fmt.Errorf("agent %s: chain does not start at genesis", ac.AgentID))
+		case r.Proofs != ac.Proofs || r.Head != ac.Head:
+			errs = append(errs, fmt.Errorf("agent %s: chain has %d proofs ending at %s, manifest says %d ending at %s",
+				ac.AgentID, r.Proofs, r.Head, ac.Proofs, ac.Head))
+		}
+	}
+	errs = append(errs, b.checkLedger()...)
+	if len(errs) > 0 {
+		return reports, fmt.Errorf("bundle: %w", errors.Join(errs...))
+	}
+	return reports, nil
+}
+
+// checkLedger checks that the ledger only moves coins the way the tracker
+// does: each proof reward matches its proof's CoinsAwarded, agent and
+// category, each reversal takes back the reward of an invalidated proof,
+// harvests yield no more than a ripe crop, and spending and decay never
+// credit coins. An opening balance, carried over by a tracker older than the
+// ledger, cannot be checked against proofs and is only accepted as the
+// first event.
+func (b *Bundle) checkLedger() []error {
+	proofs := map[string]*storage.StoredProof{}
+	var ordered []*storage.StoredProof
+	for _, ac := range b.Manifest.Agents {
+		for _, sp := range b.Proofs[ac.AgentID] {
+			if sp.FarmProof != nil {
+				proofs[sp.ProofID] = sp
+				ordered = append(ordered, sp)
+			}
+		}
+	}
+	maxHarvest := farm.DefaultRules().HarvestCoins
+
+	var errs []error
+	bad := func(i int, e *farm.Event, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("ledger event %d (%s): %s", i+1, e.Type, fmt.Sprintf(format, args...)))
+	}
+	rewards := map[string]*farm.Event{}
+	reversed := map[string]bool{}
+	for i, e := range b.Ledger {
+		switch e.Type {
+		case farm.EventProofReward:
+			sp := proofs[e.ProofID]
+			switch {
+			case sp == nil:
+				bad(i, e, "proof %q is not in the bundle", e.ProofID)
+			case rewards[e.ProofID] != nil:
+				bad(i, e, "proof %s is rewarded twice", e.ProofID)
+			case e.Coins != sp.CoinsAwarded || e.AgentID != sp.Agent.AgentID || e.Category != sp.Action.Category:
+				bad(i, e, "%d coins to %s for %s, but proof %s was awarded %d to %s for %s",
+					e.Coins, e.AgentID, e.Category, e.ProofID, sp.CoinsAwarded, sp.Agent.AgentID, sp.Action.Category)
+			}
+			rewards[e.ProofID] = e
+		case farm.EventRewardReversed:
+			reward := rewards[e.ProofID]
+			switch {
+			case reward == nil:
+				bad(i, e, "proof %q has no earlier reward", e.ProofID)
+			case reversed[e.ProofID]:
+				bad(i, e, "proof %s is reversed twice", e.ProofID)
+			case proofs[e.ProofID].InvalidatedAt == nil:
+				bad(i, e, "proof %s is still valid", e.ProofID)
+			case e.Coins != -reward.Coins:
+				bad(i, e, "takes back %d coins of a %d coin reward", -e.Coins, reward.Coins)
+			}
+			reversed[e.ProofID] = true
+		case farm.EventOpeningBalance:
+			if i != 0 || e.Coins < 0 {
+				bad(i, e, "only the first event may carry an opening balance")
+			}
+		case farm.EventHarvest:
+			if e.Coins < 0 || e.Coins > maxHarvest {
+				bad(i, e, "yields %d coins, a harvest at most %d", e.Coins, maxHarvest)
+			}
+		case farm.EventPlant, farm.EventPurchase, farm.EventUpkeep:
+			if e.Coins > 0 {
+				bad(i, e, "credits %d coins", e.Coins)
+			}
+		case farm.EventWilt, farm.EventUpkeepMissed, farm.EventStreakBroken:
+			if e.Coins != 0 {
+				bad(i, e, "moves %d coins", e.Coins)
+			}
+		default:
+			bad(i, e, "unknown event type")
+		}
+	}
+	for _, sp := range ordered {
+		if sp.CoinsAwarded > 0 && rewards[sp.ProofID] == nil {
+			errs = append(errs, fmt.Errorf("ledger: proof %s was awarded %d coins but has no reward event", sp.ProofID, sp.CoinsAwarded))
+		}
+	}
+	return errs
+}
+
+// agentKeys returns an agent's public keys, enrollment key first.
+func agentKeys(a *storage.AgentRecord) ([]ed25519.PublicKey, error) {
+	encoded := make([]string, 0, len(a.KeyHistory)+1)
+	for _, k := range a.KeyHistory {
+		encoded = append(encoded, k.PublicKey)
+	}
+	encoded = append(encoded, a.PublicKey)
+	keys := make([]ed25519.PublicKey, 0, len(encoded))
+	for _, e := range encoded {
+		k, err := proof.DecodePublicKey(e)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func marshalLines[T any](items []*T) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func unmarshalLines[T any](data []byte) ([]*T, error) {
+	var items []*T
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		item := new(T)
+		err := dec.Decode(item)
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(items)+1, err)
+		}
+		items = append(items, item)
+	}
+}
@@ -0,0 +1,219 @@
+package bundle_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farmops/farmops/pkg/bundle"
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/storage"
+)
+
+func testBundle(t *testing.T) *bundle.Bundle {
+	t.Helper()
+	pub, priv, err := proof.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := proof.AgentInfo{AgentID: "agent-1", ClusterAlias: "test-cluster"}
+	actor := proof.ActorInfo{ActorHash: proof.HashActor("github:testuser"), ActorType: proof.ActorHuman}
+	action := proof.ActionInfo{Plugin: "farmops/k8s-pod-health", ActionType: proof.ActionVerify, Category: proof.CategoryMaintenance, Description: "check"}
+	outcome := proof.OutcomeInfo{Status: proof.OutcomeSuccess, Verified: true, EvidenceHash: "abc"}
+
+	var chain []*storage.StoredProof
+	var ledger []*farm.Event
+	var prev *proof.FarmProof
+	for i := 0; i < 3; i++ {
+		p, _ := proof.New(agent, actor, action, outcome, proof.ScoringHints{Complexity: proof.ComplexityLow}, prev)
+		_ = proof.Sign(p, priv)
+		chain = append(chain, &storage.StoredProof{FarmProof: p, CoinsAwarded: 10, ReceivedAt: time.Now().UTC()})
+		ledger = append(ledger, farm.Reward(p.ProofID, agent.AgentID, proof.CategoryMaintenance, 10, time.Now().UTC()))
+		prev = p
+	}
+	return &bundle.Bundle{
+		Manifest: bundle.Manifest{Farm: "Test Farm", CreatedAt: time.Now().UTC()},
+		Agents: []*storage.AgentRecord{{
+			AgentID: agent.AgentID, ClusterAlias: agent.ClusterAlias,
+			PublicKey: proof.EncodePublicKey(pub), Status: storage.AgentStatusActive,
+		}},
+		Proofs: map[string][]*storage.StoredProof{agent.AgentID: chain},
+		Ledger: ledger,
+	}
+}
+
+func TestBundle_RoundTrip(t *testing.T) {
+	trackerPub, trackerKey, _ := proof.GenerateKeyPair()
+	written := testBundle(t)
+	written.ActorPepper = []byte("pepper")
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, written, trackerKey); err != nil {
+		t.Fatal(err)
+	}
+	if !bundle.IsBundle(buf.Bytes()) {
+		t.Error("IsBundle does not recognize a written bundle")
+	}
+
+	b, err := bundle.Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Manifest.Farm != "Test Farm" || len(b.Proofs["agent-1"]) != 3 || len(b.Ledger) != 3 {
+		t.Fatalf("read back %+v", b.Manifest)
+	}
+	if string(b.ActorPepper) != "pepper" {
+		t.Errorf("read back pepper %q, want %q", b.ActorPepper, "pepper")
+	}
+	if b.Manifest.SignerKey != proof.EncodePublicKey(trackerPub) {
+		t.Error("manifest does not name the signing key")
+	}
+	otherPub, _, _ := proof.GenerateKeyPair()
+	if err := b.CheckSigner(otherPub, trackerPub); err != nil {
+		t.Errorf("CheckSigner with the signing key trusted: %v", err)
+	}
+	if err := b.CheckSigner(otherPub); !errors.Is(err, bundle.ErrUntrustedSigner) {
+		t.Errorf("CheckSigner with another key: err = %v, want ErrUntrustedSigner", err)
+	}
+	reports, err := b.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := reports["agent-1"]; r.Verified != 3 || r.Head != b.Manifest.Agents[0].Head {
+		t.Errorf("report: %+v", r)
+	}
+}
+
+func TestBundle_RejectsTampering(t *testing.T) {
+	_, trackerKey, _ := proof.GenerateKeyPair()
+
+	// A proof altered after signing, in a bundle the tracker signed anyway,
+	// fails chain verification.
+	b := testBundle(t)
+	b.Proofs["agent-1"][1].ScoringHints.ImpactRadius = 99
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, b, trackerKey); err != nil {
+		t.Fatal(err)
+	}
+	read, err := bundle.Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := read.Verify(); err == nil {
+		t.Error("a bundle with a tampered proof verified")
+	}
+
+	// A file altered after export no longer matches the signed manifest.
+	buf.Reset()
+	if err := bundle.Write(&buf, testBundle(t), trackerKey); err != nil {
+		t.Fatal(err)
+	}
+	altered := rewrite(t, buf.Bytes(), "ledger.jsonl", func(data []byte) []byte {
+		return []byte(strings.Replace(string(data), `"coins":10`, `"coins":1000`, 1))
+	})
+	if _, err := bundle.Read(bytes.NewReader(altered)); err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Errorf("altered ledger: got %v, want a digest mismatch", err)
+	}
+}
+
+func TestBundle_ChecksLedger(t *testing.T) {
+	_, trackerKey, _ := proof.GenerateKeyPair()
+	at := time.Now().UTC()
+	for _, tc := range []struct {
+		name string
+		edit func(b *bundle.Bundle)
+		want string
+	}{
+		{"inflated reward", func(b *bundle.Bundle) { b.Ledger[0].Coins = 1000 }, "was awarded 10"},
+		{"reward for an unknown proof", func(b *bundle.Bundle) {
+			b.Ledger = append(b.Ledger, farm.Reward("no-such-proof", "agent-1", proof.CategoryMaintenance, 10, at))
+		}, "not in the bundle"},
+		{"reward paid twice", func(b *bundle.Bundle) { b.Ledger = append(b.Ledger, b.Ledger[0]) }, "rewarded twice"},
+		{"missing reward", func(b *bundle.Bundle) { b.Ledger = b.Ledger[1:] }, "has no reward event"},
+		{"reversal of a valid proof", func(b *bundle.Bundle) { b.Ledger = append(b.Ledger, farm.Reversal(b.Ledger[0], at)) }, "still valid"},
+		{"oversized harvest", func(b *bundle.Bundle) {
+			b.Ledger = append(b.Ledger, &farm.Event{Type: farm.EventHarvest, At: at, Coins: 1000, Plot: 1})
+		}, "a harvest at most"},
+		{"late opening balance", func(b *bundle.Bundle) {
+			b.Ledger = append(b.Ledger, &farm.Event{Type: farm.EventOpeningBalance, At: at, Coins: 1000})
+		}, "only the first event"},
+		{"credited purchase", func(b *bundle.Bundle) {
+			b.Ledger = append(b.Ledger, &farm.Event{Type: farm.EventPurchase, At: at, Coins: 50, Item: "silo"})
+		}, "credits 50 coins"},
+		{"unknown event", func(b *bundle.Bundle) {
+			b.Ledger = append(b.Ledger, &farm.Event{Type: "gift", At: at, Coins: 1000})
+		}, "unknown event type"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := testBundle(t)
+			tc.edit(b)
+			var buf bytes.Buffer
+			if err := bundle.Write(&buf, b, trackerKey); err != nil {
+				t.Fatal(err)
+			}
+			read, err := bundle.Read(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := read.Verify(); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Verify: got %v, want an error containing %q", err, tc.want)
+			}
+		})
+	}
+
+	// A revoked proof's reward and its reversal are both accepted.
+	b := testBundle(t)
+	invalidated := at
+	b.Proofs["agent-1"][2].InvalidatedAt = &invalidated
+	b.Ledger = append(b.Ledger, farm.Reversal(b.Ledger[2], at))
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, b, trackerKey); err != nil {
+		t.Fatal(err)
+	}
+	read, err := bundle.Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := read.Verify(); err != nil {
+		t.Errorf("Verify with a reversed reward: %v", err)
+	}
+}
+
+// rewrite returns a copy of the archive with one file's content replaced.
+func rewrite(t *testing.T, archive []byte, name string, edit func([]byte) []byte) []byte {
+	t.Helper()
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		if hdr.Name == name {
+			data = edit(data)
+			hdr.Size = int64(len(data))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(data)
+	}
+	tw.Close()
+	gw.Close()
+	return out.Bytes()
+}
@@ -0,0 +1,184 @@
+// Package evidence keeps the raw evidence behind an agent's proofs on the
+// agent, so that a disputed proof can be disclosed to an auditor without
+// ever sending evidence to the tracker. Proofs carry only a salted
+// commitment (proof.CommitEvidence); the evidence and its salt are kept in a
+// local vault, one AES-256-GCM encrypted file per proof.
+package evidence
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+// ErrNotFound is returned by Vault.Get for a proof with no stored evidence.
+var ErrNotFound = errors.New("evidence: no evidence stored for proof")
+
+// KeySize is the length of a vault key.
+const KeySize = 32
+
+// Record is the evidence behind one proof. Marshalled as JSON it is the
+// disclosure handed to an auditor, who checks that
+// sha256(salt || evidence) equals the proof's evidence_hash.
+type Record struct {
+	ProofID      string    `json:"proof_id"`
+	EvidenceHash string    `json:"evidence_hash"`
+	Salt         string    `json:"salt"`     // hex-encoded
+	Evidence     []byte    `json:"evidence"` // raw evidence bytes, base64 in JSON
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// New salts raw evidence for the proof proofID and returns its record;
+// r.EvidenceHash goes into the proof.
+func New(proofID string, raw []byte) (*Record, error) {
+	salt, err := proof.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+	return &Record{
+		ProofID:      proofID,
+		EvidenceHash: proof.CommitEvidence(raw, salt),
+		Salt:         hex.EncodeToString(salt),
+		Evidence:     raw,
+		CreatedAt:    time.Now().UTC(),
+	}, nil
+}
+
+// Verify checks that the evidence and salt open the commitment evidenceHash,
+// normally the evidence_hash of the proof as the tracker holds it.
+func (r *Record) Verify(evidenceHash string) error {
+	salt, err := hex.DecodeString(r.Salt)
+	if err != nil {
+		return fmt.Errorf("evidence: invalid salt: %w", err)
+	}
+	if !proof.VerifyEvidence(evidenceHash, r.Evidence, salt) {
+		return fmt.Errorf("evidence: proof %s: evidence does not match evidence_hash %s", r.ProofID, evidenceHash)
+	}
+	return nil
+}
+
+// Vault stores evidence records in a directory, encrypted with a key that
+// never leaves the agent's host.
+type Vault struct {
+	dir  string
+	aead cipher.AEAD
+}
+
+// Open opens the vault in dir, creating the directory if needed.
+func Open(dir string, key []byte) (*Vault, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("evidence: vault key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("evidence: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("evidence: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("evidence: create vault: %w", err)
+	}
+	return &Vault{dir: dir, aead: aead}, nil
+}
+
+// Put stores r, replacing any earlier record for the same proof.
+func (v *Vault) Put(r *Record) error {
+	path, err := v.path(r.ProofID)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("evidence: marshal record: %w", err)
+	}
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("evidence: %w", err)
+	}
+	// The proof ID is authenticated, so a record cannot be passed off as
+	// another proof's by renaming its file.
+	sealed := v.aead.Seal(nonce, nonce, plain, []byte(r.ProofID))
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0600); err != nil {
+		return fmt.Errorf("evidence: write record: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("evidence: write record: %w", err)
+	}
+	return nil
+}
+
+// Get returns the record of the proof proofID.
+func (v *Vault) Get(proofID string) (*Record, error) {
+	path, err := v.path(proofID)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w %s", ErrNotFound, proofID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("evidence: read record: %w", err)
+	}
+	n := v.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, fmt.Errorf("evidence: record for proof %s is truncated", proofID)
+	}
+	plain, err := v.aead.Open(nil, sealed[:n], sealed[n:], []byte(proofID))
+	if err != nil {
+		return nil, fmt.Errorf("evidence: record for proof %s cannot be decrypted with this key", proofID)
+	}
+	var r Record
+	if err := json.Unmarshal(plain, &r); err != nil {
+		return nil, fmt.Errorf("evidence: parse record: %w", err)
+	}
+	return &r, nil
+}
+
+func (v *Vault) path(proofID string) (string, error) {
+	if proofID == "" || strings.ContainsAny(proofID, `/\`) || strings.HasPrefix(proofID, ".") {
+		return "", fmt.Errorf("evidence: invalid proof id %q", proofID)
+	}
+	return filepath.Join(v.dir, proofID+".evidence"), nil
+}
+
+// LoadKey reads a hex-encoded vault key from path. If the file does not
+// exist and create is set, a new random key is written there with mode 0600.
+func LoadKey(path string, create bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && create {
+		key := make([]byte, KeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("evidence: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, fmt.Errorf("evidence: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+			return nil, fmt.Errorf("evidence: write key: %w", err)
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("evidence: read key: %w", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("evidence: %s must hold a hex-encoded %d-byte key", path, KeySize)
+	}
+	return key, nil
+}
@@ -0,0 +1,64 @@
+package evidence_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/farmops/farmops/pkg/evidence"
+)
+
+func TestVault_DisclosesCommittedEvidence(t *testing.T) {
+	dir := t.TempDir()
+	key, err := evidence.LoadKey(filepath.Join(dir, "evidence.key"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vault, err := evidence.Open(filepath.Join(dir, "vault"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := []byte(`{"total":52,"healthy":52,"crash_looping":0}`)
+	rec, err := evidence.New("proof-1", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := evidence.New("proof-2", raw)
+	if rec.EvidenceHash == again.EvidenceHash {
+		t.Error("the same evidence committed twice gives the same hash; salt is not applied")
+	}
+	if err := vault.Put(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := vault.Get("proof-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.Verify(rec.EvidenceHash); err != nil {
+		t.Errorf("disclosed evidence does not verify: %v", err)
+	}
+	if err := got.Verify(again.EvidenceHash); err == nil {
+		t.Error("disclosed evidence verifies against another proof's hash")
+	}
+
+	if _, err := vault.Get("proof-2"); !errors.Is(err, evidence.ErrNotFound) {
+		t.Errorf("Get of a missing proof: got %v, want ErrNotFound", err)
+	}
+
+	// A record moved to another proof's name must not decrypt.
+	if err := os.Rename(filepath.Join(dir, "vault", "proof-1.evidence"), filepath.Join(dir, "vault", "proof-2.evidence")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vault.Get("proof-2"); err == nil {
+		t.Error("a renamed record decrypted under another proof id")
+	}
+
+	// The key file is reused, not regenerated.
+	reloaded, err := evidence.LoadKey(filepath.Join(dir, "evidence.key"), true)
+	if err != nil || string(reloaded) != string(key) {
+		t.Errorf("reloaded key differs: %v", err)
+	}
+}
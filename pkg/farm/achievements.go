@@ -0,0 +1,85 @@
+package farm
+
+import (
+	"time"
+
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+// Achievement is a milestone unlocked on the farm.
+type Achievement struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	UnlockedAt  time.Time `json:"unlocked_at"`
+}
+
+// achievementDef describes an achievement and the condition that unlocks it.
+type achievementDef struct {
+	id, name, description string
+	unlocked              func(w *World, e *Event) bool
+}
+
+var achievements = []achievementDef{
+	{"first-proof", "First Furrow", "Earn coins for a verified proof.", func(w *World, _ *Event) bool {
+		return w.TotalCoins > 0 && len(w.Categories) > 0
+	}},
+	{"first-harvest", "Harvest Moon", "Harvest a ripe crop.", func(_ *World, e *Event) bool {
+		return e.Type == EventHarvest && e.Coins > 0
+	}},
+	{"first-building", "Groundbreaking", "Place your first building.", func(w *World, _ *Event) bool {
+		return len(w.Buildings) > 0
+	}},
+	{"streak-7", "Week of Chores", "Keep a 7-day streak.", func(w *World, _ *Event) bool {
+		return w.StreakDays >= 7
+	}},
+	{"streak-30", "Seasoned Farmer", "Keep a 30-day streak.", func(w *World, _ *Event) bool {
+		return w.StreakDays >= 30
+	}},
+	{"coins-1000", "Full Silo", "Earn 1,000 coins.", func(w *World, _ *Event) bool {
+		return w.TotalCoins >= 1000
+	}},
+	{"coins-10000", "Harvest Baron", "Earn 10,000 coins.", func(w *World, _ *Event) bool {
+		return w.TotalCoins >= 10000
+	}},
+	{"incident-10", "Firefighter", "Resolve 10 incidents.", func(w *World, _ *Event) bool {
+		cs := w.Categories[proof.CategoryIncident]
+		return cs != nil && cs.Proofs >= 10
+	}},
+	{"all-categories", "Mixed Farming", "Earn coins in every category.", func(w *World, _ *Event) bool {
+		for _, c := range []string{
+			proof.CategoryMaintenance, proof.CategoryToil, proof.CategoryReliability,
+			proof.CategorySecurity, proof.CategoryIncident, proof.CategoryUpgrade,
+		} {
+			if w.Categories[c] == nil {
+				return false
+			}
+		}
+		return true
+	}},
+}
+
+// HasAchievement reports whether the achievement with the given ID is unlocked.
+func (w *World) HasAchievement(id string) bool {
+	for _, a := range w.Achievements {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// unlockAchievements records every achievement whose condition holds after e.
+func (w *World) unlockAchievements(e *Event) {
+	for _, def := range achievements {
+		if w.HasAchievement(def.id) || !def.unlocked(w, e) {
+			continue
+		}
+		w.Achievements = append(w.Achievements, Achievement{
+			ID:          def.id,
+			Name:        def.name,
+			Description: def.description,
+			UnlockedAt:  e.At,
+		})
+	}
+}
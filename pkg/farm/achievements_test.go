@@ -0,0 +1,43 @@
+package farm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+func TestWorld_AchievementsUnlockOnce(t *testing.T) {
+	rules := farm.DefaultRules()
+	w := farm.NewWorld(rules)
+	var ledger []*farm.Event
+
+	for day := 0; day < 7; day++ {
+		record(w, rules, &ledger, farm.Reward("p", "agent-1", proof.CategoryIncident, 100, t0.Add(time.Duration(day)*24*time.Hour)))
+	}
+	for _, id := range []string{"first-proof", "streak-7"} {
+		if !w.HasAchievement(id) {
+			t.Errorf("achievement %q not unlocked", id)
+		}
+	}
+	if w.HasAchievement("coins-1000") {
+		t.Error("coins-1000 unlocked with 700 coins")
+	}
+
+	record(w, rules, &ledger, farm.Reward("p", "agent-1", proof.CategoryIncident, 300, t0.Add(7*24*time.Hour)))
+	if !w.HasAchievement("coins-1000") {
+		t.Error("coins-1000 not unlocked with 1000 coins")
+	}
+
+	seen := map[string]bool{}
+	for _, a := range w.Achievements {
+		if seen[a.ID] {
+			t.Errorf("achievement %q unlocked twice", a.ID)
+		}
+		seen[a.ID] = true
+	}
+	if got := w.Achievements[0].UnlockedAt; !got.Equal(t0) {
+		t.Errorf("first-proof unlocked at %v, want %v", got, t0)
+	}
+}
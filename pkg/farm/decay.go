@@ -0,0 +1,106 @@
+package farm
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Decay returns the wilt, upkeep and streak events that have fallen due by now, in
+// the order they became due. Each event is stamped with its due time rather
+// than now, so the ledger reads the same whether decay was applied lazily on
+// the next read or by a scheduler tick. Decay does not modify w.
+func (w *World) Decay(rules Rules, now time.Time) []*Event {
+	scratch := w.clone()
+	var events []*Event
+	for {
+		e := scratch.nextDecay(rules, now)
+		if e == nil {
+			return events
+		}
+		scratch.Apply(e, rules)
+		events = append(events, e)
+	}
+}
+
+// nextDecay returns the earliest decay event due at or before now, or nil.
+// Ties are broken by plot order, then building order, then the streak, to keep
+// decay deterministic.
+func (w *World) nextDecay(rules Rules, now time.Time) *Event {
+	var next *Event
+	consider := func(e *Event) {
+		if e.At.After(now) {
+			return
+		}
+		if next == nil || e.At.Before(next.At) {
+			next = e
+		}
+	}
+
+	if rules.WiltAfter > 0 {
+		for _, p := range w.Plots {
+			if p.Crop == nil || p.Crop.Wilted {
+				continue
+			}
+			consider(&Event{
+				Type:     EventWilt,
+				At:       w.lastTended(p.Crop).Add(rules.WiltAfter),
+				Category: p.Crop.Category,
+				Plot:     p.ID,
+			})
+		}
+	}
+
+	if rules.UpkeepInterval > 0 {
+		for i := range w.Buildings {
+			b := &w.Buildings[i]
+			item, ok := LookupItem(b.Slug)
+			if !ok {
+				continue
+			}
+			e := &Event{Type: EventUpkeep, At: b.upkeepDue(rules), Category: b.Category, Item: b.Slug}
+			if cost := item.UpkeepCost(b.Level); w.Balance >= cost {
+				e.Coins = -cost
+			} else {
+				e.Type = EventUpkeepMissed
+			}
+			consider(e)
+		}
+	}
+
+	if w.StreakDays > 0 && w.LastActiveAt != nil {
+		consider(&Event{Type: EventStreakBroken, At: streakBreaksAt(*w.LastActiveAt)})
+	}
+
+	return next
+}
+
+// lastTended returns when a crop last saw activity: the latest proof in its
+// category, or its planting time if no proof has arrived since.
+func (w *World) lastTended(c *Crop) time.Time {
+	if cs := w.Categories[c.Category]; cs != nil && cs.LastProofAt != nil && cs.LastProofAt.After(c.PlantedAt) {
+		return *cs.LastProofAt
+	}
+	return c.PlantedAt
+}
+
+// upkeepDue returns when the building's next upkeep falls due. Buildings
+// placed while upkeep was disabled fall due one interval after purchase.
+func (b *Building) upkeepDue(rules Rules) time.Time {
+	if b.UpkeepDueAt.IsZero() {
+		return b.PurchasedAt.Add(rules.UpkeepInterval)
+	}
+	return b.UpkeepDueAt
+}
+
+// clone returns a deep copy of the world.
+func (w *World) clone() *World {
+	data, err := json.Marshal(w)
+	if err != nil {
+		panic("farm: clone world: " + err.Error())
+	}
+	var cp World
+	if err := json.Unmarshal(data, &cp); err != nil {
+		panic("farm: clone world: " + err.Error())
+	}
+	return &cp
+}
@@ -0,0 +1,135 @@
+package farm_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+func TestWorld_DecayWiltsIdleCategory(t *testing.T) {
+	rules := farm.DefaultRules()
+	w := farm.NewWorld(rules)
+	var ledger []*farm.Event
+
+	record(w, rules, &ledger, &farm.Event{Type: farm.EventOpeningBalance, At: t0, Coins: 100})
+	for _, plot := range []int{1, 2} {
+		category := proof.CategorySecurity
+		if plot == 2 {
+			category = proof.CategoryMaintenance
+		}
+		ev, err := w.Plant(rules, plot, category, t0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record(w, rules, &ledger, ev)
+	}
+
+	// Maintenance stays active; security goes idle.
+	record(w, rules, &ledger, farm.Reward("p1", "agent-1", proof.CategoryMaintenance, 10, t0.Add(48*time.Hour)))
+
+	if got := w.Decay(rules, t0.Add(rules.WiltAfter-time.Minute)); len(got) != 0 {
+		t.Fatalf("decay before threshold produced %d events", len(got))
+	}
+
+	now := t0.Add(rules.WiltAfter + time.Hour)
+	events := w.Decay(rules, now)
+	if len(events) != 1 || events[0].Type != farm.EventWilt || events[0].Plot != 1 {
+		t.Fatalf("decay events = %+v, want one wilt on plot 1", events)
+	}
+	if !events[0].At.Equal(t0.Add(rules.WiltAfter)) {
+		t.Errorf("wilt stamped %v, want due time %v", events[0].At, t0.Add(rules.WiltAfter))
+	}
+	if w.Plots[0].Crop.Wilted {
+		t.Fatal("Decay mutated the world")
+	}
+	for _, e := range events {
+		record(w, rules, &ledger, e)
+	}
+
+	// A wilted crop stops growing and yields nothing on harvest.
+	record(w, rules, &ledger, farm.Reward("p2", "agent-1", proof.CategorySecurity, 25, now))
+	if w.Plots[0].Crop.Growth != 0 {
+		t.Error("wilted crop kept growing")
+	}
+	ev, err := w.Harvest(rules, 1, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Coins != 0 {
+		t.Errorf("wilted harvest yielded %d coins", ev.Coins)
+	}
+
+	if replayed := farm.Replay(ledger, rules); !reflect.DeepEqual(replayed, w) {
+		t.Error("replay after decay differs from projected world")
+	}
+}
+
+func TestWorld_DecayUpkeep(t *testing.T) {
+	rules := farm.DefaultRules()
+	rules.WiltAfter = 0
+	w := farm.NewWorld(rules)
+	var ledger []*farm.Event
+
+	item, _ := farm.LookupItem("security-fence")
+	record(w, rules, &ledger, &farm.Event{Type: farm.EventOpeningBalance, At: t0, Coins: item.Cost(1) + item.UpkeepCost(1)})
+	ev, err := w.Purchase(item.Slug, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record(w, rules, &ledger, ev)
+
+	// Two intervals pass: the first upkeep is paid, the second cannot be.
+	events := w.Decay(rules, t0.Add(2*rules.UpkeepInterval))
+	if len(events) != 2 {
+		t.Fatalf("got %d decay events, want 2: %+v", len(events), events)
+	}
+	if events[0].Type != farm.EventUpkeep || events[0].Coins != -item.UpkeepCost(1) {
+		t.Errorf("first event = %+v, want paid upkeep", events[0])
+	}
+	if events[1].Type != farm.EventUpkeepMissed || events[1].Coins != 0 {
+		t.Errorf("second event = %+v, want missed upkeep", events[1])
+	}
+	for _, e := range events {
+		record(w, rules, &ledger, e)
+	}
+
+	if w.Balance != 0 {
+		t.Errorf("balance = %d, want 0", w.Balance)
+	}
+	if b := w.Building(item.Slug); !b.Neglected {
+		t.Error("building not neglected after missed upkeep")
+	}
+	if got := w.UpgradeMultiplier(proof.CategorySecurity); got != 1.0 {
+		t.Errorf("neglected building still boosts: multiplier %v", got)
+	}
+}
+
+func TestWorld_DecayBreaksStreak(t *testing.T) {
+	rules := farm.DefaultRules()
+	w := farm.NewWorld(rules)
+	w.Apply(farm.Reward("p1", "agent-1", proof.CategoryToil, 10, t0), rules)
+	w.Apply(farm.Reward("p2", "agent-1", proof.CategoryToil, 10, t0.Add(24*time.Hour)), rules)
+
+	// The streak survives the whole of the next UTC day.
+	if got := w.Decay(rules, t0.Add(62*time.Hour)); len(got) != 0 {
+		t.Fatalf("streak broken early: %+v", got)
+	}
+
+	events := w.Decay(rules, t0.Add(64*time.Hour))
+	if len(events) != 1 || events[0].Type != farm.EventStreakBroken {
+		t.Fatalf("decay = %+v, want one streak_broken event", events)
+	}
+	if want := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC); !events[0].At.Equal(want) {
+		t.Errorf("streak broken at %v, want %v", events[0].At, want)
+	}
+	w.Apply(events[0], rules)
+	if w.StreakDays != 0 {
+		t.Errorf("streak = %d after break, want 0", w.StreakDays)
+	}
+	if got := w.Decay(rules, t0.Add(100*time.Hour)); len(got) != 0 {
+		t.Errorf("broken streak decayed again: %+v", got)
+	}
+}
@@ -0,0 +1,199 @@
+// Package farm defines the farm world model and the ledger events it is
+// projected from. The Stats Tracker records every coin movement as an Event
+// in an append-only ledger; the World (plots, crops, buildings, balance) is a
+// deterministic projection of that ledger and can always be rebuilt by replay.
+package farm
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event types recorded in the ledger.
+const (
+	EventOpeningBalance = "opening_balance" // carries coins earned before the ledger existed
+	EventProofReward    = "proof_reward"    // coins awarded for an accepted proof
+	EventPlant          = "plant"           // a crop was planted on a plot
+	EventHarvest        = "harvest"         // a ripe crop was harvested for coins
+	EventPurchase       = "purchase"        // a shop item was bought or upgraded
+	EventWilt           = "wilt"            // a crop wilted because its category went idle
+	EventUpkeep         = "upkeep"          // building upkeep was paid
+	EventUpkeepMissed   = "upkeep_missed"   // building upkeep was due but the balance could not cover it
+	EventStreakBroken   = "streak_broken"   // a whole UTC day passed without a rewarded proof
+	EventRewardReversed = "reward_reversed" // a proof reward was taken back, e.g. after its agent was compromised
+)
+
+// Event is a single entry in the tracker's coin ledger.
+// Coins is the signed balance delta; every other field is context for the
+// projection. Events are immutable once recorded.
+type Event struct {
+	Seq      uint64    `json:"seq"`
+	Type     string    `json:"type"`
+	At       time.Time `json:"at"`
+	Coins    int       `json:"coins"`
+	ProofID  string    `json:"proof_id,omitempty"`
+	AgentID  string    `json:"agent_id,omitempty"`
+	Category string    `json:"category,omitempty"`
+	Item     string    `json:"item,omitempty"` // shop item slug
+	Plot     int       `json:"plot,omitempty"` // 1-based plot ID
+}
+
+// World is the materialized farm: everything a renderer or dashboard needs.
+type World struct {
+	TotalCoins   int                       `json:"total_coins"` // lifetime earnings
+	Balance      int                       `json:"balance"`     // earnings minus spending; below zero is debt, see Replay
+	StreakDays   int                       `json:"streak_days"`
+	LastActiveAt *time.Time                `json:"last_active_at,omitempty"`
+	Plots        []Plot                    `json:"plots"`
+	Buildings    []Building                `json:"buildings"`
+	Categories   map[string]*CategoryStats `json:"categories"`
+	Achievements []Achievement             `json:"achievements"`
+	LastSeq      uint64                    `json:"last_seq"` // Seq of the last applied event
+}
+
+// Plot is a patch of land that holds at most one crop.
+type Plot struct {
+	ID   int   `json:"id"`
+	Crop *Crop `json:"crop,omitempty"`
+}
+
+// Crop grows on a plot as proofs of its category arrive.
+type Crop struct {
+	Category  string    `json:"category"`
+	PlantedAt time.Time `json:"planted_at"`
+	Growth    int       `json:"growth"` // matching proofs received since planting
+	Ripe      bool      `json:"ripe"`
+	Wilted    bool      `json:"wilted"`
+}
+
+// Building is a shop item placed on the farm.
+type Building struct {
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Category    string    `json:"category"`
+	Level       int       `json:"level"`
+	PurchasedAt time.Time `json:"purchased_at"`
+	UpgradedAt  time.Time `json:"upgraded_at"`
+	UpkeepDueAt time.Time `json:"upkeep_due_at"`
+	Neglected   bool      `json:"neglected"` // last upkeep was missed; boosts are suspended
+}
+
+// CategoryStats aggregates rewarded proofs per category.
+type CategoryStats struct {
+	Proofs      int        `json:"proofs"`
+	Coins       int        `json:"coins"`
+	LastProofAt *time.Time `json:"last_proof_at,omitempty"`
+}
+
+// Rules holds the tunable parameters of the farm simulation.
+type Rules struct {
+	// Plots is the number of plots on a new farm.
+	Plots int
+
+	// SeedCost is the price of planting a crop.
+	SeedCost int
+
+	// GrowthToRipe is the number of matching proofs a crop needs before it can be harvested.
+	GrowthToRipe int
+
+	// HarvestCoins is the yield of a ripe crop.
+	HarvestCoins int
+
+	// WiltAfter is how long a category may go without proofs before its crops wilt.
+	// Zero disables wilting.
+	WiltAfter time.Duration
+
+	// UpkeepInterval is how often buildings charge their upkeep.
+	// Zero disables upkeep.
+	UpkeepInterval time.Duration
+}
+
+// DefaultRules returns the default farm rules.
+func DefaultRules() Rules {
+	return Rules{
+		Plots:          6,
+		SeedCost:       5,
+		GrowthToRipe:   5,
+		HarvestCoins:   40,
+		WiltAfter:      72 * time.Hour,
+		UpkeepInterval: 7 * 24 * time.Hour,
+	}
+}
+
+// NewWorld returns an empty world laid out according to rules.
+func NewWorld(rules Rules) *World {
+	w := &World{
+		Plots:        make([]Plot, rules.Plots),
+		Buildings:    []Building{},
+		Categories:   map[string]*CategoryStats{},
+		Achievements: []Achievement{},
+	}
+	for i := range w.Plots {
+		w.Plots[i].ID = i + 1
+	}
+	return w
+}
+
+// Plot returns the plot with the given 1-based ID.
+func (w *World) Plot(id int) (*Plot, error) {
+	if id < 1 || id > len(w.Plots) {
+		return nil, fmt.Errorf("farm: plot %d does not exist", id)
+	}
+	return &w.Plots[id-1], nil
+}
+
+// Building returns the placed building with the given slug, or nil.
+func (w *World) Building(slug string) *Building {
+	for i := range w.Buildings {
+		if w.Buildings[i].Slug == slug {
+			return &w.Buildings[i]
+		}
+	}
+	return nil
+}
+
+// UpgradeMultiplier returns the combined scoring multiplier of all buildings
+// boosting the given category (1.0 if none). Neglected buildings do not boost.
+func (w *World) UpgradeMultiplier(category string) float64 {
+	mult := 1.0
+	for _, b := range w.Buildings {
+		item, ok := LookupItem(b.Slug)
+		if !ok || item.Category != category || b.Neglected {
+			continue
+		}
+		mult *= 1.0 + float64(b.Level)*item.BoostPerLevel
+	}
+	return mult
+}
+
+// StreakBonusDays returns the 0-based streak a reward at time at would earn,
+// suitable for scoring.Compute.
+func (w *World) StreakBonusDays(at time.Time) int {
+	return nextStreak(w.StreakDays, w.LastActiveAt, at) - 1
+}
+
+// nextStreak returns the streak length after activity at time at, given the
+// current streak and the time of the previous activity. Days are UTC days.
+func nextStreak(current int, last *time.Time, at time.Time) int {
+	if last == nil {
+		return 1
+	}
+	switch dayNumber(at) - dayNumber(*last) {
+	case 0:
+		return max(current, 1)
+	case 1:
+		return current + 1
+	default:
+		return 1
+	}
+}
+
+func dayNumber(t time.Time) int64 {
+	return t.UTC().Unix() / 86400
+}
+
+// streakBreaksAt returns when a streak last active at last is broken: the
+// start of the second UTC day after it, once a whole day has gone by idle.
+func streakBreaksAt(last time.Time) time.Time {
+	return time.Unix((dayNumber(last)+2)*86400, 0).UTC()
+}
@@ -0,0 +1,230 @@
+package farm
+
+import (
+	"fmt"
+	"time"
+)
+
+// RuleError reports a command that the farm rules do not allow.
+type RuleError string
+
+func (e RuleError) Error() string { return string(e) }
+
+// Rule violations returned by the command builders.
+var (
+	ErrInsufficientCoins = RuleError("not enough coins")
+	ErrPlotOccupied      = RuleError("plot already has a crop")
+	ErrPlotEmpty         = RuleError("plot has no crop")
+	ErrNotRipe           = RuleError("crop is not ripe yet")
+	ErrUnknownItem       = RuleError("unknown shop item")
+	ErrMaxLevel          = RuleError("item is already at max level")
+	ErrUnknownCategory   = RuleError("unknown category")
+)
+
+// Replay projects a world from an ordered ledger. Rewards that were later
+// reversed are left out together with their reversals, so streaks, crops,
+// category stats and achievements come out as if they had never been awarded.
+//
+// Only the rewards are taken back. What the farm did with the coins stands:
+// plantings, harvests, purchases, upkeep and streak events stay as recorded,
+// even where the replayed world could not have afforded or produced them. The
+// balance can therefore end up negative, which is the farm's debt. A farm in
+// debt cannot plant or buy and misses its upkeep, so its buildings lose their
+// boost, until later rewards and harvests pay the debt off.
+func Replay(events []*Event, rules Rules) *World {
+	reversed := map[string]bool{}
+	for _, e := range events {
+		if e.Type == EventRewardReversed {
+			reversed[e.ProofID] = true
+		}
+	}
+	w := NewWorld(rules)
+	for _, e := range events {
+		if reversed[e.ProofID] && (e.Type == EventProofReward || e.Type == EventRewardReversed) {
+			w.LastSeq = max(w.LastSeq, e.Seq)
+			continue
+		}
+		w.Apply(e, rules)
+	}
+	return w
+}
+
+// Apply folds a single ledger event into the world. Events are trusted: they
+// were validated by the command that produced them, so Apply never fails and
+// replaying the same ledger always yields the same world.
+func (w *World) Apply(e *Event, rules Rules) {
+	w.Balance += e.Coins
+	if e.Seq > w.LastSeq {
+		w.LastSeq = e.Seq
+	}
+	w.apply(e, rules)
+	w.unlockAchievements(e)
+}
+
+func (w *World) apply(e *Event, rules Rules) {
+	switch e.Type {
+	case EventOpeningBalance:
+		w.TotalCoins += e.Coins
+
+	case EventProofReward:
+		w.TotalCoins += e.Coins
+		w.StreakDays = nextStreak(w.StreakDays, w.LastActiveAt, e.At)
+		at := e.At
+		w.LastActiveAt = &at
+
+		cs := w.Categories[e.Category]
+		if cs == nil {
+			cs = &CategoryStats{}
+			w.Categories[e.Category] = cs
+		}
+		cs.Proofs++
+		cs.Coins += e.Coins
+		cs.LastProofAt = &at
+
+		for i := range w.Plots {
+			c := w.Plots[i].Crop
+			if c == nil || c.Ripe || c.Wilted || c.Category != e.Category {
+				continue
+			}
+			c.Growth++
+			c.Ripe = c.Growth >= rules.GrowthToRipe
+		}
+
+	case EventPlant:
+		if p, err := w.Plot(e.Plot); err == nil {
+			p.Crop = &Crop{Category: e.Category, PlantedAt: e.At}
+		}
+
+	case EventHarvest:
+		w.TotalCoins += e.Coins
+		if p, err := w.Plot(e.Plot); err == nil {
+			p.Crop = nil
+		}
+
+	case EventPurchase:
+		if b := w.Building(e.Item); b != nil {
+			b.Level++
+			b.UpgradedAt = e.At
+			return
+		}
+		item, _ := LookupItem(e.Item)
+		b := Building{
+			Slug:        e.Item,
+			Name:        item.Name,
+			Category:    item.Category,
+			Level:       1,
+			PurchasedAt: e.At,
+			UpgradedAt:  e.At,
+		}
+		if rules.UpkeepInterval > 0 {
+			b.UpkeepDueAt = e.At.Add(rules.UpkeepInterval)
+		}
+		w.Buildings = append(w.Buildings, b)
+
+	case EventWilt:
+		if p, err := w.Plot(e.Plot); err == nil && p.Crop != nil {
+			p.Crop.Wilted = true
+		}
+
+	case EventUpkeep, EventUpkeepMissed:
+		if b := w.Building(e.Item); b != nil {
+			b.Neglected = e.Type == EventUpkeepMissed
+			b.UpkeepDueAt = b.upkeepDue(rules).Add(rules.UpkeepInterval)
+		}
+
+	case EventStreakBroken:
+		w.StreakDays = 0
+
+	case EventRewardReversed:
+		// Applied on its own, a reversal can only take the coins back; Replay
+		// drops the reward altogether.
+		w.TotalCoins += e.Coins
+		if cs := w.Categories[e.Category]; cs != nil {
+			cs.Proofs--
+			cs.Coins += e.Coins
+		}
+	}
+}
+
+// Reward builds the ledger event for coins awarded to an accepted proof.
+func Reward(proofID, agentID, category string, coins int, at time.Time) *Event {
+	return &Event{
+		Type:     EventProofReward,
+		At:       at,
+		Coins:    coins,
+		ProofID:  proofID,
+		AgentID:  agentID,
+		Category: category,
+	}
+}
+
+// Reversal builds the compensating ledger event that takes back a proof reward.
+func Reversal(reward *Event, at time.Time) *Event {
+	return &Event{
+		Type:     EventRewardReversed,
+		At:       at,
+		Coins:    -reward.Coins,
+		ProofID:  reward.ProofID,
+		AgentID:  reward.AgentID,
+		Category: reward.Category,
+	}
+}
+
+// Plant builds the ledger event for planting a crop of the given category.
+func (w *World) Plant(rules Rules, plot int, category string, at time.Time) (*Event, error) {
+	if category == "" {
+		return nil, ErrUnknownCategory
+	}
+	p, err := w.Plot(plot)
+	if err != nil {
+		return nil, RuleError(err.Error())
+	}
+	if p.Crop != nil {
+		return nil, ErrPlotOccupied
+	}
+	if w.Balance < rules.SeedCost {
+		return nil, ErrInsufficientCoins
+	}
+	return &Event{Type: EventPlant, At: at, Coins: -rules.SeedCost, Category: category, Plot: plot}, nil
+}
+
+// Harvest builds the ledger event for harvesting a ripe crop. A wilted crop
+// can be cleared the same way, but yields nothing.
+func (w *World) Harvest(rules Rules, plot int, at time.Time) (*Event, error) {
+	p, err := w.Plot(plot)
+	if err != nil {
+		return nil, RuleError(err.Error())
+	}
+	if p.Crop == nil {
+		return nil, ErrPlotEmpty
+	}
+	yield := rules.HarvestCoins
+	switch {
+	case p.Crop.Wilted:
+		yield = 0
+	case !p.Crop.Ripe:
+		return nil, ErrNotRipe
+	}
+	return &Event{Type: EventHarvest, At: at, Coins: yield, Category: p.Crop.Category, Plot: plot}, nil
+}
+
+// Purchase builds the ledger event for buying a shop item, or upgrading it
+// by one level if it is already placed.
+func (w *World) Purchase(slug string, at time.Time) (*Event, error) {
+	item, ok := LookupItem(slug)
+	if !ok {
+		return nil, ErrUnknownItem
+	}
+	level := 1
+	if b := w.Building(slug); b != nil {
+		level = b.Level + 1
+	}
+	if level > item.MaxLevel {
+		return nil, ErrMaxLevel
+	}
+	cost := item.Cost(level)
+	if w.Balance < cost {
+		return nil, fmt.Errorf("%w: %s level %d costs %d, balance is %d", ErrInsufficientCoins, item.Name, level, cost, w.Balance)
+	}
+	return &Event{Type: EventPurchase, At: at, Coins: -cost, Category: item.Category, Item: slug}, nil
+}
@@ -0,0 +1,202 @@
+package farm_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+var t0 = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+// record validates nothing; it mimics the tracker appending events to the ledger.
+func record(w *farm.World, rules farm.Rules, ledger *[]*farm.Event, e *farm.Event) {
+	e.Seq = uint64(len(*ledger) + 1)
+	*ledger = append(*ledger, e)
+	w.Apply(e, rules)
+}
+
+func TestWorld_PlantGrowHarvest(t *testing.T) {
+	rules := farm.DefaultRules()
+	w := farm.NewWorld(rules)
+	var ledger []*farm.Event
+
+	record(w, rules, &ledger, farm.Reward("p0", "agent-1", proof.CategorySecurity, 25, t0))
+
+	ev, err := w.Plant(rules, 1, proof.CategorySecurity, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record(w, rules, &ledger, ev)
+
+	if _, err := w.Plant(rules, 1, proof.CategoryToil, t0); !errors.Is(err, farm.ErrPlotOccupied) {
+		t.Errorf("planting an occupied plot: got %v, want ErrPlotOccupied", err)
+	}
+	if _, err := w.Harvest(rules, 1, t0); !errors.Is(err, farm.ErrNotRipe) {
+		t.Errorf("harvesting an unripe crop: got %v, want ErrNotRipe", err)
+	}
+
+	// Proofs of another category must not grow the crop.
+	record(w, rules, &ledger, farm.Reward("px", "agent-1", proof.CategoryMaintenance, 10, t0))
+	if got := w.Plots[0].Crop.Growth; got != 0 {
+		t.Fatalf("maintenance proof grew security crop to %d", got)
+	}
+
+	for i := 0; i < rules.GrowthToRipe; i++ {
+		record(w, rules, &ledger, farm.Reward("p", "agent-1", proof.CategorySecurity, 25, t0))
+	}
+	if !w.Plots[0].Crop.Ripe {
+		t.Fatalf("crop not ripe after %d matching proofs", rules.GrowthToRipe)
+	}
+
+	ev, err = w.Harvest(rules, 1, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := w.Balance
+	record(w, rules, &ledger, ev)
+	if w.Plots[0].Crop != nil {
+		t.Error("plot still has a crop after harvest")
+	}
+	if w.Balance != before+rules.HarvestCoins {
+		t.Errorf("balance after harvest = %d, want %d", w.Balance, before+rules.HarvestCoins)
+	}
+
+	if replayed := farm.Replay(ledger, rules); !reflect.DeepEqual(replayed, w) {
+		t.Errorf("replayed world differs from incrementally projected world:\n got %+v\nwant %+v", replayed, w)
+	}
+}
+
+func TestWorld_PurchaseBoostsCategory(t *testing.T) {
+	rules := farm.DefaultRules()
+	w := farm.NewWorld(rules)
+	var ledger []*farm.Event
+
+	if _, err := w.Purchase("security-fence", t0); !errors.Is(err, farm.ErrInsufficientCoins) {
+		t.Fatalf("purchase with empty balance: got %v, want ErrInsufficientCoins", err)
+	}
+	if _, err := w.Purchase("golden-statue", t0); !errors.Is(err, farm.ErrUnknownItem) {
+		t.Fatalf("purchase of unknown item: got %v, want ErrUnknownItem", err)
+	}
+
+	record(w, rules, &ledger, &farm.Event{Type: farm.EventOpeningBalance, At: t0, Coins: 1000})
+	for i := 0; i < 2; i++ {
+		ev, err := w.Purchase("security-fence", t0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record(w, rules, &ledger, ev)
+	}
+
+	b := w.Building("security-fence")
+	if b == nil || b.Level != 2 {
+		t.Fatalf("security-fence = %+v, want level 2", b)
+	}
+	if got := w.UpgradeMultiplier(proof.CategorySecurity); got <= 1.0 {
+		t.Errorf("security multiplier = %v, want > 1.0", got)
+	}
+	if got := w.UpgradeMultiplier(proof.CategoryToil); got != 1.0 {
+		t.Errorf("toil multiplier = %v, want 1.0", got)
+	}
+}
+
+func TestWorld_Streak(t *testing.T) {
+	rules := farm.DefaultRules()
+	w := farm.NewWorld(rules)
+
+	days := []time.Time{t0, t0.Add(2 * time.Hour), t0.Add(24 * time.Hour), t0.Add(48 * time.Hour), t0.Add(120 * time.Hour)}
+	want := []int{1, 1, 2, 3, 1}
+	for i, at := range days {
+		w.Apply(farm.Reward("p", "agent-1", proof.CategoryMaintenance, 10, at), rules)
+		if w.StreakDays != want[i] {
+			t.Errorf("after reward %d: streak = %d, want %d", i, w.StreakDays, want[i])
+		}
+	}
+}
+
+func TestReplay_ReversedRewardIsForgotten(t *testing.T) {
+	rules := farm.DefaultRules()
+	w := farm.NewWorld(rules)
+	var ledger []*farm.Event
+
+	good := farm.Reward("p1", "agent-1", proof.CategorySecurity, 25, t0)
+	bad := farm.Reward("p2", "agent-2", proof.CategorySecurity, 40, t0.Add(24*time.Hour))
+	record(w, rules, &ledger, good)
+	record(w, rules, &ledger, bad)
+	record(w, rules, &ledger, farm.Reversal(bad, t0.Add(48*time.Hour)))
+
+	if w.Balance != 25 || w.TotalCoins != 25 {
+		t.Errorf("applied reversal: balance %d, total %d; want 25, 25", w.Balance, w.TotalCoins)
+	}
+
+	got := farm.Replay(ledger, rules)
+	want := farm.Replay(ledger[:1], rules)
+	want.LastSeq = got.LastSeq
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("replay with reversed reward:\n got %+v\nwant %+v", got, want)
+	}
+	if got.StreakDays != 1 {
+		t.Errorf("streak = %d, want 1", got.StreakDays)
+	}
+	if got.LastSeq != 3 {
+		t.Errorf("last seq = %d, want 3", got.LastSeq)
+	}
+}
+
+func TestReplay_ReversalAfterSpendingLeavesDebt(t *testing.T) {
+	rules := farm.DefaultRules()
+	w := farm.NewWorld(rules)
+	var ledger []*farm.Event
+
+	item, _ := farm.LookupItem("maintenance-barn")
+	bad := farm.Reward("p1", "agent-1", proof.CategoryMaintenance, item.Cost(1)+rules.SeedCost, t0)
+	record(w, rules, &ledger, bad)
+	ev, err := w.Purchase(item.Slug, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record(w, rules, &ledger, ev)
+	if ev, err = w.Plant(rules, 1, proof.CategoryMaintenance, t0); err != nil {
+		t.Fatal(err)
+	}
+	record(w, rules, &ledger, ev)
+	record(w, rules, &ledger, farm.Reversal(bad, t0.Add(time.Hour)))
+
+	got := farm.Replay(ledger, rules)
+	if debt := item.Cost(1) + rules.SeedCost; got.Balance != -debt || got.TotalCoins != 0 {
+		t.Errorf("balance %d, total %d; want a debt of %d and no earnings", got.Balance, got.TotalCoins, debt)
+	}
+	if b := got.Building(item.Slug); b == nil || b.Level != 1 {
+		t.Errorf("building after the reversal = %+v, want it kept", b)
+	}
+	if got.Plots[0].Crop == nil {
+		t.Error("crop after the reversal is gone, want it kept")
+	}
+
+	// In debt, the farm can neither buy nor plant, and misses its upkeep.
+	if _, err := got.Purchase(item.Slug, t0); !errors.Is(err, farm.ErrInsufficientCoins) {
+		t.Errorf("purchase in debt: got %v, want ErrInsufficientCoins", err)
+	}
+	if _, err := got.Plant(rules, 2, proof.CategoryMaintenance, t0); !errors.Is(err, farm.ErrInsufficientCoins) {
+		t.Errorf("plant in debt: got %v, want ErrInsufficientCoins", err)
+	}
+	missed := false
+	for _, e := range got.Decay(rules, t0.Add(rules.UpkeepInterval)) {
+		if e.Type == farm.EventUpkeep {
+			t.Errorf("upkeep paid in debt: %+v", e)
+		}
+		missed = missed || e.Type == farm.EventUpkeepMissed
+	}
+	if !missed {
+		t.Error("no missed upkeep in debt")
+	}
+
+	// Later rewards pay the debt off.
+	got.Apply(farm.Reward("p2", "agent-2", proof.CategoryMaintenance, 500, t0.Add(2*time.Hour)), rules)
+	if want := 500 - item.Cost(1) - rules.SeedCost; got.Balance != want {
+		t.Errorf("balance after a new reward = %d, want %d", got.Balance, want)
+	}
+}
@@ -0,0 +1,52 @@
+package farm
+
+import "github.com/farmops/farmops/pkg/proof"
+
+// Item is a building that can be bought in the shop and upgraded in levels.
+// Each level multiplies coins earned in the item's category.
+type Item struct {
+	Slug          string  `json:"slug"`
+	Name          string  `json:"name"`
+	Category      string  `json:"category"`
+	Description   string  `json:"description"`
+	BaseCost      int     `json:"base_cost"`
+	MaxLevel      int     `json:"max_level"`
+	BoostPerLevel float64 `json:"boost_per_level"`
+	Upkeep        int     `json:"upkeep"` // coins per level per upkeep interval
+}
+
+// Cost returns the price of raising the item to the given level.
+func (i Item) Cost(level int) int {
+	return i.BaseCost * level
+}
+
+// UpkeepCost returns the upkeep charged for the item at the given level.
+func (i Item) UpkeepCost(level int) int {
+	return i.Upkeep * level
+}
+
+var catalog = []Item{
+	{Slug: "maintenance-barn", Name: "Maintenance Barn", Category: proof.CategoryMaintenance, Description: "Stores the tools of the daily routine.", BaseCost: 100, MaxLevel: 5, BoostPerLevel: 0.05, Upkeep: 5},
+	{Slug: "ci-windmill", Name: "CI Windmill", Category: proof.CategoryToil, Description: "Turns automation into steady wind.", BaseCost: 150, MaxLevel: 5, BoostPerLevel: 0.05, Upkeep: 8},
+	{Slug: "reliability-well", Name: "Reliability Well", Category: proof.CategoryReliability, Description: "Never runs dry.", BaseCost: 200, MaxLevel: 5, BoostPerLevel: 0.05, Upkeep: 10},
+	{Slug: "security-fence", Name: "Security Fence", Category: proof.CategorySecurity, Description: "Keeps the foxes out.", BaseCost: 250, MaxLevel: 5, BoostPerLevel: 0.05, Upkeep: 12},
+	{Slug: "incident-watchtower", Name: "Incident Watchtower", Category: proof.CategoryIncident, Description: "Sees the fire before the smoke.", BaseCost: 300, MaxLevel: 5, BoostPerLevel: 0.05, Upkeep: 15},
+	{Slug: "upgrade-workshop", Name: "Upgrade Workshop", Category: proof.CategoryUpgrade, Description: "Where old tractors become new.", BaseCost: 200, MaxLevel: 5, BoostPerLevel: 0.05, Upkeep: 10},
+}
+
+// Catalog returns all items available in the shop.
+func Catalog() []Item {
+	out := make([]Item, len(catalog))
+	copy(out, catalog)
+	return out
+}
+
+// LookupItem returns the shop item with the given slug.
+func LookupItem(slug string) (Item, bool) {
+	for _, it := range catalog {
+		if it.Slug == slug {
+			return it, true
+		}
+	}
+	return Item{}, false
+}
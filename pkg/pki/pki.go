@@ -0,0 +1,171 @@
+// Package pki mints the small private PKI used for mutual TLS between agents
+// and the Stats Tracker: a local CA, a tracker server certificate, and one
+// client certificate per agent. An agent certificate carries the agent_id as
+// its subject common name, which the tracker can bind to submitted proofs.
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+// Organization is the subject organization of every certificate minted here.
+const Organization = "FarmOps"
+
+// Certificate is a certificate together with its private key.
+type Certificate struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// NewCA creates a self-signed CA certificate valid for the given duration.
+func NewCA(name string, validFor time.Duration) (*Certificate, error) {
+	tmpl := &x509.Certificate{
+		Subject:               pkix.Name{Organization: []string{Organization}, CommonName: name},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	return issue(tmpl, nil, validFor)
+}
+
+// IssueServer issues a server certificate for the given DNS names and IP addresses.
+func (ca *Certificate) IssueServer(hosts []string, validFor time.Duration) (*Certificate, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("pki: server certificate needs at least one host")
+	}
+	tmpl := &x509.Certificate{
+		Subject:     pkix.Name{Organization: []string{Organization}, CommonName: hosts[0]},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	return issue(tmpl, ca, validFor)
+}
+
+// IssueAgent issues a client certificate identifying the given agent.
+func (ca *Certificate) IssueAgent(agentID string, validFor time.Duration) (*Certificate, error) {
+	if agentID == "" {
+		return nil, errors.New("pki: agent certificate needs an agent ID")
+	}
+	tmpl := &x509.Certificate{
+		Subject:     pkix.Name{Organization: []string{Organization}, CommonName: agentID},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	return issue(tmpl, ca, validFor)
+}
+
+// AgentID returns the agent identity carried by a client certificate.
+func AgentID(cert *x509.Certificate) string {
+	return cert.Subject.CommonName
+}
+
+// issue signs tmpl with parent, or self-signs it if parent is nil.
+func issue(tmpl *x509.Certificate, parent *Certificate, validFor time.Duration) (*Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("pki: generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("pki: generate serial: %w", err)
+	}
+	now := time.Now()
+	tmpl.SerialNumber = serial
+	tmpl.NotBefore = now.Add(-5 * time.Minute) // tolerate small clock skew
+	tmpl.NotAfter = now.Add(validFor)
+
+	signerCert, signerKey := tmpl, crypto.Signer(key)
+	if parent != nil {
+		signerCert, signerKey = parent.Cert, parent.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert, key.Public(), signerKey)
+	if err != nil {
+		return nil, fmt.Errorf("pki: create certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("pki: parse certificate: %w", err)
+	}
+	return &Certificate{Cert: cert, Key: key}, nil
+}
+
+// CertPEM returns the certificate PEM-encoded.
+func (c *Certificate) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw})
+}
+
+// KeyPEM returns the private key as PKCS#8 PEM.
+func (c *Certificate) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(c.Key)
+	if err != nil {
+		return nil, fmt.Errorf("pki: marshal key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// WriteFiles writes the certificate and key PEM files. The key file is
+// created with mode 0600.
+func (c *Certificate) WriteFiles(certFile, keyFile string) error {
+	keyPEM, err := c.KeyPEM()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(certFile, c.CertPEM(), 0644); err != nil {
+		return fmt.Errorf("pki: write %s: %w", certFile, err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return fmt.Errorf("pki: write %s: %w", keyFile, err)
+	}
+	return nil
+}
+
+// Load reads a certificate and key written by WriteFiles.
+func Load(certFile, keyFile string) (*Certificate, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("pki: load %s: %w", certFile, err)
+	}
+	signer, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("pki: %s: unsupported key type", keyFile)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("pki: parse %s: %w", certFile, err)
+	}
+	return &Certificate{Cert: cert, Key: signer}, nil
+}
+
+// LoadCertPool reads PEM certificates from file into a new pool.
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("pki: read %s: %w", file, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("pki: %s: no PEM certificates found", file)
+	}
+	return pool, nil
+}
@@ -1,6 +1,7 @@
 package proof
 
 import (
+	"context"
 	"crypto/ed25519"
 	"encoding/hex"
 	"fmt"
@@ -9,7 +10,9 @@ import (
 // Chain validates the integrity of an ordered slice of proofs.
 // It verifies that:
 //  1. Each proof's PrevProofHash matches the actual hash of the preceding proof.
-//  2. Each proof's signature is valid against the provided public key.
+//  2. Each proof's signature is valid against the agent's key at that point:
+//     pubKey for the first proof, and after a key rotation proof, the key it
+//     introduced.
 //
 // proofs must be ordered oldest-first. The first proof is treated as genesis
 // (PrevProofID and PrevProofHash may be empty).
@@ -19,6 +22,13 @@ func Chain(proofs []*FarmProof, pubKey ed25519.PublicKey) error {
 		if err := Verify(p, pubKey); err != nil {
 			return fmt.Errorf("chain: proof %d (%s): invalid signature: %w", i, p.ProofID, err)
 		}
+		if p.KeyRotation != nil {
+			next, err := p.KeyRotation.PublicKey()
+			if err != nil {
+				return fmt.Errorf("chain: proof %d (%s): %w", i, p.ProofID, err)
+			}
+			pubKey = next
+		}
 
 		if i == 0 {
 			// Genesis proof: no previous hash required.
@@ -46,13 +56,7 @@ func Chain(proofs []*FarmProof, pubKey ed25519.PublicKey) error {
 // Sign signs the proof's canonical JSON with the given Ed25519 private key
 // and sets p.Signature to the hex-encoded signature.
 func Sign(p *FarmProof, privKey ed25519.PrivateKey) error {
-	payload, err := p.CanonicalJSON()
-	if err != nil {
-		return fmt.Errorf("sign: %w", err)
-	}
-	sig := ed25519.Sign(privKey, payload)
-	p.Signature = hex.EncodeToString(sig)
-	return nil
+	return SignWith(context.Background(), p, KeySigner(privKey))
 }
 
 // Verify checks that the proof's signature is valid against the given public key.
@@ -70,3 +74,174 @@ func Verify(p *FarmProof, pubKey ed25519.PublicKey) error {
 	}
 	return nil
 }
+
+// Problems reported by CheckChain.
+const (
+	IssueSignature = "signature" // signature does not verify against the agent's key
+	IssueLink      = "link"      // prev_proof_hash does not match the previous proof
+	IssueGap       = "gap"       // prev_proof_id names a proof that is missing
+	IssueFork      = "fork"      // more than one proof follows the same proof
+	IssueDuplicate = "duplicate" // the same proof ID appears more than once
+	IssueOrphan    = "orphan"    // unreachable from the start of the chain
+)
+
+// ChainIssue is one problem found by CheckChain.
+type ChainIssue struct {
+	Kind    string
+	ProofID string
+	Detail  string
+}
+
+func (i ChainIssue) String() string {
+	return fmt.Sprintf("%s at proof %s: %s", i.Kind, i.ProofID, i.Detail)
+}
+
+// ChainReport is the result of CheckChain.
+type ChainReport struct {
+	Proofs      int    // distinct proofs checked
+	Verified    int    // proofs whose signature and link to their predecessor hold
+	Start       string // the first proof of the chain
+	StartsAfter string // for a chain that does not start at genesis, Start's prev_proof_id
+	Head        string // the last proof, following the first branch at forks
+	Issues      []ChainIssue
+}
+
+// OK reports whether the chain verified without issues.
+func (r *ChainReport) OK() bool { return len(r.Issues) == 0 }
+
+// CheckChain verifies one agent's proofs like Chain, but accepts them in any
+// order and, instead of stopping at the first failure, reports every broken
+// signature and link, gaps, forks and duplicates. Issues are listed in chain
+// order, so the first is the first broken link.
+//
+// The chain starts at its genesis proof if present, otherwise at the first
+// proof whose predecessor is missing (a partial export); other proofs with a
+// missing predecessor are gaps. genesisKey verifies the chain from genesis;
+// knownKeys are the agent's later keys, tried where the chain does not start
+// at genesis or resumes after a gap.
+func CheckChain(proofs []*FarmProof, genesisKey ed25519.PublicKey, knownKeys ...ed25519.PublicKey) *ChainReport {
+	r := &ChainReport{}
+	byID := make(map[string]*FarmProof, len(proofs))
+	var order []*FarmProof
+	for _, p := range proofs {
+		if _, dup := byID[p.ProofID]; dup {
+			r.Issues = append(r.Issues, ChainIssue{IssueDuplicate, p.ProofID, "proof appears more than once"})
+			continue
+		}
+		byID[p.ProofID] = p
+		order = append(order, p)
+	}
+	r.Proofs = len(order)
+
+	children := make(map[string][]*FarmProof)
+	var roots []*FarmProof
+	for _, p := range order {
+		if _, ok := byID[p.PrevProofID]; ok && p.PrevProofID != "" {
+			children[p.PrevProofID] = append(children[p.PrevProofID], p)
+		} else {
+			roots = append(roots, p)
+		}
+	}
+	// Start from genesis if the chain has one.
+	for i, p := range roots {
+		if p.PrevProofID == "" {
+			roots[0], roots[i] = roots[i], roots[0]
+			break
+		}
+	}
+
+	keys := append([]ed25519.PublicKey{genesisKey}, knownKeys...)
+	visited := make(map[string]bool, len(order))
+	type step struct {
+		p, prev *FarmProof
+		key     ed25519.PublicKey
+	}
+	for i, root := range roots {
+		key := genesisKey
+		switch {
+		case i == 0 && root.PrevProofID != "":
+			r.StartsAfter = root.PrevProofID
+			key = keyFor(root, keys)
+		case i > 0 && root.PrevProofID == "":
+			r.Issues = append(r.Issues, ChainIssue{IssueFork, root.ProofID, "second genesis proof, after " + roots[0].ProofID})
+		case i > 0:
+			r.Issues = append(r.Issues, ChainIssue{IssueGap, root.ProofID, "previous proof " + root.PrevProofID + " is missing"})
+			key = keyFor(root, keys)
+		}
+		if i == 0 {
+			r.Start = root.ProofID
+		}
+
+		stack := []step{{p: root, key: key}}
+		for len(stack) > 0 {
+			s := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			p := s.p
+			visited[p.ProofID] = true
+
+			ok := true
+			if err := Verify(p, s.key); err != nil {
+				r.Issues = append(r.Issues, ChainIssue{IssueSignature, p.ProofID, err.Error()})
+				ok = false
+			}
+			if s.prev != nil {
+				if prevHash, err := s.prev.Hash(); err != nil || p.PrevProofHash != prevHash {
+					r.Issues = append(r.Issues, ChainIssue{IssueLink, p.ProofID,
+						fmt.Sprintf("prev_proof_hash %s does not match proof %s (%s)", p.PrevProofHash, s.prev.ProofID, prevHash)})
+					ok = false
+				}
+			}
+			if ok {
+				r.Verified++
+			}
+
+			next := s.key
+			if ok && p.KeyRotation != nil {
+				if k, err := p.KeyRotation.PublicKey(); err == nil {
+					next = k
+					keys = append(keys, k)
+				} else {
+					r.Issues = append(r.Issues, ChainIssue{IssueSignature, p.ProofID, err.Error()})
+				}
+			}
+
+			kids := children[p.ProofID]
+			if len(kids) > 1 {
+				ids := make([]string, len(kids))
+				for j, k := range kids {
+					ids[j] = k.ProofID
+				}
+				r.Issues = append(r.Issues, ChainIssue{IssueFork, p.ProofID, fmt.Sprintf("followed by %d proofs: %v", len(kids), ids)})
+			}
+			// Push in reverse so the first branch is checked first.
+			for j := len(kids) - 1; j >= 0; j-- {
+				stack = append(stack, step{p: kids[j], prev: p, key: next})
+			}
+		}
+	}
+
+	for _, p := range order {
+		if !visited[p.ProofID] {
+			r.Issues = append(r.Issues, ChainIssue{IssueOrphan, p.ProofID, "proof links into a cycle"})
+		}
+	}
+
+	if len(roots) > 0 {
+		head := roots[0]
+		for kids := children[head.ProofID]; len(kids) > 0; kids = children[head.ProofID] {
+			head = kids[0]
+		}
+		r.Head = head.ProofID
+	}
+	return r
+}
+
+// keyFor returns the first of keys that verifies p, or keys[0] if none does.
+func keyFor(p *FarmProof, keys []ed25519.PublicKey) ed25519.PublicKey {
+	for _, k := range keys {
+		if Verify(p, k) == nil {
+			return k
+		}
+	}
+	return keys[0]
+}
@@ -108,3 +108,152 @@ func TestChain_TamperedProof(t *testing.T) {
 		t.Error("expected chain validation to fail on tampered proof, but it passed")
 	}
 }
+
+func TestChain_KeyRotation(t *testing.T) {
+	oldPub, oldPriv, err := proof.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPub, newPriv, err := proof.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent := proof.AgentInfo{AgentID: "agent-1", ClusterAlias: "test-cluster"}
+	actor := proof.ActorInfo{ActorHash: proof.HashActor("github:testuser"), ActorType: proof.ActorHuman}
+	action := proof.ActionInfo{Plugin: "farmops/k8s-pod-health", ActionType: proof.ActionVerify, Category: proof.CategoryMaintenance, Description: "check"}
+	outcome := proof.OutcomeInfo{Status: proof.OutcomeSuccess, Verified: true, EvidenceHash: "abc"}
+	hints := proof.ScoringHints{Complexity: proof.ComplexityLow}
+
+	before, _ := proof.New(agent, actor, action, outcome, hints, nil)
+	_ = proof.Sign(before, oldPriv)
+
+	rotation, err := proof.NewKeyRotation(agent, newPub, []byte("pepper"), before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = proof.Sign(rotation, oldPriv)
+
+	after, _ := proof.New(agent, actor, action, outcome, hints, rotation)
+	_ = proof.Sign(after, newPriv)
+
+	if err := proof.Chain([]*proof.FarmProof{before, rotation, after}, oldPub); err != nil {
+		t.Errorf("chain across rotation failed: %v", err)
+	}
+
+	// A proof signed with the retired key after the rotation must fail.
+	stale, _ := proof.New(agent, actor, action, outcome, hints, rotation)
+	_ = proof.Sign(stale, oldPriv)
+	if err := proof.Chain([]*proof.FarmProof{before, rotation, stale}, oldPub); err == nil {
+		t.Error("expected proof signed with the retired key to fail, but it passed")
+	}
+}
+
+func TestChain_SchemaV1ThenV2(t *testing.T) {
+	pub, priv, err := proof.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pepper, err := proof.NewPepper()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent := proof.AgentInfo{AgentID: "agent-1", ClusterAlias: "test-cluster"}
+	action := proof.ActionInfo{Plugin: "farmops/k8s-pod-health", ActionType: proof.ActionVerify, Category: proof.CategoryMaintenance, Description: "check"}
+	outcome := proof.OutcomeInfo{Status: proof.OutcomeSuccess, Verified: true, EvidenceHash: "abc"}
+	hints := proof.ScoringHints{Complexity: proof.ComplexityLow}
+
+	// A proof from an agent that predates keyed actor hashes.
+	v1Actor := proof.ActorInfo{ActorHash: proof.HashActor("github:testuser"), ActorType: proof.ActorHuman}
+	old, _ := proof.New(agent, v1Actor, action, outcome, hints, nil)
+	old.SchemaVersion = proof.VersionV1
+	_ = proof.Sign(old, priv)
+
+	v2Actor := proof.ActorInfo{ActorHash: proof.HashActorKeyed(pepper, "github:testuser"), ActorType: proof.ActorHuman}
+	cur, _ := proof.New(agent, v2Actor, action, outcome, hints, old)
+	_ = proof.Sign(cur, priv)
+
+	if cur.SchemaVersion != proof.Version {
+		t.Errorf("new proof has schema version %s, want %s", cur.SchemaVersion, proof.Version)
+	}
+	if err := proof.Chain([]*proof.FarmProof{old, cur}, pub); err != nil {
+		t.Errorf("chain across schema versions failed: %v", err)
+	}
+	if cur.Actor.ActorHash == v1Actor.ActorHash {
+		t.Error("v2 actor hash equals the unkeyed v1 hash")
+	}
+	if proof.HashActorKeyed([]byte("other tracker"), "github:testuser") == cur.Actor.ActorHash {
+		t.Error("actor hash does not depend on the pepper")
+	}
+}
+
+func TestCheckChain_ReportsGapsForksAndTampering(t *testing.T) {
+	pub, priv, err := proof.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := proof.AgentInfo{AgentID: "agent-1", ClusterAlias: "test-cluster"}
+	actor := proof.ActorInfo{ActorHash: proof.HashActor("github:testuser"), ActorType: proof.ActorHuman}
+	action := proof.ActionInfo{Plugin: "farmops/k8s-pod-health", ActionType: proof.ActionVerify, Category: proof.CategoryMaintenance, Description: "check"}
+	outcome := proof.OutcomeInfo{Status: proof.OutcomeSuccess, Verified: true, EvidenceHash: "abc"}
+	hints := proof.ScoringHints{Complexity: proof.ComplexityLow}
+	next := func(prev *proof.FarmProof) *proof.FarmProof {
+		p, _ := proof.New(agent, actor, action, outcome, hints, prev)
+		_ = proof.Sign(p, priv)
+		return p
+	}
+
+	var chain []*proof.FarmProof
+	var prev *proof.FarmProof
+	for i := 0; i < 5; i++ {
+		prev = next(prev)
+		chain = append(chain, prev)
+	}
+
+	// Order does not matter.
+	shuffled := []*proof.FarmProof{chain[3], chain[0], chain[4], chain[2], chain[1]}
+	if r := proof.CheckChain(shuffled, pub); !r.OK() || r.Verified != 5 || r.Head != chain[4].ProofID {
+		t.Fatalf("valid chain: got %+v", r)
+	}
+
+	// A partial export starts after a missing proof without being a gap.
+	if r := proof.CheckChain(chain[2:], pub); !r.OK() || r.StartsAfter != chain[1].ProofID {
+		t.Errorf("partial chain: got %+v", r)
+	}
+
+	kinds := func(r *proof.ChainReport) []string {
+		var out []string
+		for _, i := range r.Issues {
+			out = append(out, i.Kind+":"+i.ProofID)
+		}
+		return out
+	}
+	want := func(r *proof.ChainReport, expected ...string) {
+		t.Helper()
+		got := kinds(r)
+		if len(got) != len(expected) {
+			t.Fatalf("issues: got %v, want %v", got, expected)
+		}
+		for i := range got {
+			if got[i] != expected[i] {
+				t.Fatalf("issues: got %v, want %v", got, expected)
+			}
+		}
+	}
+
+	gapped := []*proof.FarmProof{chain[0], chain[1], chain[3], chain[4]}
+	want(proof.CheckChain(gapped, pub), proof.IssueGap+":"+chain[3].ProofID)
+
+	fork := next(chain[1])
+	want(proof.CheckChain(append(append([]*proof.FarmProof{}, chain...), fork), pub), proof.IssueFork+":"+chain[1].ProofID)
+
+	// Tampering breaks the proof's signature and its successor's link.
+	tampered := *chain[2]
+	tampered.ScoringHints.ImpactRadius = 99
+	r := proof.CheckChain([]*proof.FarmProof{chain[0], chain[1], &tampered, chain[3], chain[4]}, pub)
+	want(r, proof.IssueSignature+":"+chain[2].ProofID, proof.IssueLink+":"+chain[3].ProofID)
+	if r.Verified != 3 {
+		t.Errorf("tampered chain: verified %d proofs, want 3", r.Verified)
+	}
+}
@@ -0,0 +1,252 @@
+package proof
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Key encodings accepted by FormatPublicKey and FormatPrivateKey. Decoding
+// detects the encoding by itself.
+const (
+	FormatHex     = "hex"     // raw key bytes, hex-encoded; the canonical form
+	FormatPEM     = "pem"     // PKIX "PUBLIC KEY" / PKCS#8 "PRIVATE KEY"
+	FormatOpenSSH = "openssh" // authorized_keys line / "OPENSSH PRIVATE KEY"
+	FormatJWK     = "jwk"     // JSON Web Key, kty OKP, crv Ed25519 (RFC 8037)
+)
+
+// KeyFormats lists the supported key encodings.
+var KeyFormats = []string{FormatHex, FormatPEM, FormatOpenSSH, FormatJWK}
+
+// keyComment labels OpenSSH keys written by FormatPublicKey and FormatPrivateKey.
+const keyComment = "farmops-agent"
+
+// jwk is an Ed25519 JSON Web Key. D holds the private key seed.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	D   string `json:"d,omitempty"`
+}
+
+// FormatPublicKey encodes pub in the given format.
+func FormatPublicKey(pub ed25519.PublicKey, format string) (string, error) {
+	switch format {
+	case FormatHex:
+		return EncodePublicKey(pub), nil
+	case FormatPEM:
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return "", fmt.Errorf("encode public key: %w", err)
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+	case FormatOpenSSH:
+		sshPub, err := ssh.NewPublicKey(pub)
+		if err != nil {
+			return "", fmt.Errorf("encode public key: %w", err)
+		}
+		line := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshPub)), "\n")
+		return line + " " + keyComment + "\n", nil
+	case FormatJWK:
+		data, err := json.Marshal(jwk{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub)})
+		if err != nil {
+			return "", fmt.Errorf("encode public key: %w", err)
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("encode public key: unknown format %q (want one of %s)", format, strings.Join(KeyFormats, ", "))
+}
+
+// FormatPrivateKey encodes priv in the given format, unencrypted.
+func FormatPrivateKey(priv ed25519.PrivateKey, format string) (string, error) {
+	switch format {
+	case FormatHex:
+		return EncodePrivateKey(priv), nil
+	case FormatPEM:
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return "", fmt.Errorf("encode private key: %w", err)
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+	case FormatOpenSSH:
+		block, err := ssh.MarshalPrivateKey(priv, keyComment)
+		if err != nil {
+			return "", fmt.Errorf("encode private key: %w", err)
+		}
+		return string(pem.EncodeToMemory(block)), nil
+	case FormatJWK:
+		pub := priv.Public().(ed25519.PublicKey)
+		data, err := json.Marshal(jwk{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+			D:   base64.RawURLEncoding.EncodeToString(priv.Seed()),
+		})
+		if err != nil {
+			return "", fmt.Errorf("encode private key: %w", err)
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("encode private key: unknown format %q (want one of %s)", format, strings.Join(KeyFormats, ", "))
+}
+
+// parsePublicKey detects the encoding of a trimmed public key and parses it.
+func parsePublicKey(s string) (ed25519.PublicKey, error) {
+	switch {
+	case strings.HasPrefix(s, "-----BEGIN"):
+		block, _ := pem.Decode([]byte(s))
+		if block == nil {
+			return nil, errors.New("invalid PEM")
+		}
+		if block.Type != "PUBLIC KEY" {
+			return nil, fmt.Errorf("PEM block is %q, want \"PUBLIC KEY\"", block.Type)
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return asEd25519Public(key)
+
+	case strings.HasPrefix(s, "ssh-"):
+		sshPub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(s))
+		if err != nil {
+			return nil, err
+		}
+		cryptoPub, ok := sshPub.(ssh.CryptoPublicKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported OpenSSH key type %s", sshPub.Type())
+		}
+		return asEd25519Public(cryptoPub.CryptoPublicKey())
+
+	case strings.HasPrefix(s, "{"):
+		k, err := parseJWK(s)
+		if err != nil {
+			return nil, err
+		}
+		return k.publicKey()
+	}
+
+	if b, err := hex.DecodeString(s); err == nil {
+		if len(b) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid length %d (want %d)", len(b), ed25519.PublicKeySize)
+		}
+		return ed25519.PublicKey(b), nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil && len(b) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(b), nil
+	}
+	return nil, fmt.Errorf("unrecognized encoding (want one of %s)", strings.Join(KeyFormats, ", "))
+}
+
+// parsePrivateKey detects the encoding of a trimmed private key and parses it.
+func parsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	switch {
+	case strings.HasPrefix(s, "-----BEGIN"):
+		block, _ := pem.Decode([]byte(s))
+		if block == nil {
+			return nil, errors.New("invalid PEM")
+		}
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return asEd25519Private(key)
+		case "OPENSSH PRIVATE KEY":
+			key, err := ssh.ParseRawPrivateKey([]byte(s))
+			var missing *ssh.PassphraseMissingError
+			if errors.As(err, &missing) {
+				return nil, errors.New("OpenSSH key is passphrase-protected; remove the passphrase or use an encrypted PEM key file")
+			}
+			if err != nil {
+				return nil, err
+			}
+			return asEd25519Private(key)
+		case "ENCRYPTED PRIVATE KEY":
+			return nil, errors.New("key is encrypted; load it through an encrypted key file signer")
+		}
+		return nil, fmt.Errorf("PEM block is %q, want \"PRIVATE KEY\" or \"OPENSSH PRIVATE KEY\"", block.Type)
+
+	case strings.HasPrefix(s, "{"):
+		k, err := parseJWK(s)
+		if err != nil {
+			return nil, err
+		}
+		return k.privateKey()
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("unrecognized encoding (want one of %s)", strings.Join(KeyFormats, ", "))
+	}
+	if len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid length %d (want %d)", len(b), ed25519.PrivateKeySize)
+	}
+	return ed25519.PrivateKey(b), nil
+}
+
+func parseJWK(s string) (*jwk, error) {
+	var k jwk
+	if err := json.Unmarshal([]byte(s), &k); err != nil {
+		return nil, fmt.Errorf("invalid JWK: %w", err)
+	}
+	if k.Kty != "OKP" || k.Crv != "Ed25519" {
+		return nil, fmt.Errorf("JWK is kty %q crv %q, want OKP Ed25519", k.Kty, k.Crv)
+	}
+	return &k, nil
+}
+
+func (k *jwk) publicKey() (ed25519.PublicKey, error) {
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil || len(x) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid JWK x")
+	}
+	return ed25519.PublicKey(x), nil
+}
+
+func (k *jwk) privateKey() (ed25519.PrivateKey, error) {
+	if k.D == "" {
+		return nil, errors.New("JWK has no private part (d)")
+	}
+	d, err := base64.RawURLEncoding.DecodeString(k.D)
+	if err != nil || len(d) != ed25519.SeedSize {
+		return nil, errors.New("invalid JWK d")
+	}
+	priv := ed25519.NewKeyFromSeed(d)
+	if k.X != "" {
+		pub, err := k.publicKey()
+		if err != nil {
+			return nil, err
+		}
+		if !pub.Equal(priv.Public()) {
+			return nil, errors.New("JWK x does not match d")
+		}
+	}
+	return priv, nil
+}
+
+func asEd25519Public(key any) (ed25519.PublicKey, error) {
+	if pub, ok := key.(ed25519.PublicKey); ok {
+		return pub, nil
+	}
+	return nil, fmt.Errorf("key is %T, not Ed25519", key)
+}
+
+func asEd25519Private(key any) (ed25519.PrivateKey, error) {
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return k, nil
+	case *ed25519.PrivateKey:
+		return *k, nil
+	}
+	return nil, fmt.Errorf("key is %T, not Ed25519", key)
+}
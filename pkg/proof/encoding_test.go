@@ -0,0 +1,63 @@
+package proof_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+func TestKeyFormats_RoundTrip(t *testing.T) {
+	pub, priv, err := proof.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range proof.KeyFormats {
+		pubText, err := proof.FormatPublicKey(pub, format)
+		if err != nil {
+			t.Fatalf("%s: format public key: %v", format, err)
+		}
+		gotPub, err := proof.DecodePublicKey(pubText)
+		if err != nil {
+			t.Fatalf("%s: decode public key: %v", format, err)
+		}
+		if !gotPub.Equal(pub) {
+			t.Errorf("%s: public key does not round-trip", format)
+		}
+
+		privText, err := proof.FormatPrivateKey(priv, format)
+		if err != nil {
+			t.Fatalf("%s: format private key: %v", format, err)
+		}
+		gotPriv, err := proof.DecodePrivateKey(privText)
+		if err != nil {
+			t.Fatalf("%s: decode private key: %v", format, err)
+		}
+		if !gotPriv.Equal(priv) {
+			t.Errorf("%s: private key does not round-trip", format)
+		}
+	}
+
+	// Vault's transit engine reports public keys as standard base64.
+	gotPub, err := proof.DecodePublicKey(base64.StdEncoding.EncodeToString(pub))
+	if err != nil || !gotPub.Equal(pub) {
+		t.Errorf("base64 public key: got %x, %v", gotPub, err)
+	}
+}
+
+func TestDecodePrivateKey_RejectsMismatchedJWK(t *testing.T) {
+	_, priv, _ := proof.GenerateKeyPair()
+	other, _, _ := proof.GenerateKeyPair()
+
+	tampered, _ := json.Marshal(map[string]string{
+		"kty": "OKP",
+		"crv": "Ed25519",
+		"x":   base64.RawURLEncoding.EncodeToString(other),
+		"d":   base64.RawURLEncoding.EncodeToString(priv.Seed()),
+	})
+	if _, err := proof.DecodePrivateKey(string(tampered)); err == nil {
+		t.Fatal("expected a JWK whose x does not match d to be rejected")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
 // GenerateKeyPair generates a new Ed25519 keypair for an agent.
@@ -19,21 +20,21 @@ func GenerateKeyPair() (pubKey ed25519.PublicKey, privKey ed25519.PrivateKey, er
 }
 
 // EncodePublicKey returns the hex-encoded public key string suitable for
-// storage in the Stats Tracker's agent trust store.
+// storage in the Stats Tracker's agent trust store. This is the canonical
+// encoding; see FormatPublicKey for others.
 func EncodePublicKey(pub ed25519.PublicKey) string {
 	return hex.EncodeToString(pub)
 }
 
-// DecodePublicKey parses a hex-encoded public key string.
+// DecodePublicKey parses a public key in any supported encoding: hex, PKIX
+// PEM, an OpenSSH authorized_keys line, a JWK, or raw base64 as shown by
+// Vault. The encoding is detected from the input.
 func DecodePublicKey(s string) (ed25519.PublicKey, error) {
-	b, err := hex.DecodeString(s)
+	pub, err := parsePublicKey(strings.TrimSpace(s))
 	if err != nil {
 		return nil, fmt.Errorf("decode public key: %w", err)
 	}
-	if len(b) != ed25519.PublicKeySize {
-		return nil, fmt.Errorf("decode public key: invalid length %d (want %d)", len(b), ed25519.PublicKeySize)
-	}
-	return ed25519.PublicKey(b), nil
+	return pub, nil
 }
 
 // EncodePrivateKey returns the hex-encoded private key string.
@@ -42,14 +43,25 @@ func EncodePrivateKey(priv ed25519.PrivateKey) string {
 	return hex.EncodeToString(priv)
 }
 
-// DecodePrivateKey parses a hex-encoded private key string.
+// DecodePrivateKey parses a private key in any supported encoding: hex,
+// PKCS#8 PEM, an unencrypted OpenSSH private key, or a JWK. The encoding is
+// detected from the input.
 func DecodePrivateKey(s string) (ed25519.PrivateKey, error) {
-	b, err := hex.DecodeString(s)
+	priv, err := parsePrivateKey(strings.TrimSpace(s))
 	if err != nil {
 		return nil, fmt.Errorf("decode private key: %w", err)
 	}
-	if len(b) != ed25519.PrivateKeySize {
-		return nil, fmt.Errorf("decode private key: invalid length %d (want %d)", len(b), ed25519.PrivateKeySize)
+	return priv, nil
+}
+
+// decodeHexPublicKey parses the canonical hex encoding only.
+func decodeHexPublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode public key: %w", err)
 	}
-	return ed25519.PrivateKey(b), nil
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("decode public key: invalid length %d (want %d)", len(b), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(b), nil
 }
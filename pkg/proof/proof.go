@@ -5,6 +5,8 @@
 package proof
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -14,8 +16,18 @@ import (
 	"github.com/google/uuid"
 )
 
-// Version is the current FarmProof schema version.
-const Version = "1"
+// Schema versions. Version 2 keys the actor hash with a per-tracker pepper
+// (HashActorKeyed); version 1 proofs carry the bare HashActor digest and are
+// still accepted and verified.
+const (
+	Version   = "2" // current version, used by New
+	VersionV1 = "1"
+)
+
+// SupportedVersion reports whether v is a schema version this package reads.
+func SupportedVersion(v string) bool {
+	return v == Version || v == VersionV1
+}
 
 // Action types.
 const (
@@ -27,6 +39,7 @@ const (
 	ActionReview    = "review"
 	ActionConfigure = "configure"
 	ActionObserve   = "observe"
+	ActionRotateKey = "rotate_key" // see KeyRotation
 )
 
 // Categories.
@@ -77,6 +90,10 @@ type FarmProof struct {
 	Outcome      OutcomeInfo  `json:"outcome"`
 	ScoringHints ScoringHints `json:"scoring_hints"`
 
+	// KeyRotation is set only on key rotation proofs. Proofs after it in the
+	// chain are signed with the key it introduces.
+	KeyRotation *KeyRotation `json:"key_rotation,omitempty"`
+
 	// Signature is the Ed25519 signature over the canonical JSON of all fields
 	// above (with Signature itself set to empty string before signing).
 	Signature string `json:"signature"`
@@ -90,7 +107,7 @@ type AgentInfo struct {
 
 // ActorInfo identifies who performed the action, without revealing their identity.
 type ActorInfo struct {
-	ActorHash string `json:"actor_hash"` // sha256(canonical-user-identifier)
+	ActorHash string `json:"actor_hash"` // v2: HashActorKeyed; v1: HashActor
 	ActorType string `json:"actor_type"` // human | bot | system
 }
 
@@ -107,7 +124,7 @@ type ActionInfo struct {
 type OutcomeInfo struct {
 	Status       string `json:"status"` // success | failure | partial
 	Verified     bool   `json:"verified"`
-	EvidenceHash string `json:"evidence_hash"` // sha256 of raw evidence (kept locally by agent)
+	EvidenceHash string `json:"evidence_hash"` // CommitEvidence of the raw evidence (kept locally by agent)
 }
 
 // ScoringHints provides the scoring engine with context for coin calculation.
@@ -176,17 +193,68 @@ func (p *FarmProof) Hash() (string, error) {
 }
 
 // HashEvidence returns the sha256 hex digest of arbitrary raw evidence bytes.
-// The agent calls this before creating a proof; the hash goes into EvidenceHash.
-// The raw bytes are never transmitted.
+// Small or predictable evidence can be recovered from its bare hash by trying
+// candidates, so agents commit to evidence with CommitEvidence instead.
 func HashEvidence(raw []byte) string {
-	sum := sha256.Sum256(raw)
-	return hex.EncodeToString(sum[:])
+	return CommitEvidence(raw, nil)
+}
+
+// SaltSize is the length of an evidence salt.
+const SaltSize = 32
+
+// NewSalt returns a random evidence salt.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("proof: generate salt: %w", err)
+	}
+	return salt, nil
+}
+
+// CommitEvidence returns the sha256 hex digest of salt followed by the raw
+// evidence bytes. The agent calls this before creating a proof; the digest
+// goes into EvidenceHash, while the evidence and salt stay with the agent
+// until disclosed. An empty salt gives the same digest as HashEvidence.
+func CommitEvidence(raw, salt []byte) string {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write(raw)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyEvidence reports whether raw and salt open the commitment evidenceHash.
+func VerifyEvidence(evidenceHash string, raw, salt []byte) bool {
+	return hmac.Equal([]byte(CommitEvidence(raw, salt)), []byte(evidenceHash))
 }
 
 // HashActor returns the sha256 hex digest of a canonical actor identifier
-// (e.g. "github:username" or "email:user@example.com").
-// The raw identifier is never transmitted.
+// (e.g. "github:username" or "email:user@example.com"). This is the schema v1
+// actor hash; anyone holding a list of likely identifiers can reverse it, so
+// new proofs use HashActorKeyed.
 func HashActor(canonicalID string) string {
 	sum := sha256.Sum256([]byte(canonicalID))
 	return hex.EncodeToString(sum[:])
 }
+
+// PepperSize is the length of an actor hashing pepper.
+const PepperSize = 32
+
+// NewPepper returns a random actor hashing pepper. A tracker keeps one and
+// hands it to its agents when they enroll.
+func NewPepper() ([]byte, error) {
+	pepper := make([]byte, PepperSize)
+	if _, err := rand.Read(pepper); err != nil {
+		return nil, fmt.Errorf("proof: generate pepper: %w", err)
+	}
+	return pepper, nil
+}
+
+// HashActorKeyed returns the schema v2 actor hash: HMAC-SHA256, keyed with
+// the tracker's pepper, of the v1 hash of canonicalID. Without the pepper it
+// cannot be reversed by hashing candidate identifiers. The raw identifier is
+// never transmitted.
+func HashActorKeyed(pepper []byte, canonicalID string) string {
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(HashActor(canonicalID)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
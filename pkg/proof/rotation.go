@@ -0,0 +1,44 @@
+package proof
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+// KeyRotation introduces an agent's next signing key. A rotation proof is
+// signed with the agent's current key, so only the holder of that key can
+// hand trust over to a new one.
+type KeyRotation struct {
+	NewPublicKey string `json:"new_public_key"` // hex-encoded Ed25519 public key; no other encoding is accepted
+}
+
+// PublicKey decodes the introduced key.
+func (r *KeyRotation) PublicKey() (ed25519.PublicKey, error) {
+	pub, err := decodeHexPublicKey(r.NewPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("key rotation: %w", err)
+	}
+	return pub, nil
+}
+
+// NewKeyRotation creates an unsigned proof that rotates the agent's signing
+// key to newKey. pepper is the tracker's actor hashing pepper. Sign it with
+// the current key; proofs chained after it are signed with the new one.
+// Rotation proofs are bookkeeping and earn no coins.
+func NewKeyRotation(agent AgentInfo, newKey ed25519.PublicKey, pepper []byte, prev *FarmProof) (*FarmProof, error) {
+	actor := ActorInfo{ActorHash: HashActorKeyed(pepper, "agent:"+agent.AgentID), ActorType: ActorSystem}
+	action := ActionInfo{
+		Plugin:      "farmops/agent",
+		ActionType:  ActionRotateKey,
+		Category:    CategorySecurity,
+		Description: "Rotated proof signing key",
+	}
+	outcome := OutcomeInfo{Status: OutcomeSuccess, Verified: true, EvidenceHash: HashEvidence(newKey)}
+
+	p, err := New(agent, actor, action, outcome, ScoringHints{Complexity: ComplexityLow, ImpactRadius: 1}, prev)
+	if err != nil {
+		return nil, err
+	}
+	p.KeyRotation = &KeyRotation{NewPublicKey: EncodePublicKey(newKey)}
+	return p, nil
+}
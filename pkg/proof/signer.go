@@ -0,0 +1,51 @@
+package proof
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+)
+
+// Signer produces the Ed25519 signature over a proof. Implementations may
+// keep the private key out of the process, as the Vault transit signer in
+// package signer does.
+type Signer interface {
+	// PublicKey returns the key that verifies the signer's signatures.
+	PublicKey(ctx context.Context) (ed25519.PublicKey, error)
+
+	// SignPayload returns the Ed25519 signature of payload.
+	SignPayload(ctx context.Context, payload []byte) ([]byte, error)
+}
+
+// KeySigner signs with an Ed25519 private key held in memory.
+type KeySigner ed25519.PrivateKey
+
+func (k KeySigner) PublicKey(context.Context) (ed25519.PublicKey, error) {
+	return ed25519.PrivateKey(k).Public().(ed25519.PublicKey), nil
+}
+
+func (k KeySigner) SignPayload(_ context.Context, payload []byte) ([]byte, error) {
+	if len(k) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key length %d", len(k))
+	}
+	return ed25519.Sign(ed25519.PrivateKey(k), payload), nil
+}
+
+// SignWith signs the proof's canonical JSON with s and sets p.Signature to
+// the hex-encoded signature.
+func SignWith(ctx context.Context, p *FarmProof, s Signer) error {
+	payload, err := p.CanonicalJSON()
+	if err != nil {
+		return fmt.Errorf("sign: %w", err)
+	}
+	sig, err := s.SignPayload(ctx, payload)
+	if err != nil {
+		return fmt.Errorf("sign: %w", err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("sign: signer returned %d-byte signature, want %d", len(sig), ed25519.SignatureSize)
+	}
+	p.Signature = hex.EncodeToString(sig)
+	return nil
+}
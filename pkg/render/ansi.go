@@ -0,0 +1,126 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/farmops/farmops/pkg/farm"
+)
+
+const (
+	ansiReset         = "\x1b[0m"
+	ansiBold          = "\x1b[1m"
+	ansiPlotWidth     = 13
+	ansiPlotsPerRow   = 6
+	ansiLevelFilled   = "■"
+	ansiLevelEmpty    = "□"
+	ansiStreakFlower  = "✿"
+	ansiBuildingGlyph = "⌂"
+)
+
+func fg(c rgb) string { return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", c.r, c.g, c.b) }
+func bg(c rgb) string { return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", c.r, c.g, c.b) }
+
+// cropGlyphs are drawn for each growth stage.
+var cropGlyphs = map[int]string{
+	stageSeed:    "·",
+	stageSprout:  "ı",
+	stageGrowing: "♣",
+	stageRipe:    "❀",
+	stageWilted:  "╯",
+}
+
+// ANSI writes the world as 24-bit colour terminal art.
+func ANSI(w io.Writer, world *farm.World, opts Options) error {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "%s%s%s  %s coins  %d-day streak", ansiBold, opts.title(), ansiReset, formatCoins(world.Balance), world.StreakDays)
+	if world.StreakDays >= trophyStreak {
+		fmt.Fprintf(&b, "  %s🏆%s", fg(colorTrophy), ansiReset)
+	}
+	b.WriteString("\n")
+
+	if flowers := min(world.StreakDays, maxStreakFlowers); flowers > 0 {
+		fmt.Fprintf(&b, "%s%s%s", fg(colorFlower), strings.Repeat(ansiStreakFlower+" ", flowers), ansiReset)
+		if world.StreakDays >= bannerStreak {
+			fmt.Fprintf(&b, " %s%s %d days strong %s", bg(colorBanner), fg(rgb{0xff, 0xff, 0xff}), world.StreakDays, ansiReset)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	for _, bl := range sortedBuildings(world) {
+		body := colorOf(bl.Category)
+		if bl.Neglected {
+			body = colorNeglect
+		}
+		maxLevel := bl.Level
+		if item, ok := farm.LookupItem(bl.Slug); ok {
+			maxLevel = item.MaxLevel
+		}
+		levels := strings.Repeat(ansiLevelFilled, bl.Level) + strings.Repeat(ansiLevelEmpty, max(maxLevel-bl.Level, 0))
+		fmt.Fprintf(&b, "%s%s%s %-20s %s%s%s", fg(body), ansiBuildingGlyph, ansiReset, bl.Name, fg(colorTrophy), levels, ansiReset)
+		if bl.Neglected {
+			b.WriteString("  needs upkeep")
+		}
+		b.WriteString("\n")
+	}
+	if len(world.Buildings) > 0 {
+		b.WriteString("\n")
+	}
+
+	for start := 0; start < len(world.Plots); start += ansiPlotsPerRow {
+		row := world.Plots[start:min(start+ansiPlotsPerRow, len(world.Plots))]
+		ansiPlotRow(&b, row, opts)
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// ansiPlotRow draws a row of plots as three lines of soil tiles: the plot
+// number, the crop glyph, and the crop category.
+func ansiPlotRow(b *bytes.Buffer, plots []farm.Plot, opts Options) {
+	cell := func(text string, color rgb) {
+		fmt.Fprintf(b, "%s%s%s%s ", bg(colorSoil), fg(color), center(text, ansiPlotWidth), ansiReset)
+	}
+	white := rgb{0xff, 0xff, 0xff}
+
+	for _, p := range plots {
+		cell(fmt.Sprintf("%d", p.ID), white)
+	}
+	b.WriteString("\n")
+	for _, p := range plots {
+		if p.Crop == nil {
+			cell("", white)
+			continue
+		}
+		color := colorOf(p.Crop.Category)
+		stage := cropStage(p.Crop, opts.growthToRipe())
+		if stage == stageWilted {
+			color = colorWilted
+		}
+		cell(cropGlyphs[stage], color)
+	}
+	b.WriteString("\n")
+	for _, p := range plots {
+		label := ""
+		if p.Crop != nil {
+			label = p.Crop.Category
+		}
+		cell(label, white)
+	}
+	b.WriteString("\n")
+}
+
+// center pads s with spaces to width runes, truncating if it is longer.
+func center(s string, width int) string {
+	r := []rune(s)
+	if len(r) > width {
+		r = r[:width]
+	}
+	left := (width - len(r)) / 2
+	return strings.Repeat(" ", left) + string(r) + strings.Repeat(" ", width-len(r)-left)
+}
@@ -0,0 +1,128 @@
+// Package render draws a farm world as an image. It produces SVG for web
+// embeds (READMEs, chat unfurls) and ANSI truecolor text for terminals.
+// Rendering is a pure function of the world and options — no clocks, no
+// randomness, stable iteration order — so output can be snapshot-tested.
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+// Options controls rendering.
+type Options struct {
+	// Title is drawn in the header, usually the farm name.
+	Title string
+
+	// GrowthToRipe is the growth a crop needs to ripen, used to pick its
+	// growth stage. Zero uses farm.DefaultRules.
+	GrowthToRipe int
+}
+
+func (o Options) growthToRipe() int {
+	if o.GrowthToRipe > 0 {
+		return o.GrowthToRipe
+	}
+	return farm.DefaultRules().GrowthToRipe
+}
+
+func (o Options) title() string {
+	if o.Title != "" {
+		return o.Title
+	}
+	return "My Farm"
+}
+
+// rgb is a 24-bit colour.
+type rgb struct{ r, g, b uint8 }
+
+func (c rgb) hex() string { return fmt.Sprintf("#%02x%02x%02x", c.r, c.g, c.b) }
+
+var (
+	colorSky      = rgb{0xbf, 0xe6, 0xff}
+	colorGrass    = rgb{0x9c, 0xcc, 0x65}
+	colorSoil     = rgb{0x8d, 0x6e, 0x63}
+	colorStem     = rgb{0x55, 0x8b, 0x2f}
+	colorWilted   = rgb{0x79, 0x55, 0x48}
+	colorText     = rgb{0x26, 0x32, 0x38}
+	colorRoof     = rgb{0x6d, 0x4c, 0x41}
+	colorNeglect  = rgb{0x9e, 0x9e, 0x9e}
+	colorFlower   = rgb{0xff, 0xca, 0x28}
+	colorTrophy   = rgb{0xff, 0xd7, 0x00}
+	colorBanner   = rgb{0xd8, 0x43, 0x15}
+	colorUnknown  = rgb{0xbd, 0xbd, 0xbd}
+	categoryColor = map[string]rgb{
+		proof.CategoryMaintenance: {0x7c, 0xb3, 0x42},
+		proof.CategoryToil:        {0xfb, 0xc0, 0x2d},
+		proof.CategoryReliability: {0x29, 0xb6, 0xf6},
+		proof.CategorySecurity:    {0x8e, 0x24, 0xaa},
+		proof.CategoryIncident:    {0xe5, 0x39, 0x35},
+		proof.CategoryUpgrade:     {0xfb, 0x8c, 0x00},
+	}
+)
+
+func colorOf(category string) rgb {
+	if c, ok := categoryColor[category]; ok {
+		return c
+	}
+	return colorUnknown
+}
+
+// Crop growth stages.
+const (
+	stageSeed = iota
+	stageSprout
+	stageGrowing
+	stageRipe
+	stageWilted
+)
+
+func cropStage(c *farm.Crop, growthToRipe int) int {
+	switch {
+	case c.Wilted:
+		return stageWilted
+	case c.Ripe:
+		return stageRipe
+	case c.Growth == 0:
+		return stageSeed
+	case c.Growth*2 < growthToRipe:
+		return stageSprout
+	default:
+		return stageGrowing
+	}
+}
+
+// Streak decorations.
+const (
+	maxStreakFlowers = 14 // one flower per streak day, up to two weeks
+	bannerStreak     = 7  // a banner appears after a week
+	trophyStreak     = 30 // a trophy appears after a month
+)
+
+// sortedBuildings returns the world's buildings ordered by slug.
+func sortedBuildings(w *farm.World) []farm.Building {
+	out := make([]farm.Building, len(w.Buildings))
+	copy(out, w.Buildings)
+	sort.Slice(out, func(i, j int) bool { return out[i].Slug < out[j].Slug })
+	return out
+}
+
+// formatCoins formats n with thousands separators, e.g. 1250 → "1,250".
+func formatCoins(n int) string {
+	s := strconv.Itoa(n)
+	neg := n < 0
+	if neg {
+		s = s[1:]
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
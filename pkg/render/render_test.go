@@ -0,0 +1,77 @@
+package render_test
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/render"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// fixtureWorld builds a farm with every crop stage, an upgraded building,
+// a neglected building and a week-long streak.
+func fixtureWorld() *farm.World {
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	w := farm.NewWorld(farm.DefaultRules())
+	w.Balance = 1250
+	w.TotalCoins = 4800
+	w.StreakDays = 9
+	w.Plots[0].Crop = &farm.Crop{Category: proof.CategorySecurity, PlantedAt: at}
+	w.Plots[1].Crop = &farm.Crop{Category: proof.CategoryMaintenance, PlantedAt: at, Growth: 1}
+	w.Plots[2].Crop = &farm.Crop{Category: proof.CategoryIncident, PlantedAt: at, Growth: 3}
+	w.Plots[3].Crop = &farm.Crop{Category: proof.CategoryToil, PlantedAt: at, Growth: 5, Ripe: true}
+	w.Plots[4].Crop = &farm.Crop{Category: proof.CategoryReliability, PlantedAt: at, Growth: 2, Wilted: true}
+	w.Buildings = []farm.Building{
+		{Slug: "security-fence", Name: "Security Fence", Category: proof.CategorySecurity, Level: 1, PurchasedAt: at, UpgradedAt: at, Neglected: true},
+		{Slug: "ci-windmill", Name: "CI Windmill", Category: proof.CategoryToil, Level: 3, PurchasedAt: at, UpgradedAt: at},
+	}
+	return w
+}
+
+func TestRender_Golden(t *testing.T) {
+	opts := render.Options{Title: "Fern & Co"}
+	cases := []struct {
+		golden string
+		render func(*bytes.Buffer, *farm.World) error
+	}{
+		{"farm.svg.golden", func(b *bytes.Buffer, w *farm.World) error { return render.SVG(b, w, opts) }},
+		{"farm.ansi.golden", func(b *bytes.Buffer, w *farm.World) error { return render.ANSI(b, w, opts) }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.golden, func(t *testing.T) {
+			var got bytes.Buffer
+			if err := tc.render(&got, fixtureWorld()); err != nil {
+				t.Fatal(err)
+			}
+
+			// Rendering twice must give identical output.
+			var again bytes.Buffer
+			_ = tc.render(&again, fixtureWorld())
+			if !bytes.Equal(got.Bytes(), again.Bytes()) {
+				t.Fatal("rendering is not deterministic")
+			}
+
+			path := filepath.Join("testdata", tc.golden)
+			if *update {
+				if err := os.WriteFile(path, got.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("output differs from %s (run with -update to accept):\n%s", path, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/farmops/farmops/pkg/farm"
+)
+
+// SVG layout, in pixels.
+const (
+	svgWidth       = 720
+	svgHeaderH     = 60
+	svgDecorH      = 40
+	svgBuildingW   = 100
+	svgBuildingH   = 110
+	svgPlotSize    = 100
+	svgPlotGap     = 12
+	svgPlotsPerRow = 6
+	svgMargin      = 20
+)
+
+// SVG writes the world as a standalone SVG document.
+func SVG(w io.Writer, world *farm.World, opts Options) error {
+	var b bytes.Buffer
+
+	rows := (len(world.Plots) + svgPlotsPerRow - 1) / svgPlotsPerRow
+	buildingsY := svgHeaderH + svgDecorH
+	plotsY := buildingsY + svgBuildingH + svgMargin
+	height := plotsY + rows*(svgPlotSize+svgPlotGap) + svgMargin
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n",
+		svgWidth, height, svgWidth, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`+"\n", svgWidth, svgHeaderH, colorSky.hex())
+	fmt.Fprintf(&b, `<rect y="%d" width="%d" height="%d" fill="%s"/>`+"\n", svgHeaderH, svgWidth, height-svgHeaderH, colorGrass.hex())
+
+	svgHeader(&b, world, opts)
+	svgStreak(&b, world.StreakDays)
+	svgBuildings(&b, world, buildingsY)
+	svgPlots(&b, world, opts, plotsY)
+
+	b.WriteString("</svg>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func svgHeader(b *bytes.Buffer, world *farm.World, opts Options) {
+	fmt.Fprintf(b, `<text x="%d" y="38" font-size="24" font-weight="bold" fill="%s">%s</text>`+"\n",
+		svgMargin, colorText.hex(), escape(opts.title()))
+	fmt.Fprintf(b, `<text x="%d" y="38" font-size="16" text-anchor="end" fill="%s">%s coins · %d-day streak</text>`+"\n",
+		svgWidth-svgMargin, colorText.hex(), formatCoins(world.Balance), world.StreakDays)
+	if world.StreakDays >= trophyStreak {
+		fmt.Fprintf(b, `<polygon points="%d,14 %d,14 %d,30 %d,30" fill="%s"/>`+"\n", 300, 324, 318, 306, colorTrophy.hex())
+		fmt.Fprintf(b, `<rect x="308" y="30" width="8" height="10" fill="%s"/>`+"\n", colorTrophy.hex())
+		fmt.Fprintf(b, `<rect x="302" y="40" width="20" height="4" fill="%s"/>`+"\n", colorTrophy.hex())
+	}
+}
+
+// svgStreak draws one sunflower per streak day and a banner after a week.
+func svgStreak(b *bytes.Buffer, streak int) {
+	flowers := min(streak, maxStreakFlowers)
+	for i := 0; i < flowers; i++ {
+		x := svgMargin + 10 + i*28
+		y := svgHeaderH + 26
+		fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="2"/>`+"\n", x, y, x, y+12, colorStem.hex())
+		fmt.Fprintf(b, `<circle cx="%d" cy="%d" r="7" fill="%s"/>`+"\n", x, y, colorFlower.hex())
+		fmt.Fprintf(b, `<circle cx="%d" cy="%d" r="3" fill="%s"/>`+"\n", x, y, colorRoof.hex())
+	}
+	if streak >= bannerStreak {
+		x := svgWidth - svgMargin - 140
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="140" height="26" rx="4" fill="%s"/>`+"\n", x, svgHeaderH+8, colorBanner.hex())
+		fmt.Fprintf(b, `<text x="%d" y="%d" font-size="14" text-anchor="middle" fill="#ffffff">%d days strong</text>`+"\n", x+70, svgHeaderH+26, streak)
+	}
+}
+
+func svgBuildings(b *bytes.Buffer, world *farm.World, y int) {
+	for i, bl := range sortedBuildings(world) {
+		x := svgMargin + i*(svgBuildingW+svgPlotGap)
+		body := colorOf(bl.Category)
+		if bl.Neglected {
+			body = colorNeglect
+		}
+		fmt.Fprintf(b, `<g class="building" data-slug="%s">`+"\n", escape(bl.Slug))
+		fmt.Fprintf(b, `<polygon points="%d,%d %d,%d %d,%d" fill="%s"/>`+"\n",
+			x, y+35, x+svgBuildingW/2, y+5, x+svgBuildingW, y+35, colorRoof.hex())
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="45" fill="%s"/>`+"\n", x+8, y+35, svgBuildingW-16, body.hex())
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="16" height="22" fill="%s"/>`+"\n", x+svgBuildingW/2-8, y+58, colorRoof.hex())
+
+		maxLevel := bl.Level
+		if item, ok := farm.LookupItem(bl.Slug); ok {
+			maxLevel = item.MaxLevel
+		}
+		for l := 0; l < maxLevel; l++ {
+			fill := "#ffffff"
+			if l < bl.Level {
+				fill = colorTrophy.hex()
+			}
+			fmt.Fprintf(b, `<rect x="%d" y="%d" width="10" height="6" fill="%s" stroke="%s" stroke-width="1"/>`+"\n",
+				x+8+l*14, y+84, fill, colorText.hex())
+		}
+		label := bl.Name
+		if bl.Neglected {
+			label += " (needs upkeep)"
+		}
+		fmt.Fprintf(b, `<text x="%d" y="%d" font-size="11" fill="%s">%s</text>`+"\n", x, y+104, colorText.hex(), escape(label))
+		b.WriteString("</g>\n")
+	}
+}
+
+func svgPlots(b *bytes.Buffer, world *farm.World, opts Options, y0 int) {
+	for i, p := range world.Plots {
+		x := svgMargin + (i%svgPlotsPerRow)*(svgPlotSize+svgPlotGap)
+		y := y0 + (i/svgPlotsPerRow)*(svgPlotSize+svgPlotGap)
+		fmt.Fprintf(b, `<g class="plot" data-plot="%d">`+"\n", p.ID)
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" rx="6" fill="%s"/>`+"\n", x, y, svgPlotSize, svgPlotSize, colorSoil.hex())
+		fmt.Fprintf(b, `<text x="%d" y="%d" font-size="10" fill="#ffffff">%d</text>`+"\n", x+6, y+14, p.ID)
+		if p.Crop != nil {
+			svgCrop(b, p.Crop, opts, x+svgPlotSize/2, y+svgPlotSize-14)
+			fmt.Fprintf(b, `<text x="%d" y="%d" font-size="10" text-anchor="middle" fill="#ffffff">%s</text>`+"\n",
+				x+svgPlotSize/2, y+svgPlotSize-3, escape(p.Crop.Category))
+		}
+		b.WriteString("</g>\n")
+	}
+}
+
+// svgCrop draws a crop rooted at (x, y).
+func svgCrop(b *bytes.Buffer, c *farm.Crop, opts Options, x, y int) {
+	leaf := colorOf(c.Category)
+	switch cropStage(c, opts.growthToRipe()) {
+	case stageSeed:
+		fmt.Fprintf(b, `<circle cx="%d" cy="%d" r="4" fill="%s"/>`+"\n", x, y-4, colorRoof.hex())
+	case stageSprout:
+		svgStem(b, x, y, 20, colorStem)
+		fmt.Fprintf(b, `<ellipse cx="%d" cy="%d" rx="7" ry="4" fill="%s"/>`+"\n", x+6, y-18, leaf.hex())
+	case stageGrowing:
+		svgStem(b, x, y, 45, colorStem)
+		fmt.Fprintf(b, `<ellipse cx="%d" cy="%d" rx="9" ry="5" fill="%s"/>`+"\n", x-8, y-25, leaf.hex())
+		fmt.Fprintf(b, `<ellipse cx="%d" cy="%d" rx="9" ry="5" fill="%s"/>`+"\n", x+8, y-38, leaf.hex())
+	case stageRipe:
+		svgStem(b, x, y, 60, colorStem)
+		fmt.Fprintf(b, `<ellipse cx="%d" cy="%d" rx="9" ry="5" fill="%s"/>`+"\n", x-8, y-25, leaf.hex())
+		fmt.Fprintf(b, `<ellipse cx="%d" cy="%d" rx="9" ry="5" fill="%s"/>`+"\n", x+8, y-38, leaf.hex())
+		fmt.Fprintf(b, `<circle cx="%d" cy="%d" r="9" fill="%s" stroke="%s" stroke-width="2"/>`+"\n", x, y-64, leaf.hex(), colorTrophy.hex())
+	case stageWilted:
+		fmt.Fprintf(b, `<path d="M%d %d q 4 -30 22 -26" stroke="%s" stroke-width="3" fill="none"/>`+"\n", x, y, colorWilted.hex())
+		fmt.Fprintf(b, `<ellipse cx="%d" cy="%d" rx="7" ry="3" fill="%s"/>`+"\n", x+22, y-24, colorWilted.hex())
+	}
+}
+
+func svgStem(b *bytes.Buffer, x, y, h int, c rgb) {
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="3"/>`+"\n", x, y, x, y-h, c.hex())
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -4,23 +4,27 @@
 // The scoring engine runs in the Stats Tracker, not the agent.
 package scoring
 
-import "github.com/farmops/farmops/pkg/proof"
+import (
+	"math"
+
+	"github.com/farmops/farmops/pkg/proof"
+)
 
 // Config holds the configurable scoring parameters.
 // All values can be overridden per-tracker via the database Config table.
 type Config struct {
 	// Base coins per category.
-	BaseCoins map[string]int
+	BaseCoins map[string]int `yaml:"base_coins" json:"base_coins"`
 
 	// Complexity multipliers.
-	ComplexityMultipliers map[string]float64
+	ComplexityMultipliers map[string]float64 `yaml:"complexity_multipliers" json:"complexity_multipliers"`
 
 	// ImpactMultiplier scales linearly: multiplier = 1.0 + (ImpactRadius-1) * ImpactStep
-	ImpactStep float64
+	ImpactStep float64 `yaml:"impact_step" json:"impact_step"`
 
 	// StreakMultiplier is added per consecutive active day, capped at StreakCap.
-	StreakStep float64
-	StreakCap  float64
+	StreakStep float64 `yaml:"streak_step" json:"streak_step"`
+	StreakCap  float64 `yaml:"streak_cap" json:"streak_cap"`
 }
 
 // DefaultConfig returns the default scoring configuration as defined in the
@@ -85,7 +89,9 @@ func Compute(p *proof.FarmProof, cfg Config, upgradeMult float64, streakDays int
 		upgradeMult = 1.0
 	}
 
-	total := int(float64(base) * complexityMult * impactMult * streakMult * upgradeMult)
+	// Round to the nearest coin, as the documented formula does: truncating
+	// would pay a 7-day streak (×1.49) less than the capped streak (×1.5).
+	total := int(math.Round(float64(base) * complexityMult * impactMult * streakMult * upgradeMult))
 	if total < 1 && base > 0 {
 		total = 1 // always award at least 1 coin for a verified action
 	}
@@ -60,3 +60,25 @@ func TestCompute_StreakCap(t *testing.T) {
 			r100.TotalCoins, r7.TotalCoins)
 	}
 }
+
+func TestCompute_RoundsToNearestCoin(t *testing.T) {
+	cfg := scoring.DefaultConfig()
+	for _, tt := range []struct {
+		category   string
+		complexity string
+		streak     int
+		want       int
+	}{
+		{proof.CategoryMaintenance, proof.ComplexityLow, 0, 10},
+		{proof.CategoryMaintenance, proof.ComplexityLow, 7, 15},    // 10 × 1.49 = 14.9
+		{proof.CategoryMaintenance, proof.ComplexityMedium, 0, 13}, // 10 × 1.25 = 12.5
+		{proof.CategoryToil, proof.ComplexityHigh, 0, 23},          // 15 × 1.5 = 22.5
+		{proof.CategoryToil, proof.ComplexityLow, 1, 16},           // 15 × 1.07 = 16.05
+	} {
+		p := makeProof(tt.category, tt.complexity, 1)
+		if got := scoring.Compute(p, cfg, 1.0, tt.streak).TotalCoins; got != tt.want {
+			t.Errorf("category=%s complexity=%s streak=%d: got %d coins, want %d",
+				tt.category, tt.complexity, tt.streak, got, tt.want)
+		}
+	}
+}
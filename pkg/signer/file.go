@@ -0,0 +1,270 @@
+// Package signer provides proof.Signer backends that keep an agent's private
+// key out of its config and environment: a passphrase-encrypted key file, a
+// Kubernetes Secret, and HashiCorp Vault's transit engine, where the key
+// never leaves Vault.
+package signer
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+// ErrPassphrase is returned when an encrypted key cannot be decrypted with
+// the passphrase given.
+var ErrPassphrase = errors.New("signer: wrong passphrase or corrupt key")
+
+// pemEncryptedKey is the PEM block type of an encrypted PKCS#8 private key.
+const pemEncryptedKey = "ENCRYPTED PRIVATE KEY"
+
+// scrypt cost used when encrypting a key: 16 MiB, the same as
+// `openssl pkcs8 -scrypt` and within OpenSSL's default memory limit.
+const (
+	scryptN = 1 << 14
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	oidPBES2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt     = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+	oidHMACSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// encryptedPrivateKeyInfo and the parameter types below are the ASN.1 of
+// PKCS#8 encryption with PBES2 (RFC 8018) and scrypt (RFC 7914).
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// EncryptKey returns priv as a PEM "ENCRYPTED PRIVATE KEY": PKCS#8 encrypted
+// with AES-256-CBC under a key derived from passphrase with scrypt. OpenSSL
+// reads the result, e.g. with `openssl pkey -in key.pem`.
+func EncryptKey(priv ed25519.PrivateKey, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("signer: empty passphrase")
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("signer: marshal key: %w", err)
+	}
+	salt, iv := make([]byte, 16), make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("signer: %w", err)
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, fmt.Errorf("signer: %w", err)
+	}
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("signer: scrypt: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("signer: %w", err)
+	}
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	data := append(der, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	kdf, err := algorithm(oidScrypt, scryptParams{
+		Salt:                     salt,
+		CostParameter:            scryptN,
+		BlockSize:                scryptR,
+		ParallelizationParameter: scryptP,
+		KeyLength:                32,
+	})
+	if err != nil {
+		return nil, err
+	}
+	enc, err := algorithm(oidAES256CBC, iv)
+	if err != nil {
+		return nil, err
+	}
+	pbes2, err := algorithm(oidPBES2, pbes2Params{KeyDerivationFunc: kdf, EncryptionScheme: enc})
+	if err != nil {
+		return nil, err
+	}
+	out, err := asn1.Marshal(encryptedPrivateKeyInfo{Algorithm: pbes2, EncryptedData: data})
+	if err != nil {
+		return nil, fmt.Errorf("signer: marshal encrypted key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemEncryptedKey, Bytes: out}), nil
+}
+
+// DecryptKey decrypts a PEM "ENCRYPTED PRIVATE KEY" holding an Ed25519 key.
+// Besides the scrypt keys written by EncryptKey it reads PBKDF2 keys as
+// written by `openssl genpkey -algorithm ed25519 -aes256`.
+func DecryptKey(data, passphrase []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signer: no PEM data found")
+	}
+	if block.Type != pemEncryptedKey {
+		return nil, fmt.Errorf("signer: PEM block is %q, want %q", block.Type, pemEncryptedKey)
+	}
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		return nil, fmt.Errorf("signer: parse encrypted key: %w", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("signer: unsupported key encryption %v, want PBES2", info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("signer: parse PBES2 parameters: %w", err)
+	}
+	if !params.EncryptionScheme.Algorithm.Equal(oidAES256CBC) {
+		return nil, fmt.Errorf("signer: unsupported cipher %v, want AES-256-CBC", params.EncryptionScheme.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("signer: invalid AES-256-CBC IV")
+	}
+	key, err := deriveKey(params.KeyDerivationFunc, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, ErrPassphrase
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("signer: %w", err)
+	}
+	der := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(c, iv).CryptBlocks(der, info.EncryptedData)
+	padding := int(der[len(der)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(der[len(der)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, ErrPassphrase
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der[:len(der)-padding])
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	priv, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signer: key is %T, not Ed25519", parsed)
+	}
+	return priv, nil
+}
+
+// deriveKey derives the AES-256 key from passphrase with the given KDF.
+func deriveKey(kdf pkix.AlgorithmIdentifier, passphrase []byte) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidScrypt):
+		var p scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &p); err != nil {
+			return nil, fmt.Errorf("signer: parse scrypt parameters: %w", err)
+		}
+		key, err := scrypt.Key(passphrase, p.Salt, p.CostParameter, p.BlockSize, p.ParallelizationParameter, 32)
+		if err != nil {
+			return nil, fmt.Errorf("signer: scrypt: %w", err)
+		}
+		return key, nil
+
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var p pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &p); err != nil {
+			return nil, fmt.Errorf("signer: parse PBKDF2 parameters: %w", err)
+		}
+		if !p.PRF.Algorithm.Equal(oidHMACSHA256) {
+			return nil, fmt.Errorf("signer: unsupported PBKDF2 PRF %v, want HMAC-SHA256", p.PRF.Algorithm)
+		}
+		return pbkdf2.Key(passphrase, p.Salt, p.IterationCount, 32, sha256.New), nil
+	}
+	return nil, fmt.Errorf("signer: unsupported key derivation %v", kdf.Algorithm)
+}
+
+// algorithm builds an AlgorithmIdentifier with DER-encoded params.
+func algorithm(oid asn1.ObjectIdentifier, params any) (pkix.AlgorithmIdentifier, error) {
+	der, err := asn1.Marshal(params)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, fmt.Errorf("signer: marshal %v parameters: %w", oid, err)
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.RawValue{FullBytes: der}}, nil
+}
+
+// LoadFile reads and decrypts an encrypted key file.
+func LoadFile(path string, passphrase []byte) (proof.KeySigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("signer: read %s: %w", path, err)
+	}
+	priv, err := DecryptKey(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s)", err, path)
+	}
+	return proof.KeySigner(priv), nil
+}
+
+// WriteFile encrypts priv with passphrase and writes it to path with mode
+// 0600. It refuses to overwrite an existing file.
+func WriteFile(path string, priv ed25519.PrivateKey, passphrase []byte) error {
+	data, err := EncryptKey(priv, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("signer: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("signer: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("signer: write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// ReadPassphrase reads a passphrase file, dropping one trailing newline.
+func ReadPassphrase(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("signer: read passphrase: %w", err)
+	}
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	return bytes.TrimSuffix(data, []byte("\r")), nil
+}
@@ -0,0 +1,36 @@
+package signer_test
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/signer"
+)
+
+func TestKeyFile_RoundTrip(t *testing.T) {
+	_, priv, err := proof.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "agent.pem")
+	if err := signer.WriteFile(path, priv, []byte("correct horse")); err != nil {
+		t.Fatal(err)
+	}
+	if err := signer.WriteFile(path, priv, []byte("correct horse")); err == nil {
+		t.Error("WriteFile overwrote an existing key file")
+	}
+
+	got, err := signer.LoadFile(path, []byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !priv.Equal(ed25519.PrivateKey(got)) {
+		t.Error("decrypted key differs from the original")
+	}
+	if _, err := signer.LoadFile(path, []byte("battery staple")); !errors.Is(err, signer.ErrPassphrase) {
+		t.Errorf("wrong passphrase: got %v, want ErrPassphrase", err)
+	}
+}
@@ -0,0 +1,37 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/farmops/farmops/pkg/proof"
+)
+
+// DefaultSecretKey is the Secret data key that holds the private key unless
+// another is configured.
+const DefaultSecretKey = "private-key"
+
+// LoadSecret reads the private key stored under key in a Kubernetes Secret.
+// The caller's service account needs get on the Secret.
+func LoadSecret(ctx context.Context, client kubernetes.Interface, namespace, name, key string) (proof.KeySigner, error) {
+	if key == "" {
+		key = DefaultSecretKey
+	}
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("signer: get secret %s/%s: %w", namespace, name, err)
+	}
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("signer: secret %s/%s has no key %q", namespace, name, key)
+	}
+	priv, err := proof.DecodePrivateKey(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("signer: secret %s/%s: %w", namespace, name, err)
+	}
+	return proof.KeySigner(priv), nil
+}
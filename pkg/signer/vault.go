@@ -0,0 +1,144 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Vault signs with an ed25519 key in HashiCorp Vault's transit secrets
+// engine. The private key never leaves Vault; the token needs read on
+// <mount>/keys/<key> and update on <mount>/sign/<key>.
+type Vault struct {
+	Address   string // e.g. https://vault.example.internal:8200
+	Token     string
+	Namespace string // Vault Enterprise namespace; empty for none
+	Mount     string // transit mount path; empty means "transit"
+	Key       string
+
+	// KeyVersion pins the key version to sign with. Zero signs with the
+	// latest version, so rotating the key in Vault changes the agent's public
+	// key and must be followed by re-enrolling it.
+	KeyVersion int
+
+	// HTTPClient is used for requests to Vault; nil means a client with a
+	// 10 second timeout.
+	HTTPClient *http.Client
+}
+
+func (v *Vault) PublicKey(ctx context.Context) (ed25519.PublicKey, error) {
+	var resp struct {
+		Data struct {
+			Type          string `json:"type"`
+			LatestVersion int    `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	if err := v.do(ctx, http.MethodGet, "keys/"+url.PathEscape(v.Key), nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Type != "ed25519" {
+		return nil, fmt.Errorf("signer: vault key %s is %s, not ed25519", v.Key, resp.Data.Type)
+	}
+	version := v.KeyVersion
+	if version == 0 {
+		version = resp.Data.LatestVersion
+	}
+	k, ok := resp.Data.Keys[strconv.Itoa(version)]
+	if !ok {
+		return nil, fmt.Errorf("signer: vault key %s has no version %d", v.Key, version)
+	}
+	pub, err := base64.StdEncoding.DecodeString(k.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("signer: vault key %s: invalid public key", v.Key)
+	}
+	return ed25519.PublicKey(pub), nil
+}
+
+func (v *Vault) SignPayload(ctx context.Context, payload []byte) ([]byte, error) {
+	req := map[string]any{"input": base64.StdEncoding.EncodeToString(payload)}
+	if v.KeyVersion > 0 {
+		req["key_version"] = v.KeyVersion
+	}
+	var resp struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	if err := v.do(ctx, http.MethodPost, "sign/"+url.PathEscape(v.Key), req, &resp); err != nil {
+		return nil, err
+	}
+	// Signatures come as vault:v<version>:<base64>.
+	parts := strings.SplitN(resp.Data.Signature, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, fmt.Errorf("signer: unexpected vault signature format")
+	}
+	sig, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signer: decode vault signature: %w", err)
+	}
+	return sig, nil
+}
+
+// do calls the transit API at path below the mount.
+func (v *Vault) do(ctx context.Context, method, path string, in, out any) error {
+	mount := strings.Trim(v.Mount, "/")
+	if mount == "" {
+		mount = "transit"
+	}
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("signer: marshal vault request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	endpoint := strings.TrimRight(v.Address, "/") + "/v1/" + mount + "/" + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("signer: build vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := v.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("signer: vault %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && len(e.Errors) > 0 {
+			return fmt.Errorf("signer: vault returned %d: %s", resp.StatusCode, strings.Join(e.Errors, "; "))
+		}
+		return fmt.Errorf("signer: vault returned %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("signer: decode vault response: %w", err)
+	}
+	return nil
+}
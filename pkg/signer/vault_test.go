@@ -0,0 +1,88 @@
+package signer_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/signer"
+)
+
+// fakeTransit stands in for Vault's transit engine with one ed25519 key.
+func fakeTransit(t *testing.T, token string, priv ed25519.PrivateKey) *httptest.Server {
+	pub := priv.Public().(ed25519.PublicKey)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/transit/keys/agent", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"type":           "ed25519",
+			"latest_version": 1,
+			"keys":           map[string]any{"1": map[string]string{"public_key": base64.StdEncoding.EncodeToString(pub)}},
+		}})
+	})
+	mux.HandleFunc("POST /v1/transit/sign/agent", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode sign request: %v", err)
+		}
+		input, _ := base64.StdEncoding.DecodeString(req.Input)
+		sig := ed25519.Sign(priv, input)
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"signature":   "vault:v1:" + base64.StdEncoding.EncodeToString(sig),
+			"key_version": 1,
+		}})
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVault_SignsVerifiableProofs(t *testing.T) {
+	_, priv, err := proof.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := fakeTransit(t, "s.token", priv)
+	ctx := context.Background()
+	v := &signer.Vault{Address: srv.URL, Token: "s.token", Key: "agent"}
+
+	pub, err := v.PublicKey(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(priv.Public()) {
+		t.Fatal("vault public key does not match the transit key")
+	}
+
+	p, err := proof.New(proof.AgentInfo{AgentID: "agent-1", ClusterAlias: "c"}, proof.ActorInfo{ActorType: proof.ActorSystem},
+		proof.ActionInfo{Plugin: "test", ActionType: proof.ActionVerify, Category: proof.CategoryMaintenance},
+		proof.OutcomeInfo{Status: proof.OutcomeSuccess, Verified: true}, proof.ScoringHints{Complexity: proof.ComplexityLow}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proof.SignWith(ctx, p, v); err != nil {
+		t.Fatal(err)
+	}
+	if err := proof.Verify(p, pub); err != nil {
+		t.Errorf("vault-signed proof does not verify: %v", err)
+	}
+
+	bad := &signer.Vault{Address: srv.URL, Token: "wrong", Key: "agent"}
+	if _, err := bad.SignPayload(ctx, []byte("x")); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("signing with a bad token: got %v, want permission denied", err)
+	}
+}
@@ -2,13 +2,18 @@ package storage
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"time"
 
 	bolt "go.etcd.io/bbolt"
 
+	"github.com/farmops/farmops/pkg/farm"
 	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/scoring"
 )
 
 // bucket names
@@ -16,7 +21,20 @@ var (
 	bucketProofs = []byte("proofs")
 	bucketAgents = []byte("agents")
 	bucketFarm   = []byte("farm")
-	keyFarmState = []byte("state")
+	bucketLedger = []byte("ledger")
+
+	bucketWebhooks    = []byte("webhooks")
+	bucketDeliveries  = []byte("webhook_deliveries")
+	bucketDeadLetters = []byte("webhook_dead_letters")
+	bucketOutbox      = []byte("webhook_outbox")
+	bucketTokens      = []byte("tokens")
+	bucketJoinTokens  = []byte("join_tokens")
+	bucketAudit       = []byte("audit")
+
+	keyFarmState   = []byte("state")
+	keyWorld       = []byte("world")
+	keyActorPepper = []byte("actor_pepper")
+	keyTrackerKey  = []byte("tracker_key")
 )
 
 // BoltStore is a BoltDB-backed implementation of Store.
@@ -33,7 +51,11 @@ func OpenBolt(path string) (*BoltStore, error) {
 	}
 
 	if err := db.Update(func(tx *bolt.Tx) error {
-		for _, b := range [][]byte{bucketProofs, bucketAgents, bucketFarm} {
+		for _, b := range [][]byte{
+			bucketProofs, bucketAgents, bucketFarm, bucketLedger,
+			bucketWebhooks, bucketDeliveries, bucketDeadLetters, bucketTokens,
+			bucketJoinTokens, bucketAudit, bucketOutbox,
+		} {
 			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
 				return err
 			}
@@ -53,10 +75,11 @@ func (s *BoltStore) Close() error {
 
 // --- ProofStore ---
 
-func (s *BoltStore) AppendProof(_ context.Context, p *proof.FarmProof, coinsAwarded int) error {
+func (s *BoltStore) AppendProof(_ context.Context, p *proof.FarmProof, score scoring.Result, ledger ...*farm.Event) error {
 	sp := &StoredProof{
 		FarmProof:    p,
-		CoinsAwarded: coinsAwarded,
+		CoinsAwarded: score.TotalCoins,
+		Scoring:      score,
 		ReceivedAt:   time.Now().UTC(),
 	}
 	data, err := json.Marshal(sp)
@@ -70,7 +93,16 @@ func (s *BoltStore) AppendProof(_ context.Context, p *proof.FarmProof, coinsAwar
 		if b.Get(key) != nil {
 			return ErrDuplicateProof
 		}
-		return b.Put(key, data)
+		if _, err := checkSigner(tx, p); err != nil {
+			return err
+		}
+		if err := checkLinkage(tx, p); err != nil {
+			return err
+		}
+		if err := b.Put(key, data); err != nil {
+			return err
+		}
+		return appendEvents(tx, ledger)
 	})
 }
 
@@ -120,6 +152,81 @@ func (s *BoltStore) ListProofs(_ context.Context, agentID string, afterProofID s
 
 func (s *BoltStore) LatestProof(_ context.Context, agentID string) (*StoredProof, error) {
 	var latest *StoredProof
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		latest, err = chainHead(tx, agentID)
+		return err
+	})
+	return latest, err
+}
+
+// chainHead returns the newest proof of an agent, or nil before its first.
+func chainHead(tx *bolt.Tx, agentID string) (*StoredProof, error) {
+	c := tx.Bucket(bucketProofs).Cursor()
+	for k, v := c.Last(); k != nil; k, v = c.Prev() {
+		var sp StoredProof
+		if err := json.Unmarshal(v, &sp); err != nil {
+			return nil, err
+		}
+		if sp.FarmProof.Agent.AgentID == agentID {
+			return &sp, nil
+		}
+	}
+	return nil, nil
+}
+
+// checkSigner returns the proof's agent, or ErrKeyRotated unless the
+// agent's current key signed the proof. The caller verified the signature
+// before the transaction; checking again inside it keeps a proof signed with
+// a key that a concurrent rotation retired from being accepted.
+func checkSigner(tx *bolt.Tx, p *proof.FarmProof) (*AgentRecord, error) {
+	data := tx.Bucket(bucketAgents).Get([]byte(p.Agent.AgentID))
+	if data == nil {
+		return nil, ErrNotFound
+	}
+	var agent AgentRecord
+	if err := json.Unmarshal(data, &agent); err != nil {
+		return nil, err
+	}
+	key, err := proof.DecodePublicKey(agent.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("agent %s: %w", agent.AgentID, err)
+	}
+	if proof.Verify(p, key) != nil {
+		return nil, ErrKeyRotated
+	}
+	return &agent, nil
+}
+
+// checkLinkage returns ErrChainConflict unless p links to the agent's
+// current chain head, or starts the chain if the agent has none. Checking
+// inside the write transaction keeps two proofs linked to the same head
+// from both being appended and forking the chain.
+func checkLinkage(tx *bolt.Tx, p *proof.FarmProof) error {
+	head, err := chainHead(tx, p.Agent.AgentID)
+	if err != nil {
+		return err
+	}
+	if head == nil {
+		if p.PrevProofID != "" || p.PrevProofHash != "" {
+			return ErrChainConflict
+		}
+		return nil
+	}
+	headHash, err := head.FarmProof.Hash()
+	if err != nil {
+		return fmt.Errorf("hash chain head: %w", err)
+	}
+	if p.PrevProofID != head.ProofID || p.PrevProofHash != headHash {
+		return ErrChainConflict
+	}
+	return nil
+}
+
+// RecentProofs relies on proof IDs being UUID v7, whose byte order is their
+// creation order, so the end of the bucket holds the newest proofs.
+func (s *BoltStore) RecentProofs(_ context.Context, limit int) ([]*StoredProof, error) {
+	var results []*StoredProof
 	err := s.db.View(func(tx *bolt.Tx) error {
 		c := tx.Bucket(bucketProofs).Cursor()
 		for k, v := c.Last(); k != nil; k, v = c.Prev() {
@@ -127,14 +234,14 @@ func (s *BoltStore) LatestProof(_ context.Context, agentID string) (*StoredProof
 			if err := json.Unmarshal(v, &sp); err != nil {
 				return err
 			}
-			if sp.FarmProof.Agent.AgentID == agentID {
-				latest = &sp
-				return nil
+			results = append(results, &sp)
+			if limit > 0 && len(results) >= limit {
+				break
 			}
 		}
 		return nil
 	})
-	return latest, err
+	return results, err
 }
 
 // --- AgentStore ---
@@ -149,6 +256,20 @@ func (s *BoltStore) UpsertAgent(_ context.Context, agent *AgentRecord) error {
 	})
 }
 
+func (s *BoltStore) CreateAgent(_ context.Context, agent *AgentRecord) error {
+	data, err := json.Marshal(agent)
+	if err != nil {
+		return fmt.Errorf("boltdb create agent: marshal: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAgents)
+		if b.Get([]byte(agent.AgentID)) != nil {
+			return ErrAgentExists
+		}
+		return b.Put([]byte(agent.AgentID), data)
+	})
+}
+
 func (s *BoltStore) GetAgent(_ context.Context, agentID string) (*AgentRecord, error) {
 	var agent AgentRecord
 	err := s.db.View(func(tx *bolt.Tx) error {
@@ -179,6 +300,180 @@ func (s *BoltStore) ListAgents(_ context.Context) ([]*AgentRecord, error) {
 	return agents, err
 }
 
+func (s *BoltStore) RotateAgentKey(_ context.Context, p *proof.FarmProof) error {
+	now := time.Now().UTC()
+	proofData, err := json.Marshal(&StoredProof{FarmProof: p, ReceivedAt: now})
+	if err != nil {
+		return fmt.Errorf("boltdb rotate agent key: marshal proof: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		proofs, agents := tx.Bucket(bucketProofs), tx.Bucket(bucketAgents)
+		if proofs.Get([]byte(p.ProofID)) != nil {
+			return ErrDuplicateProof
+		}
+		agent, err := checkSigner(tx, p)
+		if err != nil {
+			return err
+		}
+		if err := checkLinkage(tx, p); err != nil {
+			return err
+		}
+		agent.KeyHistory = append(agent.KeyHistory, RetiredKey{
+			PublicKey:       agent.PublicKey,
+			RetiredAt:       now,
+			RotationProofID: p.ProofID,
+		})
+		agent.PublicKey = p.KeyRotation.NewPublicKey
+		data, err := json.Marshal(agent)
+		if err != nil {
+			return fmt.Errorf("boltdb rotate agent key: marshal agent: %w", err)
+		}
+		if err := proofs.Put([]byte(p.ProofID), proofData); err != nil {
+			return err
+		}
+		return agents.Put([]byte(agent.AgentID), data)
+	})
+}
+
+func (s *BoltStore) RevokeAgent(_ context.Context, agentID string, proofIDs []string, reason string, at time.Time) ([]*farm.Event, error) {
+	var reversals []*farm.Event
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		agents := tx.Bucket(bucketAgents)
+		data := agents.Get([]byte(agentID))
+		if data == nil {
+			return ErrNotFound
+		}
+		var agent AgentRecord
+		if err := json.Unmarshal(data, &agent); err != nil {
+			return err
+		}
+		agent.Status = AgentStatusRevoked
+		if agent.RevokedAt == nil {
+			agent.RevokedAt = &at
+		}
+		data, err := json.Marshal(&agent)
+		if err != nil {
+			return fmt.Errorf("boltdb revoke agent: marshal: %w", err)
+		}
+		if err := agents.Put([]byte(agentID), data); err != nil {
+			return err
+		}
+
+		if err := invalidateProofs(tx, proofIDs, reason, at); err != nil {
+			return err
+		}
+		if reversals, err = reverseRewards(tx, proofIDs, at); err != nil {
+			return err
+		}
+		return appendEvents(tx, reversals)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reversals, nil
+}
+
+// invalidateProofs marks proofs as no longer trusted within tx. Proofs that
+// are already invalid keep their original reason; unknown IDs are ignored.
+func invalidateProofs(tx *bolt.Tx, proofIDs []string, reason string, at time.Time) error {
+	b := tx.Bucket(bucketProofs)
+	for _, id := range proofIDs {
+		data := b.Get([]byte(id))
+		if data == nil {
+			continue
+		}
+		var sp StoredProof
+		if err := json.Unmarshal(data, &sp); err != nil {
+			return err
+		}
+		if sp.InvalidatedAt != nil {
+			continue
+		}
+		sp.InvalidatedAt, sp.InvalidReason = &at, reason
+		data, err := json.Marshal(&sp)
+		if err != nil {
+			return fmt.Errorf("boltdb invalidate proof: marshal: %w", err)
+		}
+		if err := b.Put([]byte(id), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reverseRewards returns the compensating events for the ledger rewards of
+// the given proofs that have not been reversed yet.
+func reverseRewards(tx *bolt.Tx, proofIDs []string, at time.Time) ([]*farm.Event, error) {
+	wanted := make(map[string]bool, len(proofIDs))
+	for _, id := range proofIDs {
+		wanted[id] = true
+	}
+	var rewards []*farm.Event
+	err := tx.Bucket(bucketLedger).ForEach(func(_, v []byte) error {
+		var e farm.Event
+		if err := json.Unmarshal(v, &e); err != nil {
+			return err
+		}
+		switch {
+		case e.Type == farm.EventRewardReversed:
+			delete(wanted, e.ProofID)
+		case e.Type == farm.EventProofReward && wanted[e.ProofID]:
+			rewards = append(rewards, &e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var reversals []*farm.Event
+	for _, e := range rewards {
+		if wanted[e.ProofID] {
+			reversals = append(reversals, farm.Reversal(e, at))
+		}
+	}
+	return reversals, nil
+}
+
+func (s *BoltStore) ActorPepper(_ context.Context) ([]byte, error) {
+	var pepper []byte
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketFarm)
+		if v := b.Get(keyActorPepper); v != nil {
+			pepper = append([]byte(nil), v...)
+			return nil
+		}
+		var err error
+		if pepper, err = proof.NewPepper(); err != nil {
+			return err
+		}
+		return b.Put(keyActorPepper, pepper)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("boltdb actor pepper: %w", err)
+	}
+	return pepper, nil
+}
+
+func (s *BoltStore) TrackerKey(_ context.Context) (ed25519.PrivateKey, error) {
+	var key ed25519.PrivateKey
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketFarm)
+		if v := b.Get(keyTrackerKey); v != nil {
+			key = append(ed25519.PrivateKey(nil), v...)
+			return nil
+		}
+		var err error
+		if _, key, err = ed25519.GenerateKey(rand.Reader); err != nil {
+			return err
+		}
+		return b.Put(keyTrackerKey, key)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("boltdb tracker key: %w", err)
+	}
+	return key, nil
+}
+
 // --- FarmStore ---
 
 func (s *BoltStore) GetFarm(_ context.Context) (*FarmState, error) {
@@ -208,3 +503,485 @@ func (s *BoltStore) UpdateFarm(_ context.Context, farm *FarmState) error {
 		return tx.Bucket(bucketFarm).Put(keyFarmState, data)
 	})
 }
+
+func (s *BoltStore) GetWorld(_ context.Context) (*farm.World, error) {
+	var world *farm.World
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketFarm).Get(keyWorld)
+		if data == nil {
+			return nil
+		}
+		world = &farm.World{}
+		return json.Unmarshal(data, world)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return world, nil
+}
+
+func (s *BoltStore) PutWorld(_ context.Context, world *farm.World) error {
+	data, err := json.Marshal(world)
+	if err != nil {
+		return fmt.Errorf("boltdb put world: marshal: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketFarm).Put(keyWorld, data)
+	})
+}
+
+// --- LedgerStore ---
+
+func (s *BoltStore) AppendEvent(_ context.Context, e *farm.Event) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return appendEvents(tx, []*farm.Event{e})
+	})
+}
+
+func (s *BoltStore) ListEvents(_ context.Context, afterSeq uint64, limit int) ([]*farm.Event, error) {
+	var events []*farm.Event
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketLedger).Cursor()
+		for k, v := c.Seek(seqKey(afterSeq + 1)); k != nil; k, v = c.Next() {
+			var e farm.Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			events = append(events, &e)
+			if limit > 0 && len(events) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	return events, err
+}
+
+// --- WebhookStore ---
+
+func (s *BoltStore) PutWebhook(_ context.Context, hook *Webhook) error {
+	return s.put(bucketWebhooks, hook.ID, hook)
+}
+
+func (s *BoltStore) GetWebhook(_ context.Context, id string) (*Webhook, error) {
+	var hook Webhook
+	if err := s.get(bucketWebhooks, id, &hook); err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (s *BoltStore) ListWebhooks(_ context.Context) ([]*Webhook, error) {
+	var hooks []*Webhook
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketWebhooks).ForEach(func(_, v []byte) error {
+			var hook Webhook
+			if err := json.Unmarshal(v, &hook); err != nil {
+				return err
+			}
+			hooks = append(hooks, &hook)
+			return nil
+		})
+	})
+	return hooks, err
+}
+
+func (s *BoltStore) DeleteWebhook(_ context.Context, id string) error {
+	return s.delete(bucketWebhooks, id)
+}
+
+func (s *BoltStore) AppendDelivery(_ context.Context, d *WebhookDelivery) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDeliveries)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		d.Seq = seq
+		data, err := json.Marshal(d)
+		if err != nil {
+			return fmt.Errorf("boltdb append delivery: marshal: %w", err)
+		}
+		return b.Put(seqKey(seq), data)
+	})
+}
+
+func (s *BoltStore) ListDeliveries(_ context.Context, webhookID string, limit int) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketDeliveries).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var d WebhookDelivery
+			if err := json.Unmarshal(v, &d); err != nil {
+				return err
+			}
+			if webhookID != "" && d.WebhookID != webhookID {
+				continue
+			}
+			deliveries = append(deliveries, &d)
+			if limit > 0 && len(deliveries) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	return deliveries, err
+}
+
+func (s *BoltStore) PutDeadLetter(_ context.Context, dl *DeadLetter) error {
+	return s.put(bucketDeadLetters, dl.ID, dl)
+}
+
+func (s *BoltStore) GetDeadLetter(_ context.Context, id string) (*DeadLetter, error) {
+	var dl DeadLetter
+	if err := s.get(bucketDeadLetters, id, &dl); err != nil {
+		return nil, err
+	}
+	return &dl, nil
+}
+
+func (s *BoltStore) ListDeadLetters(_ context.Context) ([]*DeadLetter, error) {
+	var letters []*DeadLetter
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketDeadLetters).ForEach(func(_, v []byte) error {
+			var dl DeadLetter
+			if err := json.Unmarshal(v, &dl); err != nil {
+				return err
+			}
+			letters = append(letters, &dl)
+			return nil
+		})
+	})
+	return letters, err
+}
+
+func (s *BoltStore) DeleteDeadLetter(_ context.Context, id string) error {
+	return s.delete(bucketDeadLetters, id)
+}
+
+// Outbox keys are UUID v7 delivery IDs, so bucket order is creation order.
+func (s *BoltStore) PutOutbox(_ context.Context, ob *OutboxDelivery) error {
+	return s.put(bucketOutbox, ob.ID, ob)
+}
+
+func (s *BoltStore) ListOutbox(_ context.Context) ([]*OutboxDelivery, error) {
+	var pending []*OutboxDelivery
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketOutbox).ForEach(func(_, v []byte) error {
+			var ob OutboxDelivery
+			if err := json.Unmarshal(v, &ob); err != nil {
+				return err
+			}
+			pending = append(pending, &ob)
+			return nil
+		})
+	})
+	return pending, err
+}
+
+func (s *BoltStore) DeleteOutbox(_ context.Context, id string) error {
+	return s.delete(bucketOutbox, id)
+}
+
+// --- TokenStore ---
+
+func (s *BoltStore) PutToken(_ context.Context, t *TokenRecord) error {
+	return s.put(bucketTokens, t.ID, t)
+}
+
+func (s *BoltStore) GetToken(_ context.Context, id string) (*TokenRecord, error) {
+	var t TokenRecord
+	if err := s.get(bucketTokens, id, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *BoltStore) ListTokens(_ context.Context) ([]*TokenRecord, error) {
+	var tokens []*TokenRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketTokens).ForEach(func(_, v []byte) error {
+			var t TokenRecord
+			if err := json.Unmarshal(v, &t); err != nil {
+				return err
+			}
+			tokens = append(tokens, &t)
+			return nil
+		})
+	})
+	return tokens, err
+}
+
+// --- JoinTokenStore ---
+
+func (s *BoltStore) PutJoinToken(_ context.Context, t *JoinToken) error {
+	return s.put(bucketJoinTokens, t.ID, t)
+}
+
+func (s *BoltStore) GetJoinToken(_ context.Context, id string) (*JoinToken, error) {
+	var t JoinToken
+	if err := s.get(bucketJoinTokens, id, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *BoltStore) ListJoinTokens(_ context.Context) ([]*JoinToken, error) {
+	var tokens []*JoinToken
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketJoinTokens).ForEach(func(_, v []byte) error {
+			var t JoinToken
+			if err := json.Unmarshal(v, &t); err != nil {
+				return err
+			}
+			tokens = append(tokens, &t)
+			return nil
+		})
+	})
+	return tokens, err
+}
+
+func (s *BoltStore) JoinAgent(_ context.Context, joinTokenID string, agent *AgentRecord, token *TokenRecord, at time.Time) error {
+	agentData, err := json.Marshal(agent)
+	if err != nil {
+		return fmt.Errorf("boltdb join agent: marshal agent: %w", err)
+	}
+	tokenData, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("boltdb join agent: marshal token: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		joins, agents := tx.Bucket(bucketJoinTokens), tx.Bucket(bucketAgents)
+		data := joins.Get([]byte(joinTokenID))
+		if data == nil {
+			return ErrNotFound
+		}
+		var t JoinToken
+		if err := json.Unmarshal(data, &t); err != nil {
+			return err
+		}
+		if t.UsedAt != nil || t.RevokedAt != nil {
+			return ErrTokenUsed
+		}
+		if agents.Get([]byte(agent.AgentID)) != nil {
+			return ErrAgentExists
+		}
+
+		t.UsedAt, t.UsedBy = &at, agent.AgentID
+		if data, err = json.Marshal(&t); err != nil {
+			return fmt.Errorf("boltdb join agent: marshal join token: %w", err)
+		}
+		if err := joins.Put([]byte(joinTokenID), data); err != nil {
+			return err
+		}
+		if err := agents.Put([]byte(agent.AgentID), agentData); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketTokens).Put([]byte(token.ID), tokenData)
+	})
+}
+
+// --- AuditStore ---
+
+func (s *BoltStore) AppendAudit(_ context.Context, e *AuditEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAudit)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		e.Seq = seq
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("boltdb append audit: marshal: %w", err)
+		}
+		return b.Put(seqKey(seq), data)
+	})
+}
+
+func (s *BoltStore) ListAudit(_ context.Context, limit int) ([]*AuditEntry, error) {
+	var entries []*AuditEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketAudit).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e AuditEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			entries = append(entries, &e)
+			if limit > 0 && len(entries) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// --- ExportStore ---
+
+func (s *BoltStore) Export(_ context.Context) (*Snapshot, error) {
+	snap := &Snapshot{Farm: &FarmState{Name: "My Farm"}, Proofs: map[string][]*StoredProof{}}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		farmBucket := tx.Bucket(bucketFarm)
+		if data := farmBucket.Get(keyFarmState); data != nil {
+			if err := json.Unmarshal(data, snap.Farm); err != nil {
+				return err
+			}
+		}
+		if v := farmBucket.Get(keyActorPepper); v != nil {
+			snap.ActorPepper = append([]byte(nil), v...)
+		}
+		if err := tx.Bucket(bucketAgents).ForEach(func(_, v []byte) error {
+			var a AgentRecord
+			if err := json.Unmarshal(v, &a); err != nil {
+				return err
+			}
+			snap.Agents = append(snap.Agents, &a)
+			snap.Proofs[a.AgentID] = nil
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketProofs).ForEach(func(_, v []byte) error {
+			var sp StoredProof
+			if err := json.Unmarshal(v, &sp); err != nil {
+				return err
+			}
+			agentID := sp.FarmProof.Agent.AgentID
+			if _, ok := snap.Proofs[agentID]; ok {
+				snap.Proofs[agentID] = append(snap.Proofs[agentID], &sp)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketLedger).ForEach(func(_, v []byte) error {
+			var e farm.Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			snap.Ledger = append(snap.Ledger, &e)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("boltdb export: %w", err)
+	}
+	return snap, nil
+}
+
+// --- ImportStore ---
+
+func (s *BoltStore) Import(_ context.Context, agents []*AgentRecord, proofs []*StoredProof, events []*farm.Event, pepper []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		agentsB, proofsB, ledgerB := tx.Bucket(bucketAgents), tx.Bucket(bucketProofs), tx.Bucket(bucketLedger)
+		for _, b := range []*bolt.Bucket{agentsB, proofsB, ledgerB} {
+			if k, _ := b.Cursor().First(); k != nil {
+				return ErrNotEmpty
+			}
+		}
+
+		for _, a := range agents {
+			data, err := json.Marshal(a)
+			if err != nil {
+				return fmt.Errorf("boltdb import: marshal agent: %w", err)
+			}
+			if err := agentsB.Put([]byte(a.AgentID), data); err != nil {
+				return err
+			}
+		}
+		for _, sp := range proofs {
+			key := []byte(sp.ProofID)
+			if proofsB.Get(key) != nil {
+				return ErrDuplicateProof
+			}
+			data, err := json.Marshal(sp)
+			if err != nil {
+				return fmt.Errorf("boltdb import: marshal proof: %w", err)
+			}
+			if err := proofsB.Put(key, data); err != nil {
+				return err
+			}
+		}
+		for _, e := range events {
+			seq, err := ledgerB.NextSequence()
+			if err != nil {
+				return err
+			}
+			e.Seq = seq
+			data, err := json.Marshal(e)
+			if err != nil {
+				return fmt.Errorf("boltdb import: marshal event: %w", err)
+			}
+			if err := ledgerB.Put(seqKey(seq), data); err != nil {
+				return err
+			}
+		}
+		if len(pepper) > 0 {
+			return tx.Bucket(bucketFarm).Put(keyActorPepper, pepper)
+		}
+		return nil
+	})
+}
+
+// put stores v as JSON under key in bucket.
+func (s *BoltStore) put(bucket []byte, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("boltdb put %s: marshal: %w", bucket, err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), data)
+	})
+}
+
+// get decodes the JSON stored under key in bucket into v.
+// Returns ErrNotFound if the key does not exist.
+func (s *BoltStore) get(bucket []byte, key string, v any) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, v)
+	})
+}
+
+// delete removes key from bucket. Returns ErrNotFound if the key does not exist.
+func (s *BoltStore) delete(bucket []byte, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b.Get([]byte(key)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete([]byte(key))
+	})
+}
+
+// appendEvents appends events to the ledger within tx and sets their Seq.
+func appendEvents(tx *bolt.Tx, events []*farm.Event) error {
+	b := tx.Bucket(bucketLedger)
+	for _, e := range events {
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		e.Seq = seq
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("boltdb append event: marshal: %w", err)
+		}
+		if err := b.Put(seqKey(seq), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seqKey encodes a ledger sequence number as a sortable bucket key.
+func seqKey(seq uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, seq)
+	return k
+}
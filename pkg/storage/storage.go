@@ -5,10 +5,13 @@ package storage
 
 import (
 	"context"
+	"crypto/ed25519"
 	"io"
 	"time"
 
+	"github.com/farmops/farmops/pkg/farm"
 	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/scoring"
 )
 
 // Store is the primary storage interface for the Stats Tracker.
@@ -17,14 +20,25 @@ type Store interface {
 	ProofStore
 	AgentStore
 	FarmStore
+	LedgerStore
+	WebhookStore
+	TokenStore
+	JoinTokenStore
+	AuditStore
+	ExportStore
+	ImportStore
 	io.Closer
 }
 
 // ProofStore manages the append-only proof chain.
 type ProofStore interface {
-	// AppendProof appends a verified proof to the chain.
-	// Returns ErrDuplicateProof if proof_id already exists.
-	AppendProof(ctx context.Context, p *proof.FarmProof, coinsAwarded int) error
+	// AppendProof appends a verified proof to the chain together with its
+	// scoring breakdown and, in the same transaction, the ledger events it
+	// earned, setting their Seq. Returns ErrDuplicateProof if proof_id already
+	// exists, ErrChainConflict if the proof does not link to the agent's
+	// current chain head, and ErrKeyRotated if the agent's current key did not
+	// sign it; in each case nothing is appended.
+	AppendProof(ctx context.Context, p *proof.FarmProof, score scoring.Result, ledger ...*farm.Event) error
 
 	// GetProof retrieves a single proof by ID.
 	GetProof(ctx context.Context, proofID string) (*StoredProof, error)
@@ -36,6 +50,9 @@ type ProofStore interface {
 	// LatestProof returns the most recent proof for a given agent.
 	// Returns nil, nil if no proofs exist yet (genesis state).
 	LatestProof(ctx context.Context, agentID string) (*StoredProof, error)
+
+	// RecentProofs returns the most recent proofs across all agents, newest-first.
+	RecentProofs(ctx context.Context, limit int) ([]*StoredProof, error)
 }
 
 // AgentStore manages the agent trust registry.
@@ -43,11 +60,39 @@ type AgentStore interface {
 	// UpsertAgent creates or updates an agent record.
 	UpsertAgent(ctx context.Context, agent *AgentRecord) error
 
+	// CreateAgent registers a new agent. Returns ErrAgentExists if an agent
+	// with the same ID is already registered.
+	CreateAgent(ctx context.Context, agent *AgentRecord) error
+
 	// GetAgent retrieves an agent by ID.
 	GetAgent(ctx context.Context, agentID string) (*AgentRecord, error)
 
 	// ListAgents returns all registered agents.
 	ListAgents(ctx context.Context) ([]*AgentRecord, error)
+
+	// RotateAgentKey appends a verified key rotation proof and, in the same
+	// transaction, makes the key it introduces the agent's current key,
+	// retiring the old one to KeyHistory. Like AppendProof, it returns
+	// ErrDuplicateProof, ErrChainConflict or ErrKeyRotated, and nothing is
+	// stored; it returns ErrNotFound if the agent does not exist.
+	RotateAgentKey(ctx context.Context, p *proof.FarmProof) error
+
+	// RevokeAgent stops trusting an agent in a single transaction: it marks
+	// the agent revoked, marks the given proofs as no longer trusted, and
+	// appends a reward_reversed ledger event for each of their rewards not
+	// reversed yet, returning those reversals. The proofs stay in the chain.
+	// Revoking again keeps the original RevokedAt, invalidation reasons and
+	// reversals, so an interrupted revocation can simply be retried. Returns
+	// ErrNotFound if the agent does not exist.
+	RevokeAgent(ctx context.Context, agentID string, proofIDs []string, reason string, at time.Time) ([]*farm.Event, error)
+
+	// ActorPepper returns the tracker's actor hashing pepper, which agents
+	// key actor hashes with. A random one is created on first use.
+	ActorPepper(ctx context.Context) ([]byte, error)
+
+	// TrackerKey returns the tracker's Ed25519 signing key, which signs the
+	// proof bundles it exports. A random one is created on first use.
+	TrackerKey(ctx context.Context) (ed25519.PrivateKey, error)
 }
 
 // FarmStore manages the farm state (materialized projection from proof chain).
@@ -57,25 +102,188 @@ type FarmStore interface {
 
 	// UpdateFarm updates the farm state.
 	UpdateFarm(ctx context.Context, farm *FarmState) error
+
+	// GetWorld returns the materialized farm world.
+	// Returns nil, nil if no world has been projected yet.
+	GetWorld(ctx context.Context) (*farm.World, error)
+
+	// PutWorld replaces the materialized farm world.
+	PutWorld(ctx context.Context, world *farm.World) error
+}
+
+// LedgerStore manages the append-only coin ledger the farm world is projected from.
+type LedgerStore interface {
+	// AppendEvent appends an event to the ledger and sets its Seq.
+	AppendEvent(ctx context.Context, e *farm.Event) error
+
+	// ListEvents returns ledger events with Seq greater than afterSeq, oldest-first.
+	// A limit of 0 returns all remaining events.
+	ListEvents(ctx context.Context, afterSeq uint64, limit int) ([]*farm.Event, error)
+}
+
+// WebhookStore manages outbound webhook subscriptions and their delivery history.
+type WebhookStore interface {
+	// PutWebhook creates or updates a webhook subscription.
+	PutWebhook(ctx context.Context, hook *Webhook) error
+
+	// GetWebhook retrieves a webhook subscription by ID.
+	GetWebhook(ctx context.Context, id string) (*Webhook, error)
+
+	// ListWebhooks returns all webhook subscriptions, oldest-first.
+	ListWebhooks(ctx context.Context) ([]*Webhook, error)
+
+	// DeleteWebhook removes a webhook subscription. Its delivery log is kept.
+	DeleteWebhook(ctx context.Context, id string) error
+
+	// AppendDelivery records a delivery attempt and sets its Seq.
+	AppendDelivery(ctx context.Context, d *WebhookDelivery) error
+
+	// ListDeliveries returns the most recent delivery attempts, newest-first.
+	// An empty webhookID returns attempts for every webhook.
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*WebhookDelivery, error)
+
+	// PutDeadLetter stores a delivery that exhausted its retries.
+	PutDeadLetter(ctx context.Context, dl *DeadLetter) error
+
+	// GetDeadLetter retrieves a dead letter by ID.
+	GetDeadLetter(ctx context.Context, id string) (*DeadLetter, error)
+
+	// ListDeadLetters returns dead letters, oldest-first.
+	ListDeadLetters(ctx context.Context) ([]*DeadLetter, error)
+
+	// DeleteDeadLetter removes a dead letter, e.g. after it was redelivered.
+	DeleteDeadLetter(ctx context.Context, id string) error
+
+	// PutOutbox creates or updates a pending delivery.
+	PutOutbox(ctx context.Context, ob *OutboxDelivery) error
+
+	// ListOutbox returns the pending deliveries, oldest-first.
+	ListOutbox(ctx context.Context) ([]*OutboxDelivery, error)
+
+	// DeleteOutbox removes a pending delivery once it succeeded or was
+	// dead-lettered.
+	DeleteOutbox(ctx context.Context, id string) error
+}
+
+// TokenStore manages scoped API tokens. Only token hashes are stored.
+type TokenStore interface {
+	// PutToken creates or updates a token record.
+	PutToken(ctx context.Context, t *TokenRecord) error
+
+	// GetToken retrieves a token record by ID.
+	GetToken(ctx context.Context, id string) (*TokenRecord, error)
+
+	// ListTokens returns all token records.
+	ListTokens(ctx context.Context) ([]*TokenRecord, error)
+}
+
+// JoinTokenStore manages single-use agent join tokens. Only token hashes are stored.
+type JoinTokenStore interface {
+	// PutJoinToken creates or updates a join token.
+	PutJoinToken(ctx context.Context, t *JoinToken) error
+
+	// GetJoinToken retrieves a join token by ID.
+	GetJoinToken(ctx context.Context, id string) (*JoinToken, error)
+
+	// ListJoinTokens returns all join tokens.
+	ListJoinTokens(ctx context.Context) ([]*JoinToken, error)
+
+	// JoinAgent redeems a join token for a new agent in a single transaction:
+	// it marks the token as used by the agent, registers the agent and stores
+	// the agent's API token. Returns ErrTokenUsed if the join token was
+	// already redeemed or revoked, and ErrAgentExists if the agent ID is taken;
+	// nothing is changed in either case.
+	JoinAgent(ctx context.Context, joinTokenID string, agent *AgentRecord, token *TokenRecord, at time.Time) error
+}
+
+// AuditStore keeps the append-only log of administrative actions.
+type AuditStore interface {
+	// AppendAudit records an action and sets its Seq.
+	AppendAudit(ctx context.Context, e *AuditEntry) error
+
+	// ListAudit returns the most recent entries, newest-first.
+	// A limit of 0 returns every entry.
+	ListAudit(ctx context.Context, limit int) ([]*AuditEntry, error)
+}
+
+// ExportStore reads the tracker's history for export to another tracker.
+type ExportStore interface {
+	// Export returns the farm, every agent with its proof chain, the ledger
+	// and the actor pepper, all read in a single transaction so that they
+	// agree with each other. The pepper is nil if none has been created yet,
+	// in which case no agent has keyed actor hashes with one.
+	Export(ctx context.Context) (*Snapshot, error)
+}
+
+// Snapshot is the tracker's history as read by Export.
+type Snapshot struct {
+	Farm        *FarmState
+	Agents      []*AgentRecord
+	Proofs      map[string][]*StoredProof // by agent ID, oldest-first
+	Ledger      []*farm.Event
+	ActorPepper []byte
+}
+
+// ImportStore loads history exported from another tracker.
+type ImportStore interface {
+	// Import stores agents, proofs and ledger events as given, keeping their
+	// tracker-side metadata, in a single transaction. Ledger events are
+	// renumbered in order. A non-empty pepper replaces the actor pepper, so
+	// that the agents keep their actor hashes. Returns ErrNotEmpty if the
+	// store already holds agents, proofs or ledger events.
+	Import(ctx context.Context, agents []*AgentRecord, proofs []*StoredProof, events []*farm.Event, pepper []byte) error
 }
 
 // StoredProof is a FarmProof with additional tracker-side metadata.
 type StoredProof struct {
 	*proof.FarmProof
-	CoinsAwarded int
-	ReceivedAt   time.Time
+	CoinsAwarded  int
+	Scoring       scoring.Result
+	ReceivedAt    time.Time
+	InvalidatedAt *time.Time // set once the proof is no longer trusted
+	InvalidReason string
 }
 
 // AgentRecord represents a registered agent in the trust store.
 type AgentRecord struct {
 	AgentID      string
 	ClusterAlias string
-	PublicKey    string // hex-encoded Ed25519 public key
+	PublicKey    string // hex-encoded Ed25519 public key, current
+	KeyHistory   []RetiredKey
 	Status       AgentStatus
 	EnrolledAt   time.Time
 	RevokedAt    *time.Time
 }
 
+// RetiredKey is a public key an agent signed with before rotating it.
+type RetiredKey struct {
+	PublicKey       string
+	RetiredAt       time.Time
+	RotationProofID string // the proof, signed with this key, that introduced its successor
+}
+
+// GenesisKey returns the key the agent enrolled with, which verifies the
+// start of its proof chain.
+func (a *AgentRecord) GenesisKey() string {
+	if len(a.KeyHistory) > 0 {
+		return a.KeyHistory[0].PublicKey
+	}
+	return a.PublicKey
+}
+
+// UsedKey reports whether pub is or ever was one of the agent's keys.
+func (a *AgentRecord) UsedKey(pub string) bool {
+	if a.PublicKey == pub {
+		return true
+	}
+	for _, k := range a.KeyHistory {
+		if k.PublicKey == pub {
+			return true
+		}
+	}
+	return false
+}
+
 // AgentStatus represents the trust state of an agent.
 type AgentStatus string
 
@@ -95,10 +303,121 @@ type FarmState struct {
 	UpdatedAt    time.Time
 }
 
+// TokenRecord is a scoped API token. The secret itself is never stored.
+type TokenRecord struct {
+	ID         string
+	Name       string
+	Scope      TokenScope
+	AgentID    string // for agent tokens: the only agent_id it may submit proofs for
+	SecretHash string // hex SHA-256 of the token secret
+	CreatedAt  time.Time
+	RotatedAt  *time.Time
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+}
+
+// TokenScope is what a token is allowed to do.
+type TokenScope string
+
+const (
+	TokenScopeAgent TokenScope = "agent" // submit proofs for one agent
+	TokenScopeAdmin TokenScope = "admin" // everything, including approve, revoke and shop actions
+	TokenScopeRead  TokenScope = "read"  // read-only access for dashboards
+)
+
+// JoinToken lets one new agent enroll itself without an admin in the loop.
+// It is short-lived and single-use; the secret itself is never stored.
+type JoinToken struct {
+	ID           string
+	Name         string
+	ClusterAlias string // preset alias given to the joining agent; empty lets the agent choose
+	AutoApprove  bool   // enroll the agent as active instead of pending
+	SecretHash   string // hex SHA-256 of the token secret
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	UsedBy       string // agent_id that redeemed the token
+	RevokedAt    *time.Time
+}
+
+// AuditEntry is one administrative action in the audit log.
+type AuditEntry struct {
+	Seq    uint64
+	At     time.Time
+	Actor  string // ID of the API token that performed the action
+	Action string // e.g. agent_revoked
+	Target string // ID of the object acted on
+	Detail map[string]string
+}
+
+// Webhook is an outbound webhook subscription.
+type Webhook struct {
+	ID        string
+	URL       string
+	Events    []string // event types to deliver; empty means all
+	Format    WebhookFormat
+	Secret    string // HMAC-SHA256 signing key
+	CreatedAt time.Time
+}
+
+// WebhookFormat selects the payload shape sent to a webhook.
+type WebhookFormat string
+
+const (
+	WebhookFormatJSON    WebhookFormat = "json"    // the raw event envelope
+	WebhookFormatSlack   WebhookFormat = "slack"   // Slack incoming-webhook message
+	WebhookFormatDiscord WebhookFormat = "discord" // Discord webhook message
+)
+
+// WebhookDelivery is one attempt to deliver an event to a webhook.
+type WebhookDelivery struct {
+	Seq        uint64
+	WebhookID  string
+	EventID    uint64
+	EventType  string
+	Attempt    int
+	StatusCode int    // 0 if no response was received
+	Error      string // empty on success
+	Duration   time.Duration
+	At         time.Time
+}
+
+// DeadLetter is an event delivery that failed after every retry.
+type DeadLetter struct {
+	ID        string
+	WebhookID string
+	EventID   uint64
+	EventType string
+	Payload   []byte // the JSON event envelope
+	Attempts  int
+	LastError string
+	FailedAt  time.Time
+}
+
+// OutboxDelivery is an event delivery to a webhook that has neither succeeded
+// nor been dead-lettered yet. It is stored before the first attempt so that
+// deliveries survive a tracker restart.
+type OutboxDelivery struct {
+	ID        string
+	WebhookID string
+	EventID   uint64
+	EventType string
+	Payload   []byte // the JSON event envelope
+	Attempts  int    // attempts made so far
+	LastError string
+	NextAt    time.Time // when the next attempt is due
+	CreatedAt time.Time
+}
+
 // Sentinel errors.
 var (
 	ErrNotFound       = storageError("not found")
 	ErrDuplicateProof = storageError("duplicate proof")
+	ErrTokenUsed      = storageError("token already used")
+	ErrNotEmpty       = storageError("store is not empty")
+	ErrAgentExists    = storageError("agent already exists")
+	ErrChainConflict  = storageError("proof chain linkage invalid")
+	ErrKeyRotated     = storageError("proof not signed by the agent's current key")
 )
 
 type storageError string
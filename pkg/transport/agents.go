@@ -0,0 +1,75 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Agent is an agent in the tracker's trust store.
+type Agent struct {
+	AgentID      string
+	ClusterAlias string
+	PublicKey    string // hex-encoded Ed25519 public key, current
+	KeyHistory   []RetiredKey
+	Status       string // pending, active or revoked
+	EnrolledAt   time.Time
+	RevokedAt    *time.Time
+}
+
+// RetiredKey is a public key an agent signed with before rotating it.
+type RetiredKey struct {
+	PublicKey       string
+	RetiredAt       time.Time
+	RotationProofID string
+}
+
+// ListAgents returns every agent the tracker knows.
+func (c *TrackerClient) ListAgents(ctx context.Context) ([]Agent, error) {
+	var agents []Agent
+	return agents, c.do(ctx, http.MethodGet, "/api/v1/agents", nil, &agents)
+}
+
+// EnrollAgent registers an agent with the given public key, in any encoding
+// proof.DecodePublicKey accepts. The agent is pending until approved.
+// Returns ErrConflict if the agent ID is already enrolled.
+func (c *TrackerClient) EnrollAgent(ctx context.Context, agentID, clusterAlias, publicKey string) error {
+	req := map[string]string{"agent_id": agentID, "cluster_alias": clusterAlias, "public_key": publicKey}
+	return c.do(ctx, http.MethodPost, "/api/v1/agents/enroll", req, nil)
+}
+
+// ApproveAgent makes a pending or revoked agent active.
+func (c *TrackerClient) ApproveAgent(ctx context.Context, agentID string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/agents/"+url.PathEscape(agentID)+"/approve", nil, nil)
+}
+
+// RevokeOptions selects the point in an agent's chain from which its proofs
+// are no longer trusted. Set at most one of FromProofID and FromTime; the zero
+// value revokes the agent without touching its past proofs.
+type RevokeOptions struct {
+	FromProofID string
+	FromTime    time.Time // proofs received by the tracker at or after this time
+	Reason      string
+}
+
+// RevokeResult reports what revoking an agent undid.
+type RevokeResult struct {
+	Status            string   `json:"status"`
+	InvalidatedProofs []string `json:"invalidated_proofs"`
+	CoinsReversed     int      `json:"coins_reversed"`
+}
+
+// RevokeAgent stops trusting an agent. Proofs from the point given in opts
+// onward are marked invalid and their coins are reversed.
+func (c *TrackerClient) RevokeAgent(ctx context.Context, agentID string, opts RevokeOptions) (*RevokeResult, error) {
+	req := map[string]any{"from_proof_id": opts.FromProofID, "reason": opts.Reason}
+	if !opts.FromTime.IsZero() {
+		req["from_time"] = opts.FromTime
+	}
+	var res RevokeResult
+	if err := c.do(ctx, http.MethodPost, "/api/v1/agents/"+url.PathEscape(agentID)+"/revoke", req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
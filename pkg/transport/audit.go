@@ -0,0 +1,24 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// AuditEntry is one administrative action in the tracker's audit log.
+type AuditEntry struct {
+	Seq    uint64
+	At     time.Time
+	Actor  string // ID of the API token that performed the action
+	Action string
+	Target string
+	Detail map[string]string
+}
+
+// ListAudit returns the most recent audit log entries, newest-first.
+func (c *TrackerClient) ListAudit(ctx context.Context, limit int) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	return entries, c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/audit?limit=%d", limit), nil, &entries)
+}
@@ -0,0 +1,54 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ImportResult reports what importing a bundle loaded.
+type ImportResult struct {
+	Farm         string `json:"farm"`
+	SignerKey    string `json:"signer_key"` // key of the tracker that exported the bundle
+	Agents       int    `json:"agents"`
+	Proofs       int    `json:"proofs"`
+	LedgerEvents int    `json:"ledger_events"`
+	// PepperImported is false for a bundle without the exporting tracker's
+	// actor pepper; the importing tracker then keeps its own.
+	PepperImported bool `json:"pepper_imported"`
+}
+
+// Export downloads a bundle of the tracker's agents, proof chains and
+// ledger, signed by the tracker, into w.
+func (c *TrackerClient) Export(ctx context.Context, w io.Writer) error {
+	resp, _, err := c.send(ctx, http.MethodGet, "/api/v1/export", nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("transport: download bundle: %w", err)
+	}
+	return nil
+}
+
+// Import uploads a bundle to an empty tracker, which checks that it was
+// signed by signerKey, the exporting tracker's public key, and verifies every
+// chain in it before storing anything. A tracker that already has history
+// fails with ErrConflict.
+func (c *TrackerClient) Import(ctx context.Context, r io.Reader, signerKey string) (*ImportResult, error) {
+	path := "/api/v1/import?" + url.Values{"signer_key": {signerKey}}.Encode()
+	resp, _, err := c.send(ctx, http.MethodPost, path, r, "application/gzip")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var res ImportResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("transport: decode response: %w", err)
+	}
+	return &res, nil
+}
@@ -0,0 +1,57 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors an *APIError matches with errors.Is, by HTTP status.
+var (
+	ErrBadRequest   = errors.New("transport: bad request")         // 400
+	ErrUnauthorized = errors.New("transport: unauthorized")        // 401
+	ErrForbidden    = errors.New("transport: forbidden")           // 403
+	ErrNotFound     = errors.New("transport: not found")           // 404
+	ErrConflict     = errors.New("transport: conflict")            // 409
+	ErrUnavailable  = errors.New("transport: tracker unavailable") // 429, 502, 503, 504
+)
+
+// APIError is a non-2xx response from the tracker. Message is the error
+// the tracker gave in its {"error": ...} body, if any.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("transport: tracker returned %d", e.StatusCode)
+	}
+	return fmt.Sprintf("transport: tracker returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Is matches e against the Err* values for its status code.
+func (e *APIError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return target == ErrBadRequest
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusForbidden:
+		return target == ErrForbidden
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	}
+	return e.transient() && target == ErrUnavailable
+}
+
+// transient reports whether the request may succeed if retried.
+func (e *APIError) transient() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Event types on the tracker's live stream.
+const (
+	EventProofAccepted       = "proof_accepted"
+	EventCoinsAwarded        = "coins_awarded"
+	EventAgentEnrolled       = "agent_enrolled"
+	EventUpgradePurchased    = "upgrade_purchased"
+	EventAchievementUnlocked = "achievement_unlocked"
+	EventStreakBroken        = "streak_broken"
+	EventRewardReversed      = "reward_reversed"
+)
+
+// ProofAccepted is the payload of a proof_accepted event.
+type ProofAccepted struct {
+	ProofID      string `json:"proof_id"`
+	AgentID      string `json:"agent_id"`
+	ClusterAlias string `json:"cluster_alias"`
+	Category     string `json:"category"`
+	Description  string `json:"description"`
+	Status       string `json:"status"`
+	Coins        int    `json:"coins"`
+}
+
+// Event is an event from the tracker's live stream. Data is its payload,
+// whose shape depends on Type; see the tracker's events package.
+type Event struct {
+	ID   uint64
+	Type string
+	Data json.RawMessage
+}
+
+// StreamEvents follows the tracker's Server-Sent Events stream, calling fn
+// for each event after the one with ID after, of the given types or all if
+// none are given. It returns when ctx is done, fn returns an error or the
+// stream breaks. It does not reconnect: callers resume with the ID of the
+// last event they saw.
+func (c *TrackerClient) StreamEvents(ctx context.Context, after uint64, types []string, fn func(Event) error) error {
+	q := url.Values{}
+	if after > 0 {
+		q.Set("last_event_id", strconv.FormatUint(after, 10))
+	}
+	if len(types) > 0 {
+		q.Set("types", strings.Join(types, ","))
+	}
+	path := "/api/v1/events?" + q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("transport: build request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	// The stream outlives the client's request timeout; ctx bounds it.
+	stream := *c.httpClient
+	stream.Timeout = 0
+	resp, err := stream.Do(req)
+	if err != nil {
+		return fmt.Errorf("transport: GET %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return readAPIError(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	var ev Event
+	var data bytes.Buffer
+	for scanner.Scan() {
+		line := scanner.Text()
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "":
+			if line != "" {
+				continue // a comment, such as the keep-alive
+			}
+			if data.Len() == 0 {
+				continue
+			}
+			ev.Data = json.RawMessage(bytes.Clone(data.Bytes()))
+			if err := fn(ev); err != nil {
+				return err
+			}
+			ev, data = Event{}, bytes.Buffer{}
+		case "id":
+			ev.ID, _ = strconv.ParseUint(value, 10, 64)
+		case "event":
+			ev.Type = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("transport: read event stream: %w", err)
+	}
+	return fmt.Errorf("transport: event stream closed by the tracker")
+}
@@ -0,0 +1,144 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/farmops/farmops/pkg/farm"
+	"github.com/farmops/farmops/pkg/scoring"
+)
+
+// Farm is the tracker's summary of the farm.
+type Farm struct {
+	Name         string
+	TotalCoins   int
+	CurrentCoins int // total minus spent
+	StreakDays   int
+	LastActiveAt *time.Time
+	UpdatedAt    time.Time
+}
+
+// FarmStatus is the farm summary together with its per-category stats.
+type FarmStatus struct {
+	Farm
+	Categories map[string]*farm.CategoryStats
+}
+
+// Profile is the farm's public profile: aggregate stats only.
+type Profile struct {
+	FarmName     string `json:"farm_name"`
+	TotalCoins   int    `json:"total_coins"`
+	CurrentCoins int    `json:"current_coins"`
+	StreakDays   int    `json:"streak_days"`
+}
+
+// GetFarm returns the farm summary.
+func (c *TrackerClient) GetFarm(ctx context.Context) (*Farm, error) {
+	var f Farm
+	if err := c.do(ctx, http.MethodGet, "/api/v1/farm", nil, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Status returns the farm summary and its per-category stats.
+func (c *TrackerClient) Status(ctx context.Context) (*FarmStatus, error) {
+	f, err := c.GetFarm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := c.FarmStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &FarmStatus{Farm: *f, Categories: stats}, nil
+}
+
+// GetWorld fetches the tracker's farm world projection.
+func (c *TrackerClient) GetWorld(ctx context.Context) (*farm.World, error) {
+	var world farm.World
+	if err := c.do(ctx, http.MethodGet, "/api/v1/farm/world", nil, &world); err != nil {
+		return nil, err
+	}
+	return &world, nil
+}
+
+// FarmStats returns the per-category proof and coin counts.
+func (c *TrackerClient) FarmStats(ctx context.Context) (map[string]*farm.CategoryStats, error) {
+	var stats struct {
+		Categories map[string]*farm.CategoryStats `json:"categories"`
+	}
+	return stats.Categories, c.do(ctx, http.MethodGet, "/api/v1/farm/stats", nil, &stats)
+}
+
+// ListLedger returns up to limit farm events with a sequence number above
+// after, oldest first. A limit of zero uses the tracker's default.
+func (c *TrackerClient) ListLedger(ctx context.Context, after uint64, limit int) ([]*farm.Event, error) {
+	q := url.Values{}
+	if after > 0 {
+		q.Set("after", strconv.FormatUint(after, 10))
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var events []*farm.Event
+	return events, c.do(ctx, http.MethodGet, "/api/v1/ledger?"+q.Encode(), nil, &events)
+}
+
+// Plant sows a crop of the given proof category on a plot and returns the
+// updated world. A move the farm rules forbid fails with ErrConflict.
+func (c *TrackerClient) Plant(ctx context.Context, plot int, category string) (*farm.World, error) {
+	var world farm.World
+	req := map[string]string{"category": category}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/farm/plots/"+strconv.Itoa(plot)+"/plant", req, &world); err != nil {
+		return nil, err
+	}
+	return &world, nil
+}
+
+// Harvest harvests the crop on a plot and returns the updated world.
+func (c *TrackerClient) Harvest(ctx context.Context, plot int) (*farm.World, error) {
+	var world farm.World
+	if err := c.do(ctx, http.MethodPost, "/api/v1/farm/plots/"+strconv.Itoa(plot)+"/harvest", nil, &world); err != nil {
+		return nil, err
+	}
+	return &world, nil
+}
+
+// ShopItems returns the shop catalog.
+func (c *TrackerClient) ShopItems(ctx context.Context) ([]farm.Item, error) {
+	var items []farm.Item
+	return items, c.do(ctx, http.MethodGet, "/api/v1/shop/items", nil, &items)
+}
+
+// Purchase buys a shop item with the farm's balance and returns the updated
+// world.
+func (c *TrackerClient) Purchase(ctx context.Context, item string) (*farm.World, error) {
+	var world farm.World
+	if err := c.do(ctx, http.MethodPost, "/api/v1/shop/purchase", map[string]string{"item": item}, &world); err != nil {
+		return nil, err
+	}
+	return &world, nil
+}
+
+// PublicProfile returns the farm's public profile. It needs no token.
+func (c *TrackerClient) PublicProfile(ctx context.Context) (*Profile, error) {
+	var p Profile
+	if err := c.do(ctx, http.MethodGet, "/api/v1/public/profile", nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ScoringConfig returns the scoring parameters the tracker rewards proofs
+// with. It needs no token.
+func (c *TrackerClient) ScoringConfig(ctx context.Context) (*scoring.Config, error) {
+	var cfg scoring.Config
+	if err := c.do(ctx, http.MethodGet, "/api/v1/scoring/config", nil, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// JoinToken is a single-use agent join token on the tracker. Token holds the
+// secret token string and is only set in the response to CreateJoinToken.
+type JoinToken struct {
+	ID           string
+	Name         string
+	ClusterAlias string
+	AutoApprove  bool
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	UsedBy       string
+	RevokedAt    *time.Time
+	Token        string
+}
+
+// JoinResult is the tracker's answer to an agent joining with a join token.
+// Token is the agent-scoped API token the agent submits proofs with.
+type JoinResult struct {
+	AgentID      string `json:"agent_id"`
+	ClusterAlias string `json:"cluster_alias"`
+	Status       string `json:"status"` // active, or pending approval
+	TokenID      string `json:"token_id"`
+	Token        string `json:"token"`
+	ActorPepper  string `json:"actor_pepper"` // hex-encoded; see ActorPepper
+}
+
+// CreateJoinToken mints a join token. A non-empty clusterAlias is assigned to
+// the joining agent; autoApprove enrolls it as active. expiresIn of zero uses
+// the tracker's default lifetime.
+func (c *TrackerClient) CreateJoinToken(ctx context.Context, name, clusterAlias string, autoApprove bool, expiresIn time.Duration) (*JoinToken, error) {
+	req := map[string]any{"name": name, "cluster_alias": clusterAlias, "auto_approve": autoApprove}
+	if expiresIn > 0 {
+		req["expires_in"] = expiresIn.String()
+	}
+	var t JoinToken
+	if err := c.do(ctx, http.MethodPost, "/api/v1/join-tokens", req, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListJoinTokens returns every join token the tracker knows, without secrets.
+func (c *TrackerClient) ListJoinTokens(ctx context.Context) ([]JoinToken, error) {
+	var tokens []JoinToken
+	return tokens, c.do(ctx, http.MethodGet, "/api/v1/join-tokens", nil, &tokens)
+}
+
+// RevokeJoinToken disables an unused join token.
+func (c *TrackerClient) RevokeJoinToken(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/join-tokens/"+url.PathEscape(id)+"/revoke", nil, nil)
+}
+
+// Join enrolls an agent using a join token in place of the client's API key.
+// clusterAlias may be empty if the join token presets one.
+func (c *TrackerClient) Join(ctx context.Context, joinToken, agentID, clusterAlias, publicKeyHex string) (*JoinResult, error) {
+	jc := *c
+	jc.apiKey = joinToken
+	req := map[string]string{"agent_id": agentID, "cluster_alias": clusterAlias, "public_key": publicKeyHex}
+	var res JoinResult
+	if err := jc.do(ctx, http.MethodPost, "/api/v1/agents/join", req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ActorPepper fetches the tracker's actor hashing pepper, which agents key
+// the actor hashes in their proofs with. It needs an agent or admin token.
+func (c *TrackerClient) ActorPepper(ctx context.Context) ([]byte, error) {
+	var resp struct {
+		ActorPepper string `json:"actor_pepper"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/actor-pepper", nil, &resp); err != nil {
+		return nil, err
+	}
+	pepper, err := hex.DecodeString(resp.ActorPepper)
+	if err != nil || len(pepper) == 0 {
+		return nil, fmt.Errorf("transport: invalid actor pepper from tracker")
+	}
+	return pepper, nil
+}
@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/farmops/farmops/pkg/proof"
+	"github.com/farmops/farmops/pkg/scoring"
+)
+
+// StoredProof is a proof as the tracker holds it, with its scoring.
+type StoredProof struct {
+	*proof.FarmProof
+	CoinsAwarded  int
+	Scoring       scoring.Result
+	ReceivedAt    time.Time
+	InvalidatedAt *time.Time // set once the proof is no longer trusted
+	InvalidReason string
+}
+
+// ListProofs returns up to limit proofs. With an agentID it walks that
+// agent's chain oldest first, starting after the proof ID after; without one
+// it returns the most recent proofs from all agents. A limit of zero uses the
+// tracker's default.
+func (c *TrackerClient) ListProofs(ctx context.Context, agentID, after string, limit int) ([]*StoredProof, error) {
+	q := url.Values{}
+	if agentID != "" {
+		q.Set("agent_id", agentID)
+	}
+	if after != "" {
+		q.Set("after", after)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var proofs []*StoredProof
+	return proofs, c.do(ctx, http.MethodGet, "/api/v1/proofs?"+q.Encode(), nil, &proofs)
+}
+
+// GetProof returns a stored proof by ID.
+func (c *TrackerClient) GetProof(ctx context.Context, proofID string) (*StoredProof, error) {
+	var p StoredProof
+	if err := c.do(ctx, http.MethodGet, "/api/v1/proofs/"+url.PathEscape(proofID), nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
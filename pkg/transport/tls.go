@@ -0,0 +1,43 @@
+package transport
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/farmops/farmops/pkg/pki"
+)
+
+// TLSFiles names the PEM files used to reach a tracker over TLS.
+type TLSFiles struct {
+	CAFile   string // CA bundle that signed the tracker's certificate; empty uses the system roots
+	CertFile string // client certificate for mutual TLS
+	KeyFile  string // client certificate key
+}
+
+// Config builds a client TLS config from the files. It returns nil if no
+// files are set, leaving the HTTP client's defaults in place.
+func (f TLSFiles) Config() (*tls.Config, error) {
+	if f == (TLSFiles{}) {
+		return nil, nil
+	}
+	if (f.CertFile == "") != (f.KeyFile == "") {
+		return nil, fmt.Errorf("transport: a client certificate needs both a cert and a key file")
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if f.CAFile != "" {
+		pool, err := pki.LoadCertPool(f.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("transport: %w", err)
+		}
+		cfg.RootCAs = pool
+	}
+	if f.CertFile != "" {
+		pair, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("transport: load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	return cfg, nil
+}
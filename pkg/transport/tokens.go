@@ -0,0 +1,65 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Token is a scoped API token on the tracker. Token holds the secret token
+// string and is only set in the responses to CreateToken and RotateToken.
+type Token struct {
+	ID        string
+	Name      string
+	Scope     string // agent, admin or read
+	AgentID   string
+	CreatedAt time.Time
+	RotatedAt *time.Time
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+	Token     string
+}
+
+// CreateToken mints a token with the given scope. agentID is required for
+// agent tokens; expiresIn of zero means the token does not expire.
+func (c *TrackerClient) CreateToken(ctx context.Context, name, scope, agentID string, expiresIn time.Duration) (*Token, error) {
+	req := map[string]string{"name": name, "scope": scope, "agent_id": agentID}
+	if expiresIn > 0 {
+		req["expires_in"] = expiresIn.String()
+	}
+	var t Token
+	if err := c.do(ctx, http.MethodPost, "/api/v1/tokens", req, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTokens returns every token the tracker knows, without secrets.
+func (c *TrackerClient) ListTokens(ctx context.Context) ([]Token, error) {
+	var tokens []Token
+	return tokens, c.do(ctx, http.MethodGet, "/api/v1/tokens", nil, &tokens)
+}
+
+// CurrentToken describes the token the client authenticates with.
+func (c *TrackerClient) CurrentToken(ctx context.Context) (*Token, error) {
+	var t Token
+	if err := c.do(ctx, http.MethodGet, "/api/v1/tokens/self", nil, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RotateToken replaces a token's secret and returns the new token string.
+func (c *TrackerClient) RotateToken(ctx context.Context, id string) (*Token, error) {
+	var t Token
+	if err := c.do(ctx, http.MethodPost, "/api/v1/tokens/"+url.PathEscape(id)+"/rotate", nil, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RevokeToken permanently disables a token.
+func (c *TrackerClient) RevokeToken(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/tokens/"+url.PathEscape(id)+"/revoke", nil, nil)
+}
@@ -1,14 +1,17 @@
-// Package transport provides client and server helpers for agent↔tracker
-// communication. Phase 0 uses a simple HTTP/JSON transport. gRPC will be
-// added in Phase 1 once proto generation is wired into the build.
+// Package transport provides the client for the Stats Tracker's HTTP/JSON
+// API, used by agents and farmctl, and TLS helpers for both ends. gRPC will
+// be added once proto generation is wired into the build.
 package transport
 
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/farmops/farmops/pkg/proof"
@@ -21,7 +24,8 @@ type SubmitResponse struct {
 	CoinsAwarded    int    `json:"coins_awarded"`
 }
 
-// TrackerClient submits proofs to a Stats Tracker over HTTP.
+// TrackerClient is a typed client for the Stats Tracker's HTTP API, used by
+// agents to submit proofs and by farmctl for everything else.
 type TrackerClient struct {
 	baseURL    string
 	apiKey     string
@@ -29,44 +33,153 @@ type TrackerClient struct {
 }
 
 // NewTrackerClient creates a new client targeting the given tracker base URL.
-// apiKey is the shared secret used to authenticate the agent.
+// apiKey is the token used to authenticate with the tracker.
 func NewTrackerClient(baseURL, apiKey string) *TrackerClient {
+	return NewTrackerClientTLS(baseURL, apiKey, nil)
+}
+
+// NewTrackerClientTLS is like NewTrackerClient but connects with the given TLS
+// config, e.g. to present a client certificate for mutual TLS. A nil config
+// uses the defaults.
+func NewTrackerClientTLS(baseURL, apiKey string, tlsConfig *tls.Config) *TrackerClient {
+	client := &http.Client{Timeout: 15 * time.Second}
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client.Transport = transport
+	}
 	return &TrackerClient{
-		baseURL: baseURL,
-		apiKey:  apiKey,
-		httpClient: &http.Client{
-			Timeout: 15 * time.Second,
-		},
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		httpClient: client,
 	}
 }
 
-// SubmitProof sends a FarmProof to the Stats Tracker.
+// SubmitProof sends a FarmProof to the Stats Tracker. It is not retried: a
+// resubmitted proof is rejected as a duplicate.
 func (c *TrackerClient) SubmitProof(ctx context.Context, p *proof.FarmProof) (*SubmitResponse, error) {
-	body, err := json.Marshal(p)
+	var result SubmitResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/proofs", p, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// LatestProof returns the head of the agent's proof chain on the tracker,
+// or nil if the agent has not submitted a proof yet.
+func (c *TrackerClient) LatestProof(ctx context.Context, agentID string) (*proof.FarmProof, error) {
+	var p *proof.FarmProof
+	if err := c.do(ctx, http.MethodGet, "/api/v1/agents/"+url.PathEscape(agentID)+"/proofs/latest", nil, &p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Ready reports whether the tracker is up and its storage is readable.
+func (c *TrackerClient) Ready(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/readyz", nil, nil)
+}
+
+// Idempotent requests that fail with a network error or an overloaded
+// tracker are retried up to maxAttempts times, waiting retryBackoff before
+// the first retry and twice as long before each further one.
+const (
+	maxAttempts  = 3
+	retryBackoff = 250 * time.Millisecond
+)
+
+// do sends an authenticated JSON request to the tracker and decodes the
+// response into out (if non-nil). Non-2xx responses are returned as
+// *APIError carrying the tracker's error message. GET, PUT and DELETE
+// requests are retried on transient failures.
+func (c *TrackerClient) do(ctx context.Context, method, path string, in, out any) error {
+	var data []byte
+	if in != nil {
+		var err error
+		if data, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("transport: marshal request: %w", err)
+		}
+	}
+
+	attempts := 1
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		attempts = maxAttempts
+	}
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := c.try(ctx, method, path, data, out)
+		if err == nil || !retry || attempt == attempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// try makes one attempt at a JSON request and reports whether a failure is
+// transient.
+func (c *TrackerClient) try(ctx context.Context, method, path string, data []byte, out any) (bool, error) {
+	var body io.Reader
+	contentType := ""
+	if data != nil {
+		body, contentType = bytes.NewReader(data), "application/json"
+	}
+	resp, retry, err := c.send(ctx, method, path, body, contentType)
 	if err != nil {
-		return nil, fmt.Errorf("transport: marshal proof: %w", err)
+		return retry, err
 	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("transport: decode response: %w", err)
+	}
+	return false, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/proofs", bytes.NewReader(body))
+// send makes one authenticated request and returns the response if it
+// succeeded; the caller closes its body. Non-2xx responses are returned as
+// *APIError. It reports whether a failure is transient.
+func (c *TrackerClient) send(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Response, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
 	if err != nil {
-		return nil, fmt.Errorf("transport: build request: %w", err)
+		return nil, false, fmt.Errorf("transport: build request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("transport: submit proof: %w", err)
+		return nil, ctx.Err() == nil, fmt.Errorf("transport: %s %s: %w", method, path, err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("transport: tracker returned %d", resp.StatusCode)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		apiErr := readAPIError(resp)
+		return nil, apiErr.transient(), apiErr
 	}
+	return resp, false, nil
+}
 
-	var result SubmitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("transport: decode response: %w", err)
+// readAPIError builds the *APIError for a failed response, with the message
+// from the tracker's {"error": ...} body if there is one.
+func readAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	var e struct {
+		Error string `json:"error"`
 	}
-	return &result, nil
+	if json.NewDecoder(resp.Body).Decode(&e) == nil {
+		apiErr.Message = e.Error
+	}
+	return apiErr
 }
@@ -0,0 +1,90 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/farmops/farmops/pkg/transport"
+)
+
+func TestTrackerClient_RetriesIdempotentCalls(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"AgentID":"agent-1","Status":"active"}]`))
+	}))
+	defer srv.Close()
+
+	agents, err := transport.NewTrackerClient(srv.URL, "k").ListAgents(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agents) != 1 || agents[0].AgentID != "agent-1" || calls.Load() != 2 {
+		t.Errorf("got %+v after %d calls, want agent-1 after 2", agents, calls.Load())
+	}
+}
+
+func TestTrackerClient_MapsErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/api/v1/agents/ghost/approve":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"agent not found"}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	client := transport.NewTrackerClient(srv.URL, "k")
+
+	err := client.ApproveAgent(context.Background(), "ghost")
+	var apiErr *transport.APIError
+	if !errors.Is(err, transport.ErrNotFound) || !errors.As(err, &apiErr) || apiErr.Message != "agent not found" {
+		t.Errorf("approve unknown agent: got %v, want ErrNotFound with the tracker's message", err)
+	}
+
+	// A purchase is not idempotent and must be sent once.
+	calls.Store(0)
+	if _, err := client.Purchase(context.Background(), "barn"); !errors.Is(err, transport.ErrUnavailable) {
+		t.Errorf("purchase: got %v, want ErrUnavailable", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("purchase was sent %d times, want 1", calls.Load())
+	}
+}
+
+func TestTrackerClient_StreamEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("last_event_id") != "6" || r.URL.Query().Get("types") != "coins_awarded" {
+			t.Errorf("query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "retry: 3000\n\n: keep-alive\n\n")
+		fmt.Fprint(w, "id: 7\nevent: coins_awarded\ndata: {\"coins\":10}\n\n")
+		fmt.Fprint(w, "id: 8\nevent: coins_awarded\ndata: {\"coins\":15}\n\n")
+	}))
+	defer srv.Close()
+
+	var got []transport.Event
+	err := transport.NewTrackerClient(srv.URL, "k").StreamEvents(context.Background(), 6, []string{"coins_awarded"},
+		func(ev transport.Event) error {
+			got = append(got, ev)
+			return nil
+		})
+	if err == nil {
+		t.Error("a closed stream returned no error")
+	}
+	if len(got) != 2 || got[0].ID != 7 || got[1].Type != "coins_awarded" || string(got[1].Data) != `{"coins":15}` {
+		t.Errorf("events: %+v", got)
+	}
+}
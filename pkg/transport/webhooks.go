@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Webhook is an outbound webhook subscription on the tracker.
+type Webhook struct {
+	ID        string
+	URL       string
+	Events    []string
+	Format    string
+	Secret    string // only returned when the webhook is created
+	CreatedAt time.Time
+}
+
+// WebhookDelivery is one attempt by the tracker to deliver an event.
+type WebhookDelivery struct {
+	Seq        uint64
+	WebhookID  string
+	EventID    uint64
+	EventType  string
+	Attempt    int
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+	At         time.Time
+}
+
+// DeadLetter is an event delivery that failed after every retry.
+type DeadLetter struct {
+	ID        string
+	WebhookID string
+	EventID   uint64
+	EventType string
+	Attempts  int
+	LastError string
+	FailedAt  time.Time
+}
+
+// CreateWebhook subscribes url to the given event types (all if empty).
+// format is json, slack or discord; an empty secret is generated by the tracker.
+func (c *TrackerClient) CreateWebhook(ctx context.Context, hookURL string, events []string, format, secret string) (*Webhook, error) {
+	req := map[string]any{"url": hookURL, "events": events, "format": format, "secret": secret}
+	var hook Webhook
+	if err := c.do(ctx, http.MethodPost, "/api/v1/webhooks", req, &hook); err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// ListWebhooks returns the tracker's webhook subscriptions without their secrets.
+func (c *TrackerClient) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var hooks []Webhook
+	return hooks, c.do(ctx, http.MethodGet, "/api/v1/webhooks", nil, &hooks)
+}
+
+// DeleteWebhook removes a webhook subscription.
+func (c *TrackerClient) DeleteWebhook(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/webhooks/"+url.PathEscape(id), nil, nil)
+}
+
+// ListWebhookDeliveries returns the most recent delivery attempts for a webhook, newest-first.
+func (c *TrackerClient) ListWebhookDeliveries(ctx context.Context, id string, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	path := fmt.Sprintf("/api/v1/webhooks/%s/deliveries?limit=%d", url.PathEscape(id), limit)
+	return deliveries, c.do(ctx, http.MethodGet, path, nil, &deliveries)
+}
+
+// ListDeadLetters returns deliveries that exhausted their retries.
+func (c *TrackerClient) ListDeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	var letters []DeadLetter
+	return letters, c.do(ctx, http.MethodGet, "/api/v1/webhooks/dead-letters", nil, &letters)
+}
+
+// RedeliverDeadLetter asks the tracker to retry a dead letter.
+func (c *TrackerClient) RedeliverDeadLetter(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/webhooks/dead-letters/"+url.PathEscape(id)+"/redeliver", nil, nil)
+}
@@ -0,0 +1,11 @@
+// Package pluginv1 holds the FarmPlugin contract that out-of-process plugins
+// implement. `make proto` generates its Go bindings into this package.
+package pluginv1
+
+import _ "embed"
+
+// Proto is the source of plugin.proto, for tools that generate plugin
+// projects against this version of the contract.
+//
+//go:embed plugin.proto
+var Proto []byte